@@ -7,14 +7,66 @@ import (
 
 type Client interface {
 	// User
+	// Login returns an error wrapping an *api.ChallengeError when the user has to
+	// supply a second factor, which is done with LoginWithCode, or an *api.PasswordExpiredError
+	// when the password expired, which is changed with LoginWithNewPassword
 	Login(username, password string) (*jwt.TokenPair, error)
+	// LoginWithCode completes the challenge of a login with a code from an authenticator or a recovery code.
+	// It returns an error wrapping an *api.PasswordExpiredError when the password expired, like Login does.
+	LoginWithCode(challenge, code string) (*jwt.TokenPair, error)
+	// LoginWithNewPassword replaces the expired password of a login with the token of the *api.PasswordExpiredError
+	LoginWithNewPassword(token, password string) (*jwt.TokenPair, error)
 	Register(username, password, email string) error
 	Verify(token string) (*jwt.Claims, error)
+	// IsRevoked asks Aurum whether a token, which Verify accepts by itself, has been revoked
+	IsRevoked(claims *jwt.Claims) (bool, error)
+	Logout(tp *jwt.TokenPair) error
+	// Refresh rotates the token pair, the old refresh token can't be used anymore afterwards
+	Refresh(tp *jwt.TokenPair) error
 	GetUserInfo(tp *jwt.TokenPair) (*models.User, error)
 	UpdateUser(tp *jwt.TokenPair, user *models.User) (*models.User, error)
+	// ForgotPassword has Aurum mail a password reset token to the user, which ResetPassword takes
+	ForgotPassword(username string) error
+	ResetPassword(token, password string) error
+	// GetPasswordPolicy gets the rules the passwords of new users have to follow
+	GetPasswordPolicy() (*models.PasswordPolicy, error)
+	// GetUserPasswordPolicy gets the rules a new password of the user has to follow, including those of their groups
+	GetUserPasswordPolicy(tp *jwt.TokenPair) (*models.PasswordPolicy, error)
+	// VerifyEmail confirms an email address with the token Aurum mailed to it
+	VerifyEmail(token string) error
+	ResendVerification(tp *jwt.TokenPair) error
+
+	// User management (admin only)
+	GetUsers(tp *jwt.TokenPair, query models.UserQuery) (*models.UserPage, error)
+	LookupUser(tp *jwt.TokenPair, user string) (*models.User, error)
+	AdminUpdateUser(tp *jwt.TokenPair, user *models.User) (*models.User, error)
+	RemoveUser(tp *jwt.TokenPair, user string) (*models.RemovalSummary, error)
+	GetLockouts(tp *jwt.TokenPair) ([]models.Lockout, error)
+	GetUserLockout(tp *jwt.TokenPair, user string) (*models.Lockout, error)
+	UnlockUser(tp *jwt.TokenPair, user string) error
+	UnlockAddress(tp *jwt.TokenPair, address string) error
+
+	// Sessions
+	GetSessions(tp *jwt.TokenPair) ([]models.Session, error)
+	RevokeSession(tp *jwt.TokenPair, id string) error
+	RevokeOtherSessions(tp *jwt.TokenPair) error
+
+	// Session management (admin only)
+	GetUserSessions(tp *jwt.TokenPair, user string) ([]models.Session, error)
+	RevokeUserSession(tp *jwt.TokenPair, user, id string) error
+	RevokeUserSessions(tp *jwt.TokenPair, user string) error
+
+	// Two-factor authentication
+	EnrollTOTP(tp *jwt.TokenPair) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(tp *jwt.TokenPair, code string) (*models.RecoveryCodes, error)
+	DisableTOTP(tp *jwt.TokenPair, code string) error
 
 	// Group
 	AddGroup(tp *jwt.TokenPair, group *models.Group) error
+	GetGroups(tp *jwt.TokenPair, query models.GroupQuery) (*models.GroupPage, error)
+	GetGroup(tp *jwt.TokenPair, group string) (*models.Group, error)
+	UpdateGroup(tp *jwt.TokenPair, group string, update models.GroupUpdate) (*models.Group, error)
+	GetGroupMembers(tp *jwt.TokenPair, group string, query models.MemberQuery) (*models.MemberPage, error)
 	RemoveGroup(tp *jwt.TokenPair, group string) error
 	GetAccess(group, user string) (models.AccessStatus, error)
 	SetAccess(tp *jwt.TokenPair, access models.AccessStatus) error
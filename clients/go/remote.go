@@ -51,6 +51,16 @@ func (a *RemoteClient) Login(username, password string) (*jwt.TokenPair, error)
 	return tp, nil
 }
 
+func (a *RemoteClient) LoginWithCode(challenge, code string) (*jwt.TokenPair, error) {
+	tp, err := api.CompleteChallenge(a.url, challenge, code)
+	return tp, errors.Wrap(err, "login challenge request failed")
+}
+
+func (a *RemoteClient) LoginWithNewPassword(token, password string) (*jwt.TokenPair, error) {
+	tp, err := api.ChangeExpiredPassword(a.url, token, password)
+	return tp, errors.Wrap(err, "expired password change request failed")
+}
+
 func (a *RemoteClient) Register(username, password, email string) error {
 	return errors.Wrap(api.SignUp(a.url, models.User{
 		Username: username,
@@ -63,6 +73,15 @@ func (a *RemoteClient) Verify(token string) (*jwt.Claims, error) {
 	return jwt.VerifyJWT(token, a.pk)
 }
 
+func (a *RemoteClient) IsRevoked(claims *jwt.Claims) (bool, error) {
+	revoked, err := api.IsTokenRevoked(a.url, claims.Id, claims.Family)
+	return revoked, errors.Wrap(err, "IsTokenRevoked api request failed")
+}
+
+func (a *RemoteClient) Logout(tp *jwt.TokenPair) error {
+	return errors.Wrap(api.Logout(a.url, tp), "logout request failed")
+}
+
 func (a *RemoteClient) Refresh(tp *jwt.TokenPair) error {
 	return errors.Wrap(api.Refresh(a.url, tp), "refresh client request failed")
 }
@@ -77,11 +96,146 @@ func (a *RemoteClient) UpdateUser(tp *jwt.TokenPair, user *models.User) (*models
 	return user, errors.Wrap(err, "update user api request failed")
 }
 
+func (a *RemoteClient) ForgotPassword(username string) error {
+	err := api.ForgotPassword(a.url, username)
+	return errors.Wrap(err, "ForgotPassword api request failed")
+}
+
+func (a *RemoteClient) ResetPassword(token, password string) error {
+	err := api.ResetPassword(a.url, token, password)
+	return errors.Wrap(err, "ResetPassword api request failed")
+}
+
+func (a *RemoteClient) GetPasswordPolicy() (*models.PasswordPolicy, error) {
+	policy, err := api.GetPasswordPolicy(a.url)
+	return policy, errors.Wrap(err, "GetPasswordPolicy api request failed")
+}
+
+func (a *RemoteClient) GetUserPasswordPolicy(tp *jwt.TokenPair) (*models.PasswordPolicy, error) {
+	policy, err := api.GetUserPasswordPolicy(a.url, tp)
+	return policy, errors.Wrap(err, "GetUserPasswordPolicy api request failed")
+}
+
+func (a *RemoteClient) VerifyEmail(token string) error {
+	err := api.VerifyEmail(a.url, token)
+	return errors.Wrap(err, "VerifyEmail api request failed")
+}
+
+func (a *RemoteClient) ResendVerification(tp *jwt.TokenPair) error {
+	err := api.ResendVerification(a.url, tp)
+	return errors.Wrap(err, "ResendVerification api request failed")
+}
+
+func (a *RemoteClient) GetUsers(tp *jwt.TokenPair, query models.UserQuery) (*models.UserPage, error) {
+	page, err := api.GetUsers(a.url, tp, query)
+	return page, errors.Wrap(err, "GetUsers api request failed")
+}
+
+func (a *RemoteClient) LookupUser(tp *jwt.TokenPair, user string) (*models.User, error) {
+	u, err := api.LookupUser(a.url, tp, user)
+	return u, errors.Wrap(err, "LookupUser api request failed")
+}
+
+func (a *RemoteClient) AdminUpdateUser(tp *jwt.TokenPair, user *models.User) (*models.User, error) {
+	user, err := api.AdminUpdateUser(a.url, tp, user)
+	return user, errors.Wrap(err, "AdminUpdateUser api request failed")
+}
+
+func (a *RemoteClient) RemoveUser(tp *jwt.TokenPair, user string) (*models.RemovalSummary, error) {
+	summary, err := api.RemoveUser(a.url, tp, user)
+	return summary, errors.Wrap(err, "RemoveUser api request failed")
+}
+
+func (a *RemoteClient) GetLockouts(tp *jwt.TokenPair) ([]models.Lockout, error) {
+	lockouts, err := api.GetLockouts(a.url, tp)
+	return lockouts, errors.Wrap(err, "GetLockouts api request failed")
+}
+
+func (a *RemoteClient) GetUserLockout(tp *jwt.TokenPair, user string) (*models.Lockout, error) {
+	lockout, err := api.GetUserLockout(a.url, tp, user)
+	return lockout, errors.Wrap(err, "GetUserLockout api request failed")
+}
+
+func (a *RemoteClient) UnlockUser(tp *jwt.TokenPair, user string) error {
+	err := api.UnlockUser(a.url, tp, user)
+	return errors.Wrap(err, "UnlockUser api request failed")
+}
+
+func (a *RemoteClient) UnlockAddress(tp *jwt.TokenPair, address string) error {
+	err := api.UnlockAddress(a.url, tp, address)
+	return errors.Wrap(err, "UnlockAddress api request failed")
+}
+
+func (a *RemoteClient) GetSessions(tp *jwt.TokenPair) ([]models.Session, error) {
+	sessions, err := api.GetSessions(a.url, tp)
+	return sessions, errors.Wrap(err, "GetSessions api request failed")
+}
+
+func (a *RemoteClient) RevokeSession(tp *jwt.TokenPair, id string) error {
+	err := api.RevokeSession(a.url, tp, id)
+	return errors.Wrap(err, "RevokeSession api request failed")
+}
+
+func (a *RemoteClient) RevokeOtherSessions(tp *jwt.TokenPair) error {
+	err := api.RevokeOtherSessions(a.url, tp)
+	return errors.Wrap(err, "RevokeOtherSessions api request failed")
+}
+
+func (a *RemoteClient) GetUserSessions(tp *jwt.TokenPair, user string) ([]models.Session, error) {
+	sessions, err := api.GetUserSessions(a.url, tp, user)
+	return sessions, errors.Wrap(err, "GetUserSessions api request failed")
+}
+
+func (a *RemoteClient) RevokeUserSession(tp *jwt.TokenPair, user, id string) error {
+	err := api.RevokeUserSession(a.url, tp, user, id)
+	return errors.Wrap(err, "RevokeUserSession api request failed")
+}
+
+func (a *RemoteClient) RevokeUserSessions(tp *jwt.TokenPair, user string) error {
+	err := api.RevokeUserSessions(a.url, tp, user)
+	return errors.Wrap(err, "RevokeUserSessions api request failed")
+}
+
+func (a *RemoteClient) EnrollTOTP(tp *jwt.TokenPair) (*models.TOTPEnrollment, error) {
+	enrollment, err := api.EnrollTOTP(a.url, tp)
+	return enrollment, errors.Wrap(err, "EnrollTOTP api request failed")
+}
+
+func (a *RemoteClient) ConfirmTOTP(tp *jwt.TokenPair, code string) (*models.RecoveryCodes, error) {
+	codes, err := api.ConfirmTOTP(a.url, tp, code)
+	return codes, errors.Wrap(err, "ConfirmTOTP api request failed")
+}
+
+func (a *RemoteClient) DisableTOTP(tp *jwt.TokenPair, code string) error {
+	err := api.DisableTOTP(a.url, tp, code)
+	return errors.Wrap(err, "DisableTOTP api request failed")
+}
+
 func (a *RemoteClient) AddGroup(tp *jwt.TokenPair, group *models.Group) error {
 	err := api.AddGroup(a.url, tp, group)
 	return errors.Wrap(err, "add group api request failed")
 }
 
+func (a *RemoteClient) GetGroups(tp *jwt.TokenPair, query models.GroupQuery) (*models.GroupPage, error) {
+	page, err := api.GetGroups(a.url, tp, query)
+	return page, errors.Wrap(err, "GetGroups api request failed")
+}
+
+func (a *RemoteClient) GetGroup(tp *jwt.TokenPair, group string) (*models.Group, error) {
+	g, err := api.GetGroup(a.url, tp, group)
+	return g, errors.Wrap(err, "GetGroup api request failed")
+}
+
+func (a *RemoteClient) UpdateGroup(tp *jwt.TokenPair, group string, update models.GroupUpdate) (*models.Group, error) {
+	g, err := api.UpdateGroup(a.url, tp, group, update)
+	return g, errors.Wrap(err, "UpdateGroup api request failed")
+}
+
+func (a *RemoteClient) GetGroupMembers(tp *jwt.TokenPair, group string, query models.MemberQuery) (*models.MemberPage, error) {
+	page, err := api.GetGroupMembers(a.url, tp, group, query)
+	return page, errors.Wrap(err, "GetGroupMembers api request failed")
+}
+
 func (a *RemoteClient) RemoveGroup(tp *jwt.TokenPair, group string) error {
 	err := api.RemoveGroup(a.url, tp, group)
 	return errors.Wrap(err, "remove group api request failed")
@@ -8,6 +8,9 @@ export interface User {
 export interface Group {
     name: string
     allow_registration: string
+    display_name?: string
+    description?: string
+    metadata?: Record<string, string>
 }
 
 export interface GroupWithRole extends Group {
@@ -25,6 +28,12 @@ export enum ErrorCode {
     Duplicate,
     WeakPassword,
     Unauthorized,
+    NotFound,
+    EmailNotVerified,
+    LockedOut,
+    RateLimited,
+    BreachedPassword,
+    PasswordReused,
 }
 
 export interface AurumError {
@@ -106,6 +106,8 @@ export class SingleUserClient {
 
             if (LocalstorageAvailable() && this.tokenpair !== null) {
                 localStorage.setItem(LocalStorageLoginTokenKey, this.tokenpair.login_token)
+                // Refresh tokens are rotated, the old one can't be used anymore
+                localStorage.setItem(LocalStorageRefreshTokenKey, this.tokenpair.refresh_token)
             }
 
             const second = await func(this.tokenpair, ...args);
@@ -120,7 +120,8 @@ export class API {
     }
 
     /**
-     * Uses the refresh token to get a new login token
+     * Uses the refresh token to get a new login token. The refresh token is rotated,
+     * so the old one can't be used anymore.
      * @param tokenPair the tokenpair containing the refresh token used for getting the new login token
      * @returns A new tokenpair or an [ErrorState]
      */
@@ -147,7 +148,7 @@ export class API {
             return [null, ErrorState.ServerError];
         }
 
-        const newTokenPair = new TokenPair(resultObject.login_token, tokenPair.refreshToken);
+        const newTokenPair = new TokenPair(resultObject.login_token, resultObject.refresh_token || tokenPair.refreshToken);
 
         return [newTokenPair, ErrorState.Ok];
     }
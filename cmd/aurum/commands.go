@@ -31,6 +31,14 @@ type getMeMsg struct {
 	user *models.User
 }
 
+type sessionsMsg struct {
+	sessions []models.Session
+}
+
+type sessionsErrMsg struct {
+	err error
+}
+
 func connect() tea.Msg {
 	au, err := aurum.NewRemoteClient(*host)
 	if err != nil {
@@ -72,3 +80,34 @@ func register(au aurum.Client, username, email, password string) tea.Cmd {
 		return registerMsg{}
 	}
 }
+
+func getSessions(au aurum.Client, tp *jwt.TokenPair) tea.Cmd {
+	return func() tea.Msg {
+		sessions, err := au.GetSessions(tp)
+		if err != nil {
+			return sessionsErrMsg{err}
+		}
+
+		return sessionsMsg{sessions}
+	}
+}
+
+func revokeSession(au aurum.Client, tp *jwt.TokenPair, id string) tea.Cmd {
+	return func() tea.Msg {
+		if err := au.RevokeSession(tp, id); err != nil {
+			return sessionsErrMsg{err}
+		}
+
+		return getSessions(au, tp)()
+	}
+}
+
+func revokeOtherSessions(au aurum.Client, tp *jwt.TokenPair) tea.Cmd {
+	return func() tea.Msg {
+		if err := au.RevokeOtherSessions(tp); err != nil {
+			return sessionsErrMsg{err}
+		}
+
+		return getSessions(au, tp)()
+	}
+}
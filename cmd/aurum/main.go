@@ -36,6 +36,7 @@ const (
 	LoginScreen
 	RegisterScreen
 	UserScreen
+	SessionsScreen
 )
 
 type model struct {
@@ -46,19 +47,21 @@ type model struct {
 
 	info string
 
-	main  MainScreenModel
-	login LoginRegisterModel
-	user  UserModel
+	main     MainScreenModel
+	login    LoginRegisterModel
+	user     UserModel
+	sessions SessionsModel
 }
 
 func initialModel() model {
 	return model{
-		au:     nil,
-		screen: MainScreen,
-		err:    nil,
-		main:   InitialMainScreenModel(),
-		login:  InitialLoginScreenModel(),
-		user:   InitialUserScreenModel(),
+		au:       nil,
+		screen:   MainScreen,
+		err:      nil,
+		main:     InitialMainScreenModel(),
+		login:    InitialLoginScreenModel(),
+		user:     InitialUserScreenModel(),
+		sessions: InitialSessionsScreenModel(),
 	}
 }
 
@@ -83,6 +86,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.screen = UserScreen
 		m.tp = msg.tp
 		cmds = append(cmds, getme(m.au, msg.tp))
+	case screenSessionsMsg:
+		m.screen = SessionsScreen
+		m.sessions = InitialSessionsScreenModel()
+		cmds = append(cmds, getSessions(m.au, m.tp))
 	case registerMsg:
 		m.info = te.String("Registered successfully!").Foreground(color("#0f0")).String()
 		m.screen = MainScreen
@@ -96,6 +103,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
 		case tea.KeyEsc:
+			if m.screen == SessionsScreen {
+				m.screen = UserScreen
+				return m, nil
+			}
 			m.screen = MainScreen
 		}
 	}
@@ -108,6 +119,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.login, cmd = m.login.Update(m.au, msg)
 	case UserScreen:
 		m.user, cmd = m.user.Update(msg)
+	case SessionsScreen:
+		m.sessions, cmd = m.sessions.Update(m.au, m.tp, msg)
 	}
 	cmds = append(cmds, cmd)
 	return m, tea.Batch(cmds...)
@@ -130,6 +143,8 @@ func (m model) View() string {
 		s += m.login.View()
 	case UserScreen:
 		s += m.user.View()
+	case SessionsScreen:
+		s += m.sessions.View()
 	}
 
 	return s
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/finitum/aurum/clients/go"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	te "github.com/muesli/termenv"
+)
+
+const sessionTimeFormat = "2006-01-02 15:04"
+
+type SessionsModel struct {
+	sessions []models.Session
+	cursor   int
+	loading  bool
+
+	err error
+}
+
+func InitialSessionsScreenModel() SessionsModel {
+	return SessionsModel{loading: true}
+}
+
+type screenSessionsMsg struct{}
+
+func toSessionsScreen() tea.Msg { return screenSessionsMsg{} }
+
+func (m SessionsModel) View() string {
+	s := fmt.Sprintf(" %s Sessions\n", aurumText)
+
+	if m.err != nil {
+		s += te.String("Error: ").Foreground(color("#f00")).String() + strings.TrimSpace(m.err.Error()) + "\n"
+	}
+
+	s += "\n"
+
+	if m.loading {
+		s += "Loading...\n"
+	}
+
+	for i, session := range m.sessions {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+
+		line := fmt.Sprintf("%s %s  created %s  last used %s  %s",
+			cursor,
+			session.IP,
+			session.CreatedAt.Local().Format(sessionTimeFormat),
+			session.LastRefresh.Local().Format(sessionTimeFormat),
+			session.UserAgent,
+		)
+
+		if session.Current {
+			line += te.String(" (current)").Foreground(color(focusedTextColor)).String()
+		}
+
+		s += line + "\n"
+	}
+
+	s += "\n"
+	s += te.String("<ENTER> revoke session, <o> revoke all other sessions, <r> reload, <ESC> back\n").Faint().Italic().String()
+
+	return s
+}
+
+func (m SessionsModel) Update(au aurum.Client, tp *jwt.TokenPair, msg tea.Msg) (SessionsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sessionsMsg:
+		m.loading = false
+		m.err = nil
+		m.sessions = msg.sessions
+		if m.cursor > len(m.sessions)-1 {
+			m.cursor = len(m.sessions) - 1
+		}
+		if m.cursor < 0 {
+			m.cursor = 0
+		}
+	case sessionsErrMsg:
+		m.loading = false
+		m.err = msg.err
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyUp:
+			m.cursor--
+			if m.cursor < 0 {
+				m.cursor = len(m.sessions) - 1
+			}
+		case tea.KeyDown:
+			m.cursor++
+			if m.cursor > len(m.sessions)-1 {
+				m.cursor = 0
+			}
+		case tea.KeyEnter:
+			if len(m.sessions) == 0 {
+				return m, nil
+			}
+
+			m.loading = true
+			return m, revokeSession(au, tp, m.sessions[m.cursor].ID)
+		}
+
+		switch msg.String() {
+		case "o":
+			m.loading = true
+			return m, revokeOtherSessions(au, tp)
+		case "r":
+			m.loading = true
+			return m, getSessions(au, tp)
+		}
+	}
+
+	return m, nil
+}
@@ -21,6 +21,7 @@ func (m UserModel) View() string {
 
 	s := fmt.Sprintf("Welcome %s\n\n", username)
 
+	s += te.String("Press <s> to manage your sessions\n").Faint().Italic().String()
 	s += te.String("Press <ESC> to logout\n").Faint().Italic().String()
 
 	return s
@@ -30,6 +31,10 @@ func (m UserModel) Update(msg tea.Msg) (UserModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case getMeMsg:
 		m.user = msg.user
+	case tea.KeyMsg:
+		if msg.String() == "s" {
+			return m, toSessionsScreen
+		}
 	}
 
 	return m, nil
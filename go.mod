@@ -11,12 +11,14 @@ require (
 	github.com/go-chi/chi v4.1.2+incompatible
 	github.com/golang/mock v1.4.4
 	github.com/google/uuid v1.1.2
+	github.com/lib/pq v1.9.0
 	github.com/muesli/termenv v0.7.4
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.7.0
 	github.com/stretchr/testify v1.6.1
 	github.com/trustelem/zxcvbn v1.0.1
 	go.deanishe.net/env v0.5.1
+	go.etcd.io/bbolt v1.3.5
 	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
 	google.golang.org/grpc v1.33.2
 )
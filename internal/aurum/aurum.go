@@ -7,8 +7,11 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"strings"
+	"time"
 
 	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/internal/mail"
+	"github.com/finitum/aurum/internal/passwords"
 	"github.com/finitum/aurum/pkg/config"
 	"github.com/finitum/aurum/pkg/jwt"
 	"github.com/finitum/aurum/pkg/jwt/ecc"
@@ -22,6 +25,13 @@ var (
 	ErrInvalidInput = errors.New("password is too weak")
 	ErrWeakPassword = errors.New("password is too weak")
 	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrBreachedPassword is returned for passwords which appeared in a data breach
+	ErrBreachedPassword = errors.New("password appeared in a data breach")
+	// ErrPasswordReused is returned for passwords which are still in the password history of the user
+	ErrPasswordReused   = errors.New("password has been used before")
+	ErrEmailNotVerified = errors.New("email address has not been verified")
+	ErrLockedOut        = errors.New("too many failed logins, try again later")
 )
 
 const (
@@ -29,20 +39,106 @@ const (
 	AurumName     = "aurum"
 )
 
+const (
+	// DefaultPageSize is the number of users or groups listed when no limit is requested
+	DefaultPageSize = 50
+	// MaxPageSize is the maximum number of users or groups listed at once
+	MaxPageSize = 100
+)
+
 type Aurum struct {
-	db store.AurumStore
-	pk ecc.PublicKey
-	sk ecc.SecretKey
+	db     store.AurumStore
+	pk     ecc.PublicKey
+	sk     ecc.SecretKey
+	mailer mail.Mailer
+
+	// resetURL is the page password reset mails link to
+	resetURL string
+	// resetCooldown is how long after a password reset mail no other is sent to the same user
+	resetCooldown time.Duration
+	// verifyURL is the page email verification mails link to
+	verifyURL string
+
+	// hasher hashes passwords, see hashing
+	hasher hash.Hasher
+	// breached are the passwords which may not be used, if configured
+	breached *passwords.BreachedList
+	// policy is the password policy, see basePolicy
+	policy *models.PasswordPolicy
+
+	lockout LockoutPolicy
 }
 
-func New(ctx context.Context, db store.AurumStore, cfg *config.Config) (Aurum, error) {
-	if err := setup(ctx, db); err != nil {
+func New(ctx context.Context, db store.AurumStore, mailer mail.Mailer, cfg *config.Config) (Aurum, error) {
+	params, err := newHashParams(cfg)
+	if err != nil {
+		return Aurum{}, errors.Wrap(err, "password hashing")
+	}
+	hasher := hash.NewHasher(params, cfg.Peppers)
+
+	var breached *passwords.BreachedList
+	if cfg.BreachedPasswordsPath != "" {
+		if breached, err = passwords.OpenBreachedList(cfg.BreachedPasswordsPath); err != nil {
+			return Aurum{}, err
+		}
+		log.Infof("Loaded %d breached passwords", breached.Len())
+	}
+
+	policy := cfg.PasswordPolicy
+	if !passwords.Valid(policy) {
+		return Aurum{}, errors.New("invalid password policy")
+	}
+
+	if err := checkPeppers(ctx, db, cfg.Peppers); err != nil {
+		return Aurum{}, err
+	}
+
+	if err := setup(ctx, db, hasher); err != nil {
 		return Aurum{}, err
 	}
-	return Aurum{db, cfg.PublicKey, cfg.SecretKey}, nil
+
+	return Aurum{
+		db:            db,
+		pk:            cfg.PublicKey,
+		sk:            cfg.SecretKey,
+		mailer:        mailer,
+		resetURL:      cfg.PasswordResetURL,
+		resetCooldown: cfg.PasswordResetCooldown,
+		verifyURL:     cfg.EmailVerificationURL,
+		hasher:        hasher,
+		breached:      breached,
+		policy:        &policy,
+		lockout: newLockoutPolicy(
+			cfg.LockoutAccountThreshold, cfg.LockoutAddressThreshold,
+			cfg.LockoutDuration, cfg.LockoutMaxDuration, cfg.LockoutResetAfter,
+		),
+	}, nil
+}
+
+// checkPeppers refuses to start when stored hashes were made with a pepper which isn't in peppers,
+// as the passwords of their users could never be verified again.
+func checkPeppers(ctx context.Context, db store.AurumStore, peppers map[int][]byte) error {
+	missing, err := db.CountPasswordsWithPrefix(ctx, hash.PepperPrefix(0))
+	if err != nil {
+		return errors.Wrap(err, "count peppered passwords")
+	}
+
+	for version := range peppers {
+		n, err := db.CountPasswordsWithPrefix(ctx, hash.PepperPrefix(version))
+		if err != nil {
+			return errors.Wrap(err, "count peppered passwords")
+		}
+		missing -= n
+	}
+
+	if missing > 0 {
+		return errors.Errorf("%d passwords are peppered with a pepper which isn't loaded", missing)
+	}
+
+	return nil
 }
 
-func setup(ctx context.Context, db store.AurumStore) error {
+func setup(ctx context.Context, db store.AurumStore, hasher hash.Hasher) error {
 	nu, err := db.CountUsers(ctx)
 	if err != nil {
 		return errors.Wrap(err, "count users")
@@ -63,33 +159,46 @@ func setup(ctx context.Context, db store.AurumStore) error {
 
 	log.Infof("Created initial user: '%s' with password '%s'", adminUsername, pass)
 
-	hashed, err := hash.HashPassword(pass)
+	hashed, err := hasher.Hash(pass)
 	if err != nil {
 		return errors.Wrap(err, "hashing failed")
 	}
 
-	if err := db.CreateUser(ctx, models.User{
-		Username: adminUsername,
-		Password: hashed,
-	}); err != nil {
-		return errors.Wrap(err, "create initial user")
-	}
+	// The admin user, the aurum group and the membership of the admin are created together,
+	// so a failure halfway can't leave Aurum without an administrator.
+	return db.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateUser(ctx, models.User{
+			Username: adminUsername,
+			Password: hashed,
+		}); err != nil {
+			return errors.Wrap(err, "create initial user")
+		}
 
-	if err := db.CreateGroup(ctx, models.Group{
-		Name:              AurumName,
-		AllowRegistration: true,
-	}); err != nil {
-		return errors.Wrap(err, "create initial group")
-	}
+		if err := tx.CreateGroup(ctx, models.Group{
+			Name:              AurumName,
+			AllowRegistration: true,
+		}); err != nil {
+			return errors.Wrap(err, "create initial group")
+		}
 
-	if err := db.AddGroupToUser(ctx, adminUsername, AurumName, models.RoleAdmin); err != nil {
-		return errors.Wrap(err, "add initial user to Aurum group")
-	}
+		if err := tx.AddGroupToUser(ctx, adminUsername, AurumName, models.RoleAdmin); err != nil {
+			return errors.Wrap(err, "add initial user to Aurum group")
+		}
 
-	return nil
+		return nil
+	})
+}
+
+// hashing is the Hasher passwords are hashed with, which is hash.DefaultParams unless configured
+func (au Aurum) hashing() hash.Hasher {
+	if au.hasher == nil {
+		return hash.DefaultParams
+	}
+	return au.hasher
 }
 
-func (au Aurum) checkToken(token string) (*jwt.Claims, error) {
+// parseToken checks the signature and validity of a login or refresh token
+func (au Aurum) parseToken(token string) (*jwt.Claims, error) {
 	claims, err := jwt.VerifyJWT(token, au.pk)
 	if err != nil {
 		return nil, ErrUnauthorized
@@ -99,6 +208,32 @@ func (au Aurum) checkToken(token string) (*jwt.Claims, error) {
 		return nil, ErrUnauthorized
 	}
 
+	return claims, nil
+}
+
+// verifyToken checks that a login or refresh token is valid and that neither it nor its family has been revoked
+func (au Aurum) verifyToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	claims, err := au.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := au.isRevoked(ctx, claims.Id, claims.Family)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking token revocation")
+	} else if revoked {
+		return nil, ErrUnauthorized
+	}
+
+	return claims, nil
+}
+
+func (au Aurum) checkToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	claims, err := au.verifyToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
 	// Refresh tokens are not allowed to be used as authentication
 	if claims.Refresh {
 		return nil, ErrInvalidInput
@@ -108,7 +243,7 @@ func (au Aurum) checkToken(token string) (*jwt.Claims, error) {
 }
 
 func (au Aurum) checkTokenAndRole(ctx context.Context, token, group string) (models.Role, *jwt.Claims, error) {
-	claims, err := au.checkToken(token)
+	claims, err := au.checkToken(ctx, token)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -131,3 +266,52 @@ func (au Aurum) checkRole(ctx context.Context, claims *jwt.Claims, group string)
 	return role, nil
 }
 
+// checkTokenAndMembership is like checkTokenAndRole, but returns role 0 when the user isn't a member of group
+func (au Aurum) checkTokenAndMembership(ctx context.Context, token, group string) (models.Role, *jwt.Claims, error) {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	role, err := au.membership(ctx, claims, group)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return role, claims, nil
+}
+
+// membership is like checkRole, but returns role 0 when the user isn't a member of group
+func (au Aurum) membership(ctx context.Context, claims *jwt.Claims, group string) (models.Role, error) {
+	role, err := au.checkRole(ctx, claims, group)
+	if err == store.ErrNotExists {
+		return 0, nil
+	}
+
+	return role, err
+}
+
+// pageSize limits the requested page size to MaxPageSize, and replaces no limit with DefaultPageSize
+func pageSize(limit int) int {
+	if limit == 0 {
+		return DefaultPageSize
+	} else if limit > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return limit
+}
+
+// requireAdmin checks that the token belongs to an admin of Aurum
+func (au Aurum) requireAdmin(ctx context.Context, token string) (*jwt.Claims, error) {
+	role, claims, err := au.checkTokenAndRole(ctx, token, AurumName)
+	if err != nil {
+		return nil, err
+	}
+
+	if role < models.RoleAdmin {
+		return nil, ErrUnauthorized
+	}
+
+	return claims, nil
+}
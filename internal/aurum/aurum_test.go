@@ -0,0 +1,135 @@
+package aurum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectTx expects a transaction, which runs directly against ms
+func expectTx(ms *mock_store.MockAurumStore) *gomock.Call {
+	return ms.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(tx store.AurumStore) error) error {
+			return fn(ms)
+		})
+}
+
+// expectSignUpPolicy expects the password policy of the aurum group to be looked up, which has none
+func expectSignUpPolicy(ms *mock_store.MockAurumStore) *gomock.Call {
+	return ms.EXPECT().GetGroup(gomock.Any(), AurumName).Return(&models.Group{Name: AurumName}, nil)
+}
+
+// expectUserPolicy expects the groups of a user to be looked up for their password policies, of
+// which there are none
+func expectUserPolicy(ms *mock_store.MockAurumStore, username string) *gomock.Call {
+	return ms.EXPECT().GetGroupsForUser(gomock.Any(), username).Return([]models.GroupWithRole{
+		{Group: models.Group{Name: AurumName}, Role: models.RoleUser},
+	}, nil)
+}
+
+// expectNoPasswordHistory expects the password history of a user to be looked up, which is empty
+func expectNoPasswordHistory(ms *mock_store.MockAurumStore, username string) *gomock.Call {
+	return ms.EXPECT().GetPasswordHistory(gomock.Any(), username).Return(nil, nil)
+}
+
+// expectNotRevoked lets every token checked against ms pass the revocation check
+func expectNotRevoked(ms *mock_store.MockAurumStore) *gomock.Call {
+	return ms.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+}
+
+func TestCheckPeppers(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	peppers := map[int][]byte{1: []byte("0123456789abcdef")}
+
+	ms.EXPECT().CountPasswordsWithPrefix(gomock.Any(), "$pepper=").Return(2, nil)
+	ms.EXPECT().CountPasswordsWithPrefix(gomock.Any(), "$pepper=1$").Return(2, nil)
+
+	assert.NoError(t, checkPeppers(ctx, ms, peppers))
+}
+
+func TestCheckPeppers_Missing(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	peppers := map[int][]byte{2: []byte("fedcba9876543210")}
+
+	// One hash still uses the removed version 1
+	ms.EXPECT().CountPasswordsWithPrefix(gomock.Any(), "$pepper=").Return(2, nil)
+	ms.EXPECT().CountPasswordsWithPrefix(gomock.Any(), "$pepper=2$").Return(1, nil)
+
+	assert.Error(t, checkPeppers(ctx, ms, peppers))
+}
+
+func TestCheckPeppers_NoPeppers(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	ms.EXPECT().CountPasswordsWithPrefix(gomock.Any(), "$pepper=").Return(1, nil)
+
+	assert.Error(t, checkPeppers(ctx, ms, nil))
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	ms.EXPECT().CountUsers(gomock.Any()).Return(0, nil)
+	expectTx(ms)
+	ms.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Do(func(_ context.Context, u models.User) {
+		assert.Equal(t, adminUsername, u.Username)
+		assert.NotEmpty(t, u.Password)
+	})
+	ms.EXPECT().CreateGroup(gomock.Any(), models.Group{Name: AurumName, AllowRegistration: true})
+	ms.EXPECT().AddGroupToUser(gomock.Any(), adminUsername, AurumName, models.RoleAdmin)
+
+	assert.NoError(t, setup(ctx, ms, hash.DefaultParams))
+}
+
+func TestSetup_Failure(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	errFailed := errors.New("failed")
+
+	ms.EXPECT().CountUsers(gomock.Any()).Return(0, nil)
+	expectTx(ms)
+	ms.EXPECT().CreateUser(gomock.Any(), gomock.Any())
+	ms.EXPECT().CreateGroup(gomock.Any(), gomock.Any())
+	ms.EXPECT().AddGroupToUser(gomock.Any(), adminUsername, AurumName, models.RoleAdmin).Return(errFailed)
+
+	err := setup(ctx, ms, hash.DefaultParams)
+	assert.Equal(t, errFailed, errors.Cause(err))
+}
+
+func TestSetup_AlreadyInitialized(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	ms.EXPECT().CountUsers(gomock.Any()).Return(1, nil)
+
+	assert.NoError(t, setup(ctx, ms, hash.DefaultParams))
+}
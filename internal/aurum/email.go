@@ -0,0 +1,135 @@
+package aurum
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/finitum/aurum/internal/mail"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+const verificationMailBody = `Hi %s,
+
+Please confirm that this is the email address of your Aurum account within %d hours using %s
+
+If you didn't sign up for Aurum or change your email address, you can ignore this mail.
+`
+
+// sendVerification mails a verification link for email to the user
+func (au Aurum) sendVerification(ctx context.Context, username, email string) error {
+	token, err := jwt.GenerateVerificationJWT(username, email, au.sk)
+	if err != nil {
+		return errors.Wrap(err, "jwt generation error")
+	}
+
+	link, err := mailLink(au.verifyURL, token)
+	if err != nil {
+		return errors.Wrap(err, "email verification link")
+	}
+
+	return au.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your Aurum email address",
+		Body:    fmt.Sprintf(verificationMailBody, username, int(jwt.VerificationExpiry.Hours()), link),
+	})
+}
+
+// setEmail changes the email address of the user. The new address only replaces the current
+// one once it's verified, so until then it's pending. It returns whether a verification mail
+// should be sent to the new address.
+func setEmail(ctx context.Context, db store.AurumStore, username, email string) (bool, error) {
+	curr, err := db.GetUser(ctx, username)
+	if err != nil {
+		return false, err
+	}
+
+	if email == curr.Email {
+		// Changing back to the current address cancels the pending change
+		if curr.PendingEmail == "" {
+			return false, nil
+		}
+
+		return false, db.SetUserEmail(ctx, username, curr.Email, "", curr.EmailVerified)
+	}
+
+	if email == curr.PendingEmail {
+		// Asking again resends the verification mail
+		return true, nil
+	}
+
+	return true, db.SetUserEmail(ctx, username, curr.Email, email, curr.EmailVerified)
+}
+
+// VerifyEmail confirms an email address with the token from a verification mail.
+// A pending address replaces the current one once it's confirmed.
+func (au Aurum) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := jwt.VerifyVerificationJWT(token, au.pk)
+	if err != nil || claims.Email == "" {
+		return ErrUnauthorized
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		user, err := tx.GetUser(ctx, claims.Subject)
+		if err == store.ErrNotExists {
+			return ErrUnauthorized
+		} else if err != nil {
+			return err
+		}
+
+		switch claims.Email {
+		case user.PendingEmail:
+			return tx.SetUserEmail(ctx, user.Username, user.PendingEmail, "", true)
+		case user.Email:
+			return tx.SetUserEmail(ctx, user.Username, user.Email, user.PendingEmail, true)
+		}
+
+		// The address has been changed since the token was mailed
+		return ErrUnauthorized
+	})
+}
+
+// ResendVerification mails a new verification link for the pending, or else the unverified,
+// email address of the user
+func (au Aurum) ResendVerification(ctx context.Context, token string) error {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	user, err := au.db.GetUser(ctx, claims.Username)
+	if err != nil {
+		return err
+	}
+
+	email := user.PendingEmail
+	if email == "" && !user.EmailVerified {
+		email = user.Email
+	}
+
+	if email == "" {
+		return ErrInvalidInput
+	}
+
+	return au.sendVerification(ctx, user.Username, email)
+}
+
+// requireVerifiedEmail checks whether the user may join a group which requires a verified email address
+func (au Aurum) requireVerifiedEmail(ctx context.Context, group models.Group, username string) error {
+	if !group.RequireVerifiedEmail {
+		return nil
+	}
+
+	user, err := au.db.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
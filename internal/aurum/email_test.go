@@ -0,0 +1,118 @@
+package aurum
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var verifyLink = regexp.MustCompile(`https://aurum\.example\.com/verify\?token=\S+`)
+
+func TestAurum_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	user := models.User{Username: "bob", Email: "old@example.com", PendingEmail: "new@example.com"}
+
+	current, err := jwt.GenerateVerificationJWT("bob", "old@example.com", cfg.SecretKey)
+	require.NoError(t, err)
+	pending, err := jwt.GenerateVerificationJWT("bob", "new@example.com", cfg.SecretKey)
+	require.NoError(t, err)
+	stale, err := jwt.GenerateVerificationJWT("bob", "older@example.com", cfg.SecretKey)
+	require.NoError(t, err)
+
+	expectTx(ms).Times(3)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil).Times(3)
+
+	// Verifying the current address keeps the pending change
+	ms.EXPECT().SetUserEmail(gomock.Any(), "bob", "old@example.com", "new@example.com", true)
+	assert.NoError(t, au.VerifyEmail(ctx, current))
+
+	// Verifying the pending address makes it the current one
+	ms.EXPECT().SetUserEmail(gomock.Any(), "bob", "new@example.com", "", true)
+	assert.NoError(t, au.VerifyEmail(ctx, pending))
+
+	// Addresses the user changed away from can't be verified anymore
+	assert.Equal(t, ErrUnauthorized, au.VerifyEmail(ctx, stale))
+
+	// Nor can other tokens be used
+	login, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+	assert.Equal(t, ErrUnauthorized, au.VerifyEmail(ctx, login))
+}
+
+func TestAurum_ResendVerification(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	mb := &mailbox{}
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, mailer: mb, verifyURL: "https://aurum.example.com/verify"}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	// The pending address takes precedence
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Email: "old@example.com", PendingEmail: "new@example.com"}, nil)
+	require.NoError(t, au.ResendVerification(ctx, token))
+	require.Len(t, mb.messages, 1)
+	assert.Equal(t, "new@example.com", mb.messages[0].To)
+
+	link, err := url.Parse(verifyLink.FindString(mb.messages[0].Body))
+	require.NoError(t, err)
+
+	claims, err := jwt.VerifyVerificationJWT(link.Query().Get("token"), cfg.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", claims.Subject)
+	assert.Equal(t, "new@example.com", claims.Email)
+
+	// Nothing to verify
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Email: "old@example.com", EmailVerified: true}, nil)
+	assert.Equal(t, ErrInvalidInput, au.ResendVerification(ctx, token))
+	assert.Len(t, mb.messages, 1)
+}
+
+func TestAurum_AddUserToGroupRequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	group := &models.Group{Name: "group", AllowRegistration: true, RequireVerifiedEmail: true}
+
+	ms.EXPECT().GetGroup(gomock.Any(), "group").Return(group, nil).Times(2)
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleUser, nil).Times(2)
+
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Email: "bob@example.com"}, nil)
+	assert.Equal(t, ErrEmailNotVerified, au.AddUserToGroup(ctx, token, "bob", "group", models.RoleUser))
+
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Email: "bob@example.com", EmailVerified: true}, nil)
+	ms.EXPECT().AddGroupToUser(gomock.Any(), "bob", "group", models.RoleUser)
+	assert.NoError(t, au.AddUserToGroup(ctx, token, "bob", "group", models.RoleUser))
+}
@@ -20,27 +20,83 @@ func (au Aurum) AddGroup(ctx context.Context, token string, group models.Group)
 	}
 
 	group.Name = strings.ToLower(group.Name)
-	if err := au.db.CreateGroup(ctx, group); err != nil {
-		return err
+
+	if !validMetadata(group.Metadata) || !au.validPolicy(group.PasswordPolicy) {
+		return ErrInvalidInput
+	}
+
+	if group.PasswordPolicy != nil && group.PasswordPolicy.IsZero() {
+		group.PasswordPolicy = nil
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateGroup(ctx, group); err != nil {
+			return err
+		}
+
+		return tx.AddGroupToUser(ctx, claims.Username, group.Name, models.RoleAdmin)
+	})
+}
+
+// UpdateGroup changes the settings of a group, which only admins of the group may do
+func (au Aurum) UpdateGroup(ctx context.Context, token, name string, update models.GroupUpdate) (models.Group, error) {
+	name = strings.ToLower(name)
+
+	role, _, err := au.checkTokenAndRole(ctx, token, name)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	if role < models.RoleAdmin {
+		return models.Group{}, ErrUnauthorized
+	}
+
+	if !validMetadata(update.Metadata) || !au.validPolicy(update.PasswordPolicy) {
+		return models.Group{}, ErrInvalidInput
+	}
+
+	var group models.Group
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		g, err := tx.GetGroup(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		update.Apply(g)
+		group = *g
+
+		return tx.SetGroup(ctx, group)
+	})
+
+	return group, err
+}
+
+// validMetadata checks that none of the metadata keys are empty
+func validMetadata(metadata map[string]string) bool {
+	for k := range metadata {
+		if k == "" {
+			return false
+		}
 	}
 
-	return au.db.AddGroupToUser(ctx, claims.Username, group.Name, models.RoleAdmin)
+	return true
 }
 
-func (au Aurum) RemoveGroup(ctx context.Context, token, group string) error {
+// RemoveGroup removes a group together with the memberships of all its users
+func (au Aurum) RemoveGroup(ctx context.Context, token, group string) (models.RemovalSummary, error) {
 	group = strings.ToLower(group)
 
 	role, _, err := au.checkTokenAndRole(ctx, token, group)
 	if err != nil {
-		return err
+		return models.RemovalSummary{}, err
 	}
 
 	if role < models.RoleAdmin {
-		return ErrUnauthorized
+		return models.RemovalSummary{}, ErrUnauthorized
 	}
 
 	if group == strings.ToLower(AurumName) {
-		return errors.Errorf("Can't remove group named %s", AurumName)
+		return models.RemovalSummary{}, errors.Errorf("Can't remove group named %s", AurumName)
 	}
 
 	return au.db.RemoveGroup(ctx, group)
@@ -61,6 +117,27 @@ func (au Aurum) GetAccess(ctx context.Context, user, group string) (models.Acces
 		return models.AccessStatus{}, err
 	}
 
+	g, err := au.db.GetGroup(ctx, group)
+	if err != nil {
+		return models.AccessStatus{}, err
+	}
+
+	if g.RequireTwoFactor {
+		twoFactor, err := au.hasSecondFactor(ctx, user)
+		if err != nil {
+			return models.AccessStatus{}, err
+		}
+
+		if !twoFactor {
+			return models.AccessStatus{
+				GroupName:         group,
+				Username:          user,
+				AllowedAccess:     false,
+				TwoFactorRequired: true,
+			}, nil
+		}
+	}
+
 	return models.AccessStatus{
 		GroupName:     group,
 		Username:      user,
@@ -97,13 +174,19 @@ func (au Aurum) AddUserToGroup(ctx context.Context, token, username, groupName s
 		return errors.Wrap(err, "getting token and role")
 	}
 
-	if role == models.RoleAdmin {
-		return au.db.AddGroupToUser(ctx, username, group.Name, wanted)
-	} else if wanted > models.RoleUser || username != claims.Username || !group.AllowRegistration {
-		return ErrUnauthorized
+	if role != models.RoleAdmin {
+		if wanted > models.RoleUser || username != claims.Username || !group.AllowRegistration {
+			return ErrUnauthorized
+		}
+
+		wanted = models.RoleUser
 	}
 
-	return au.db.AddGroupToUser(ctx, claims.Username, group.Name, models.RoleUser)
+	if err := au.requireVerifiedEmail(ctx, *group, username); err != nil {
+		return err
+	}
+
+	return au.db.AddGroupToUser(ctx, username, group.Name, wanted)
 }
 
 func (au Aurum) RemoveUserFromGroup(ctx context.Context, token, target, group string) error {
@@ -122,7 +205,7 @@ func (au Aurum) RemoveUserFromGroup(ctx context.Context, token, target, group st
 }
 
 func (au Aurum) GetGroupsForUser(ctx context.Context, token, user string) ([]models.GroupWithRole, error) {
-	claims, err := au.checkToken(token)
+	claims, err := au.checkToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
@@ -141,3 +224,103 @@ func (au Aurum) GetGroupsForUser(ctx context.Context, token, user string) ([]mod
 
 	return au.db.GetGroupsForUser(ctx, user)
 }
+
+// GetGroups lists a page of groups selected by query. Only admins of Aurum may list all groups,
+// other users only see the groups they may join themselves.
+func (au Aurum) GetGroups(ctx context.Context, token string, query models.GroupQuery) (models.GroupPage, error) {
+	role, _, err := au.checkTokenAndMembership(ctx, token, AurumName)
+	if err != nil {
+		return models.GroupPage{}, err
+	}
+
+	if role < models.RoleAdmin {
+		query.AllowRegistration = true
+	}
+
+	query.Limit = pageSize(query.Limit)
+
+	return au.db.GetGroups(ctx, query)
+}
+
+// GetGroup retrieves a group. Users may see the groups they are a member of and those they
+// may join themselves, admins of Aurum may see all groups.
+func (au Aurum) GetGroup(ctx context.Context, token, name string) (models.Group, error) {
+	name = strings.ToLower(name)
+
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	group, err := au.db.GetGroup(ctx, name)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	if group.AllowRegistration {
+		return *group, nil
+	}
+
+	role, err := au.membership(ctx, claims, group.Name)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	if role == 0 {
+		aurumRole, err := au.membership(ctx, claims, AurumName)
+		if err != nil {
+			return models.Group{}, err
+		}
+
+		if aurumRole < models.RoleAdmin {
+			return models.Group{}, ErrUnauthorized
+		}
+	}
+
+	return *group, nil
+}
+
+// GetGroupMembers lists a page of the members of a group selected by query. Admins of the group
+// and of Aurum see all members, other members of the group only see who its admins are.
+func (au Aurum) GetGroupMembers(ctx context.Context, token, group string, query models.MemberQuery) (models.MemberPage, error) {
+	group = strings.ToLower(group)
+
+	role, claims, err := au.checkTokenAndMembership(ctx, token, group)
+	if err != nil {
+		return models.MemberPage{}, err
+	}
+
+	restricted := role < models.RoleAdmin
+	if restricted {
+		aurumRole, err := au.membership(ctx, claims, AurumName)
+		if err != nil {
+			return models.MemberPage{}, err
+		}
+
+		if aurumRole == models.RoleAdmin {
+			restricted = false
+		} else if role == 0 {
+			return models.MemberPage{}, ErrUnauthorized
+		}
+	}
+
+	if restricted {
+		query.Role = models.RoleAdmin
+	}
+
+	query.Limit = pageSize(query.Limit)
+
+	page, err := au.db.GetGroupMembers(ctx, group, query)
+	if err != nil {
+		return models.MemberPage{}, err
+	}
+
+	for i := range page.Members {
+		page.Members[i].Password = ""
+		if restricted {
+			page.Members[i].Email = ""
+		}
+	}
+
+	return page, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/finitum/aurum/pkg/config"
 	"github.com/finitum/aurum/pkg/jwt"
 	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
 	"github.com/finitum/aurum/pkg/store/mock_store"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -21,6 +22,7 @@ func TestAurum_AddGroup(t *testing.T) {
 	cfg := config.EphemeralConfig()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	group := models.Group{
 		Name:              "NotAurum",
@@ -37,6 +39,7 @@ func TestAurum_AddGroup(t *testing.T) {
 
 	// Expect
 	ms.EXPECT().GetGroupRole(gomock.Any(), strings.ToLower(AurumName), "bob").Return(models.RoleAdmin, nil)
+	expectTx(ms)
 	ms.EXPECT().CreateGroup(gomock.Any(), groupL)
 	ms.EXPECT().AddGroupToUser(gomock.Any(), "bob", groupL.Name, models.RoleAdmin)
 
@@ -45,6 +48,103 @@ func TestAurum_AddGroup(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAurum_UpdateGroup(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	current := models.Group{
+		Name:              "group",
+		AllowRegistration: true,
+		DisplayName:       "Group",
+		Metadata:          map[string]string{"homepage": "https://example.com", "team": "alpha"},
+	}
+
+	closed := false
+	description := "A group"
+	update := models.GroupUpdate{
+		AllowRegistration: &closed,
+		Description:       &description,
+		Metadata:          map[string]string{"team": "", "owner": "bob"},
+	}
+
+	expected := models.Group{
+		Name:        "group",
+		DisplayName: "Group",
+		Description: description,
+		Metadata:    map[string]string{"homepage": "https://example.com", "owner": "bob"},
+	}
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleAdmin, nil)
+	expectTx(ms)
+	ms.EXPECT().GetGroup(gomock.Any(), "group").Return(&current, nil)
+	ms.EXPECT().SetGroup(gomock.Any(), expected)
+
+	// SUT
+	group, err := au.UpdateGroup(ctx, token, "Group", update)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, group)
+}
+
+func TestAurum_UpdateGroupNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("alice", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	name := "Renamed"
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "alice").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.UpdateGroup(ctx, token, "group", models.GroupUpdate{DisplayName: &name})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_UpdateGroupEmptyMetadataKey(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleAdmin, nil)
+
+	// SUT
+	_, err = au.UpdateGroup(ctx, token, "group", models.GroupUpdate{Metadata: map[string]string{"": "value"}})
+	assert.Equal(t, ErrInvalidInput, err)
+}
+
 func TestAurum_RemoveGroup(t *testing.T) {
 	ctx := context.Background()
 	ctrl, ctx := gomock.WithContext(ctx, t)
@@ -53,6 +153,7 @@ func TestAurum_RemoveGroup(t *testing.T) {
 	cfg := config.EphemeralConfig()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	group := models.Group{
 		Name:              "NotAurum",
@@ -69,11 +170,12 @@ func TestAurum_RemoveGroup(t *testing.T) {
 
 	// Expect
 	ms.EXPECT().GetGroupRole(gomock.Any(), groupL.Name, "bob").Return(models.RoleAdmin, nil)
-	ms.EXPECT().RemoveGroup(gomock.Any(), groupL.Name)
+	ms.EXPECT().RemoveGroup(gomock.Any(), groupL.Name).Return(models.RemovalSummary{Groups: []string{groupL.Name}}, nil)
 
 	// SUT
-	err = au.RemoveGroup(ctx, token, groupL.Name)
+	summary, err := au.RemoveGroup(ctx, token, groupL.Name)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{groupL.Name}, summary.Groups)
 }
 
 func testAddToGroupHelper(t *testing.T, registration bool) {
@@ -84,6 +186,7 @@ func testAddToGroupHelper(t *testing.T, registration bool) {
 	cfg := config.EphemeralConfig()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	group := models.Group{
 		Name:              "NotAurum",
@@ -133,6 +236,7 @@ func TestAurum_GetAccess(t *testing.T) {
 
 	// Expect
 	ms.EXPECT().GetGroupRole(gomock.Any(), groupL, username).Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetGroup(gomock.Any(), groupL).Return(&models.Group{Name: groupL}, nil)
 
 	// SUT
 	au := Aurum{db: ms}
@@ -147,6 +251,37 @@ func TestAurum_GetAccess(t *testing.T) {
 	}, resp)
 }
 
+func TestAurum_GetAccessTwoFactorRequired(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	au := Aurum{db: ms}
+
+	const group = "angroup"
+	ms.EXPECT().GetGroupRole(gomock.Any(), group, gomock.Any()).Return(models.RoleUser, nil).Times(3)
+	ms.EXPECT().GetGroup(gomock.Any(), group).Return(&models.Group{Name: group, RequireTwoFactor: true}, nil).Times(3)
+
+	// Members without a second factor are denied access
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(models.SecondFactor{}, store.ErrNotExists)
+	resp, err := au.GetAccess(ctx, "bob", group)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AccessStatus{GroupName: group, Username: "bob", TwoFactorRequired: true}, resp)
+
+	// As are members who haven't confirmed it yet
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "carol").Return(models.SecondFactor{Username: "carol"}, nil)
+	resp, err = au.GetAccess(ctx, "carol", group)
+	assert.NoError(t, err)
+	assert.False(t, resp.AllowedAccess)
+	assert.True(t, resp.TwoFactorRequired)
+
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "dave").Return(models.SecondFactor{Username: "dave", Confirmed: true}, nil)
+	resp, err = au.GetAccess(ctx, "dave", group)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AccessStatus{GroupName: group, Username: "dave", AllowedAccess: true, Role: models.RoleUser}, resp)
+}
+
 func TestAurum_SetAccess(t *testing.T) {
 	ctx := context.Background()
 	ctrl, ctx := gomock.WithContext(ctx, t)
@@ -155,6 +290,7 @@ func TestAurum_SetAccess(t *testing.T) {
 	cfg := config.EphemeralConfig()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	const username = "bob"
 	const target = "wooloo"
@@ -184,6 +320,7 @@ func TestAurum_RemoveUserFromGroup(t *testing.T) {
 	cfg := config.EphemeralConfig()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	const username = "bob"
 	const target = "wooloo"
@@ -204,3 +341,206 @@ func TestAurum_RemoveUserFromGroup(t *testing.T) {
 	err = au.RemoveUserFromGroup(ctx, token, target, group)
 	assert.NoError(t, err)
 }
+
+func TestAurum_GetGroups(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	expected := models.GroupPage{Groups: []models.Group{{Name: AurumName}}}
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "bob").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetGroups(gomock.Any(), models.GroupQuery{Limit: DefaultPageSize}).Return(expected, nil)
+
+	// SUT
+	page, err := au.GetGroups(ctx, token, models.GroupQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+}
+
+func TestAurum_GetGroupsNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	expected := models.GroupPage{Groups: []models.Group{{Name: "open", AllowRegistration: true}}}
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "bob").Return(models.RoleUser, nil)
+	ms.EXPECT().GetGroups(gomock.Any(), models.GroupQuery{
+		AllowRegistration: true,
+		Limit:             DefaultPageSize,
+	}).Return(expected, nil)
+
+	// SUT
+	page, err := au.GetGroups(ctx, token, models.GroupQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+}
+
+func TestAurum_GetGroup(t *testing.T) {
+	private := models.Group{Name: "private"}
+	open := models.Group{Name: "open", AllowRegistration: true}
+
+	tests := []struct {
+		name      string
+		group     models.Group
+		role      models.Role
+		aurumRole models.Role
+		err       error
+	}{
+		{"open group", open, 0, 0, nil},
+		{"member", private, models.RoleUser, 0, nil},
+		{"aurum admin", private, 0, models.RoleAdmin, nil},
+		{"not a member", private, 0, models.RoleUser, ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl, ctx := gomock.WithContext(ctx, t)
+			defer ctrl.Finish()
+
+			cfg := config.EphemeralConfig()
+
+			ms := mock_store.NewMockAurumStore(ctrl)
+			expectNotRevoked(ms)
+
+			au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+			token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+			assert.NoError(t, err)
+
+			roleOf := func(role models.Role) (models.Role, error) {
+				if role == 0 {
+					return 0, store.ErrNotExists
+				}
+				return role, nil
+			}
+
+			// Expect
+			ms.EXPECT().GetGroup(gomock.Any(), tt.group.Name).Return(&tt.group, nil)
+			ms.EXPECT().GetGroupRole(gomock.Any(), tt.group.Name, "bob").Return(roleOf(tt.role)).AnyTimes()
+			ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "bob").Return(roleOf(tt.aurumRole)).AnyTimes()
+
+			// SUT
+			group, err := au.GetGroup(ctx, token, strings.ToUpper(tt.group.Name))
+			assert.Equal(t, tt.err, err)
+			if tt.err == nil {
+				assert.Equal(t, tt.group, group)
+			}
+		})
+	}
+}
+
+func TestAurum_GetGroupMembers(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	members := []models.UserWithRole{
+		{User: models.User{Username: "alice", Email: "alice@example.com"}, Role: models.RoleUser},
+		{User: models.User{Username: "bob", Email: "bob@example.com"}, Role: models.RoleAdmin},
+	}
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetGroupMembers(gomock.Any(), "group", models.MemberQuery{
+		Search: "a",
+		Limit:  DefaultPageSize,
+	}).Return(models.MemberPage{Members: members}, nil)
+
+	// SUT
+	page, err := au.GetGroupMembers(ctx, token, "Group", models.MemberQuery{Search: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, members, page.Members)
+}
+
+func TestAurum_GetGroupMembersAsMember(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("alice", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "alice").Return(models.RoleUser, nil)
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "alice").Return(models.RoleUser, nil)
+	ms.EXPECT().GetGroupMembers(gomock.Any(), "group", models.MemberQuery{
+		Role:  models.RoleAdmin,
+		Limit: DefaultPageSize,
+	}).Return(models.MemberPage{Members: []models.UserWithRole{
+		{User: models.User{Username: "bob", Email: "bob@example.com"}, Role: models.RoleAdmin},
+	}}, nil)
+
+	// SUT
+	page, err := au.GetGroupMembers(ctx, token, "group", models.MemberQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UserWithRole{
+		{User: models.User{Username: "bob"}, Role: models.RoleAdmin},
+	}, page.Members)
+}
+
+func TestAurum_GetGroupMembersNotAMember(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	cfg := config.EphemeralConfig()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	au := Aurum{db: ms, sk: cfg.SecretKey, pk: cfg.PublicKey}
+
+	token, err := jwt.GenerateJWT("eve", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	// Expect
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "eve").Return(models.Role(0), store.ErrNotExists)
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "eve").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.GetGroupMembers(ctx, token, "group", models.MemberQuery{})
+	assert.Equal(t, ErrUnauthorized, err)
+}
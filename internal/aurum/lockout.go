@@ -0,0 +1,221 @@
+package aurum
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	accountKeyPrefix = "user:"
+	addressKeyPrefix = "ip:"
+)
+
+// LockoutPolicy decides how failed logins lock accounts and source addresses
+type LockoutPolicy struct {
+	// AccountThreshold and AddressThreshold are the numbers of failed logins after which an
+	// account or a source address is locked. Zero disables locking it.
+	AccountThreshold int
+	AddressThreshold int
+
+	// Duration is how long the first lock lasts, every further failure doubles it up to MaxDuration
+	Duration    time.Duration
+	MaxDuration time.Duration
+
+	// ResetAfter is how long after the last failure the failures are forgotten
+	ResetAfter time.Duration
+}
+
+func newLockoutPolicy(threshold, addressThreshold int, duration, maxDuration, resetAfter time.Duration) LockoutPolicy {
+	// Failures may not be forgotten while they still lock their key
+	if resetAfter < maxDuration {
+		resetAfter = maxDuration
+	}
+
+	return LockoutPolicy{
+		AccountThreshold: threshold,
+		AddressThreshold: addressThreshold,
+		Duration:         duration,
+		MaxDuration:      maxDuration,
+		ResetAfter:       resetAfter,
+	}
+}
+
+// lockedUntil returns until when failures lock their key, which is the zero time when they don't
+func (p LockoutPolicy) lockedUntil(failures models.LoginFailures, threshold int) time.Time {
+	if threshold == 0 || failures.Count < threshold {
+		return time.Time{}
+	}
+
+	d := p.Duration
+	for i := threshold; i < failures.Count && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+
+	if d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+
+	return failures.LastFailure.Add(d)
+}
+
+// lockoutKey is a key failed logins are counted for, together with the threshold to lock it
+type lockoutKey struct {
+	key       string
+	threshold int
+}
+
+// lockoutKeys returns the keys failed logins to the account from client are counted for
+func (p LockoutPolicy) lockoutKeys(username string, client ClientInfo) []lockoutKey {
+	var keys []lockoutKey
+
+	if p.AccountThreshold > 0 {
+		keys = append(keys, lockoutKey{accountKeyPrefix + username, p.AccountThreshold})
+	}
+
+	if p.AddressThreshold > 0 && client.IP != "" {
+		keys = append(keys, lockoutKey{addressKeyPrefix + client.IP, p.AddressThreshold})
+	}
+
+	return keys
+}
+
+// checkLockout returns ErrLockedOut when the account, or the source address of the client, is locked
+func (au Aurum) checkLockout(ctx context.Context, username string, client ClientInfo) error {
+	now := time.Now()
+
+	for _, k := range au.lockout.lockoutKeys(username, client) {
+		failures, err := au.db.GetLoginFailures(ctx, k.key)
+		if err == store.ErrNotExists {
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "getting login failures")
+		}
+
+		if now.Before(au.lockout.lockedUntil(failures, k.threshold)) {
+			return ErrLockedOut
+		}
+	}
+
+	return nil
+}
+
+// addLoginFailure counts a failed login to the account from client
+func (au Aurum) addLoginFailure(ctx context.Context, username string, client ClientInfo) error {
+	now := time.Now().UTC()
+
+	for _, k := range au.lockout.lockoutKeys(username, client) {
+		if _, err := au.db.AddLoginFailure(ctx, k.key, now, now.Add(-au.lockout.ResetAfter)); err != nil {
+			return errors.Wrap(err, "counting login failure")
+		}
+	}
+
+	return nil
+}
+
+// resetLoginFailures forgets the failed logins to an account after it was logged in to. Those
+// from the source address are kept, or an attacker could reset them by logging in to their own account.
+func (au Aurum) resetLoginFailures(ctx context.Context, username string) error {
+	if au.lockout.AccountThreshold == 0 {
+		return nil
+	}
+
+	if err := au.db.RemoveLoginFailures(ctx, accountKeyPrefix+username); err != nil && err != store.ErrNotExists {
+		return errors.Wrap(err, "resetting login failures")
+	}
+
+	return nil
+}
+
+// lockoutOf converts failures into the lockout they cause
+func (au Aurum) lockoutOf(failures models.LoginFailures) models.Lockout {
+	lockout := models.Lockout{
+		Failures:    failures.Count,
+		LastFailure: failures.LastFailure,
+	}
+
+	threshold := au.lockout.AddressThreshold
+	if strings.HasPrefix(failures.Key, accountKeyPrefix) {
+		lockout.Username = strings.TrimPrefix(failures.Key, accountKeyPrefix)
+		threshold = au.lockout.AccountThreshold
+	} else {
+		lockout.Address = strings.TrimPrefix(failures.Key, addressKeyPrefix)
+	}
+
+	if until := au.lockout.lockedUntil(failures, threshold); time.Now().Before(until) {
+		lockout.LockedUntil = until
+	}
+
+	return lockout
+}
+
+// GetLockouts lists the accounts and source addresses which are currently locked.
+// Only admins of Aurum may see them.
+func (au Aurum) GetLockouts(ctx context.Context, token string) ([]models.Lockout, error) {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return nil, err
+	}
+
+	list, err := au.db.ListLoginFailures(ctx, time.Now().Add(-au.lockout.MaxDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := []models.Lockout{}
+	for _, failures := range list {
+		if lockout := au.lockoutOf(failures); !lockout.LockedUntil.IsZero() {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+
+	return lockouts, nil
+}
+
+// GetUserLockout returns the failed logins to an account, and whether it's locked.
+// Only admins of Aurum may see it.
+func (au Aurum) GetUserLockout(ctx context.Context, token, username string) (models.Lockout, error) {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return models.Lockout{}, err
+	}
+
+	failures, err := au.db.GetLoginFailures(ctx, accountKeyPrefix+username)
+	if err == store.ErrNotExists {
+		return models.Lockout{Username: username}, nil
+	} else if err != nil {
+		return models.Lockout{}, err
+	}
+
+	return au.lockoutOf(failures), nil
+}
+
+// UnlockUser forgets the failed logins to an account. Only admins of Aurum may unlock accounts.
+func (au Aurum) UnlockUser(ctx context.Context, token, username string) error {
+	return au.unlock(ctx, token, accountKeyPrefix+username)
+}
+
+// UnlockAddress forgets the failed logins from a source address. Only admins of Aurum may unlock addresses.
+func (au Aurum) UnlockAddress(ctx context.Context, token, address string) error {
+	return au.unlock(ctx, token, addressKeyPrefix+address)
+}
+
+func (au Aurum) unlock(ctx context.Context, token, key string) error {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return err
+	}
+
+	// Unlocking what isn't locked is fine
+	if err := au.db.RemoveLoginFailures(ctx, key); err != nil && err != store.ErrNotExists {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveExpiredLoginFailures forgets the failed logins which no longer count, and returns how many keys were removed
+func (au Aurum) RemoveExpiredLoginFailures(ctx context.Context) (int, error) {
+	return au.db.RemoveExpiredLoginFailures(ctx, time.Now().Add(-au.lockout.ResetAfter))
+}
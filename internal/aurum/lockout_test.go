@@ -0,0 +1,296 @@
+package aurum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLockout = newLockoutPolicy(3, 10, time.Minute, 10*time.Minute, time.Hour)
+
+func TestLockoutPolicy_LockedUntil(t *testing.T) {
+	last := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		count    int
+		expected time.Duration
+	}{
+		{count: 1, expected: 0},
+		{count: 2, expected: 0},
+		{count: 3, expected: time.Minute},
+		{count: 4, expected: 2 * time.Minute},
+		{count: 6, expected: 8 * time.Minute},
+		{count: 7, expected: 10 * time.Minute},
+		{count: 100, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		until := testLockout.lockedUntil(models.LoginFailures{Count: tt.count, LastFailure: last}, testLockout.AccountThreshold)
+		if tt.expected == 0 {
+			assert.True(t, until.IsZero(), "count %d", tt.count)
+		} else {
+			assert.Equal(t, last.Add(tt.expected), until, "count %d", tt.count)
+		}
+	}
+
+	// A zero threshold never locks
+	assert.True(t, testLockout.lockedUntil(models.LoginFailures{Count: 100, LastFailure: last}, 0).IsZero())
+
+	// Failures are kept for at least as long as they lock
+	assert.Equal(t, time.Hour, newLockoutPolicy(3, 10, time.Minute, time.Hour, time.Minute).ResetAfter)
+}
+
+func TestAurum_LoginLockedOut(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	au := Aurum{db: ms, lockout: testLockout}
+
+	client := ClientInfo{IP: "192.0.2.1"}
+
+	// The password isn't even checked
+	ms.EXPECT().GetLoginFailures(gomock.Any(), "user:bob").Return(models.LoginFailures{Key: "user:bob", Count: 3, LastFailure: time.Now()}, nil)
+
+	_, err := au.Login(ctx, models.User{Username: "bob", Password: "password"}, client)
+	assert.Equal(t, ErrLockedOut, err)
+
+	// So is the address of the client
+	ms.EXPECT().GetLoginFailures(gomock.Any(), "user:alice").Return(models.LoginFailures{}, store.ErrNotExists)
+	ms.EXPECT().GetLoginFailures(gomock.Any(), "ip:192.0.2.1").Return(models.LoginFailures{Key: "ip:192.0.2.1", Count: 10, LastFailure: time.Now()}, nil)
+
+	_, err = au.Login(ctx, models.User{Username: "alice", Password: "password"}, client)
+	assert.Equal(t, ErrLockedOut, err)
+}
+
+func TestAurum_LoginCountsFailures(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, lockout: testLockout}
+
+	const password = "wH6VLfolKTUb"
+	hashed, err := hash.HashPassword(password)
+	require.NoError(t, err)
+
+	client := ClientInfo{IP: "192.0.2.1"}
+
+	ms.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Return(models.LoginFailures{}, store.ErrNotExists).AnyTimes()
+
+	// A wrong password counts for both the account and the address
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Password: hashed}, nil)
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "user:bob", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, at, since time.Time) (models.LoginFailures, error) {
+			assert.Equal(t, testLockout.ResetAfter, at.Sub(since))
+			return models.LoginFailures{Key: key, Count: 1, LastFailure: at}, nil
+		})
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "ip:192.0.2.1", gomock.Any(), gomock.Any())
+
+	_, err = au.Login(ctx, models.User{Username: "bob", Password: "wrong"}, client)
+	assert.Error(t, err)
+
+	// So does an unknown account
+	ms.EXPECT().GetUser(gomock.Any(), "nobody").Return(models.User{}, store.ErrNotExists)
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "user:nobody", gomock.Any(), gomock.Any())
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "ip:192.0.2.1", gomock.Any(), gomock.Any())
+
+	_, err = au.Login(ctx, models.User{Username: "nobody", Password: "wrong"}, client)
+	assert.Error(t, err)
+
+	// Logging in forgets the failures of the account, but not those of the address
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Password: hashed}, nil)
+	ms.EXPECT().RemoveLoginFailures(gomock.Any(), "user:bob")
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(models.SecondFactor{}, store.ErrNotExists)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	_, err = au.Login(ctx, models.User{Username: "bob", Password: password}, client)
+	assert.NoError(t, err)
+}
+
+func TestAurum_CompleteChallengeCountsFailures(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, lockout: testLockout}
+
+	const password = "wH6VLfolKTUb"
+	hashed, err := hash.HashPassword(password)
+	require.NoError(t, err)
+
+	client := ClientInfo{IP: "192.0.2.1"}
+	factor := models.SecondFactor{Username: "bob", Secret: testSecret, Confirmed: true}
+
+	ms.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Return(models.LoginFailures{}, store.ErrNotExists).AnyTimes()
+
+	// The password alone doesn't forget the failures of the account
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Password: hashed}, nil)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(factor, nil)
+
+	resp, err := au.Login(ctx, models.User{Username: "bob", Password: password}, client)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Challenge)
+
+	// A wrong code counts for both the account and the address
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	expectTx(ms)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(factor, nil)
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "user:bob", gomock.Any(), gomock.Any())
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "ip:192.0.2.1", gomock.Any(), gomock.Any())
+
+	_, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: resp.Challenge, Code: "000000"}, client)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Only completing the challenge does
+	challenge, err := jwt.GenerateChallengeJWT("bob", cfg.SecretKey)
+	require.NoError(t, err)
+
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	expectTx(ms)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(factor, nil)
+	ms.EXPECT().SetSecondFactor(gomock.Any(), gomock.Any())
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Password: hashed}, nil)
+	ms.EXPECT().RemoveLoginFailures(gomock.Any(), "user:bob")
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	_, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: challenge, Code: currentCode(t)}, client)
+	assert.NoError(t, err)
+}
+
+func TestAurum_CompleteChallengeLockedOut(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, lockout: testLockout}
+
+	challenge, err := jwt.GenerateChallengeJWT("bob", cfg.SecretKey)
+	require.NoError(t, err)
+
+	// The code isn't even checked
+	ms.EXPECT().GetLoginFailures(gomock.Any(), "user:bob").Return(models.LoginFailures{Key: "user:bob", Count: 3, LastFailure: time.Now()}, nil)
+
+	_, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: challenge, Code: currentCode(t)}, ClientInfo{})
+	assert.Equal(t, ErrLockedOut, err)
+}
+
+func TestAurum_TOTPCodesCountFailures(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, lockout: testLockout}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	client := ClientInfo{IP: "192.0.2.1"}
+	ms.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Return(models.LoginFailures{}, store.ErrNotExists).Times(4)
+
+	// Wrong codes count for both the account and the address, whether confirming or disabling
+	expectTx(ms).Times(2)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(models.SecondFactor{Username: "bob", Secret: testSecret}, nil)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(models.SecondFactor{Username: "bob", Secret: testSecret, Confirmed: true}, nil)
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "user:bob", gomock.Any(), gomock.Any()).Times(2)
+	ms.EXPECT().AddLoginFailure(gomock.Any(), "ip:192.0.2.1", gomock.Any(), gomock.Any()).Times(2)
+
+	_, err = au.ConfirmTOTP(ctx, token, "000000", client)
+	assert.Equal(t, ErrInvalidInput, err)
+	assert.Equal(t, ErrInvalidInput, au.DisableTOTP(ctx, token, "000000", client))
+
+	// Once locked the codes aren't even checked
+	locked := models.LoginFailures{Key: "user:bob", Count: 3, LastFailure: time.Now()}
+	ms.EXPECT().GetLoginFailures(gomock.Any(), "user:bob").Return(locked, nil).Times(2)
+
+	_, err = au.ConfirmTOTP(ctx, token, currentCode(t), client)
+	assert.Equal(t, ErrLockedOut, err)
+	assert.Equal(t, ErrLockedOut, au.DisableTOTP(ctx, token, currentCode(t), client))
+}
+
+func TestAurum_GetLockouts(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, lockout: testLockout}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	ms.EXPECT().ListLoginFailures(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, since time.Time) ([]models.LoginFailures, error) {
+			// Failures before the longest lock can't lock anything anymore
+			assert.WithinDuration(t, now.Add(-testLockout.MaxDuration), since, time.Minute)
+
+			return []models.LoginFailures{
+				{Key: "ip:192.0.2.1", Count: 10, LastFailure: now},
+				{Key: "user:alice", Count: 2, LastFailure: now},
+				{Key: "user:bob", Count: 3, LastFailure: now},
+			}, nil
+		})
+
+	lockouts, err := au.GetLockouts(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Lockout{
+		{Address: "192.0.2.1", Failures: 10, LastFailure: now, LockedUntil: now.Add(time.Minute)},
+		{Username: "bob", Failures: 3, LastFailure: now, LockedUntil: now.Add(time.Minute)},
+	}, lockouts)
+}
+
+func TestAurum_Unlock(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, lockout: testLockout}
+
+	admin, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	require.NoError(t, err)
+	user, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil).Times(2)
+	ms.EXPECT().RemoveLoginFailures(gomock.Any(), "user:bob")
+	ms.EXPECT().RemoveLoginFailures(gomock.Any(), "ip:2001:db8::1").Return(store.ErrNotExists)
+
+	assert.NoError(t, au.UnlockUser(ctx, admin, "bob"))
+	// Unlocking what isn't locked is fine
+	assert.NoError(t, au.UnlockAddress(ctx, admin, "2001:db8::1"))
+
+	// Users can't unlock themselves
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "bob").Return(models.RoleUser, nil)
+	assert.Equal(t, ErrUnauthorized, au.UnlockUser(ctx, user, "bob"))
+}
@@ -0,0 +1,311 @@
+package aurum
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/internal/mail"
+	"github.com/finitum/aurum/internal/passwords"
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// resetCooldownPrefix prefixes the key of the token bucket which keeps the password reset cooldown of a user
+const resetCooldownPrefix = "reset-mail:"
+
+const resetMailBody = `Hi %s,
+
+Someone asked to reset the password of your Aurum account. If that was you, you can
+choose a new password within %d minutes using %s
+
+If it wasn't you, you can ignore this mail, your password stays the same.
+`
+
+// passwordFingerprint identifies a password hash without revealing it, so reset tokens
+// can be tied to the password a user had when the token was issued
+func passwordFingerprint(hashed string) string {
+	sum := sha256.Sum256([]byte(hashed))
+	return hex.EncodeToString(sum[:16])
+}
+
+// newHashParams reads the password hashing parameters from the config
+func newHashParams(cfg *config.Config) (hash.Params, error) {
+	if cfg.Argon2Memory < 0 || cfg.Argon2Time < 0 || cfg.Argon2Parallelism < 0 || cfg.Argon2Parallelism > 255 {
+		return hash.Params{}, errors.New("argon2 parameters out of range")
+	}
+
+	p := hash.Params{
+		Algorithm:   cfg.PasswordHash,
+		Memory:      uint32(cfg.Argon2Memory),
+		Time:        uint32(cfg.Argon2Time),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		Cost:        cfg.BcryptCost,
+	}
+
+	return p, p.Validate()
+}
+
+// checkPassword checks that a new password of user follows the policy, isn't too long to be hashed,
+// and didn't appear in a data breach
+func (au Aurum) checkPassword(password string, user models.User, policy models.PasswordPolicy) error {
+	if max := au.hashing().MaxLength(); max > 0 && len(password) > max {
+		return ErrWeakPassword
+	}
+
+	if !passwords.Check(policy, password, []string{user.Username, user.Email}) {
+		return ErrWeakPassword
+	}
+
+	if au.breached != nil {
+		breached, err := au.breached.Contains(password)
+		if err != nil {
+			return errors.Wrap(err, "checking breached passwords")
+		} else if breached {
+			return ErrBreachedPassword
+		}
+	}
+
+	return nil
+}
+
+// changePassword checks a new password of user against the password policy and the previous passwords
+// of the user, and hashes it. The current password of user becomes the most recent previous password,
+// and the history is cut to what the policy requires. The caller stores the hash using tx as well.
+func (au Aurum) changePassword(ctx context.Context, tx store.AurumStore, user models.User, password string) (string, error) {
+	policy, err := au.userPolicy(ctx, tx, user.Username)
+	if err != nil {
+		return "", err
+	}
+
+	if err := au.checkPassword(password, user, policy); err != nil {
+		return "", err
+	}
+
+	history, err := tx.GetPasswordHistory(ctx, user.Username)
+	if err != nil {
+		return "", errors.Wrap(err, "getting password history")
+	}
+
+	// The current password counts towards the depth as well
+	previous := append([]string{user.Password}, history...)
+	if len(previous) > policy.HistoryDepth {
+		previous = previous[:policy.HistoryDepth]
+	}
+
+	for _, hashed := range previous {
+		if au.hashing().Verify(password, hashed) {
+			return "", ErrPasswordReused
+		}
+	}
+
+	hashed, err := au.hashing().Hash(password)
+	if err != nil {
+		return "", errors.Wrap(err, "hashing failed")
+	}
+
+	// Once the new password is stored it counts towards the depth, so one previous password less is kept
+	if len(previous) > 0 {
+		previous = previous[:len(previous)-1]
+	}
+
+	if len(history) > 0 || len(previous) > 0 {
+		if err := tx.SetPasswordHistory(ctx, user.Username, previous); err != nil {
+			return "", errors.Wrap(err, "setting password history")
+		}
+	}
+
+	return hashed, nil
+}
+
+// passwordExpired tells whether the password of user is older than the password policy of the user allows.
+// Passwords of which it isn't known when they were set never expire.
+func (au Aurum) passwordExpired(ctx context.Context, user models.User, now time.Time) (bool, error) {
+	if user.PasswordChangedAt.IsZero() {
+		return false, nil
+	}
+
+	policy, err := au.userPolicy(ctx, au.db, user.Username)
+	if err != nil {
+		return false, err
+	}
+
+	if policy.MaxAgeDays == 0 {
+		return false, nil
+	}
+
+	return now.After(user.PasswordChangedAt.AddDate(0, 0, policy.MaxAgeDays)), nil
+}
+
+// mailLink returns what a mail should point the user to, which is a page with the token in its
+// query, or just the token when no page is configured
+func mailLink(page, token string) (string, error) {
+	if page == "" {
+		return "this token:\n\n" + token, nil
+	}
+
+	u, err := url.Parse(page)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid url")
+	}
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return "this link:\n\n" + u.String(), nil
+}
+
+// ForgotPassword mails a password reset token to a user. To not reveal which users exist,
+// nothing happens for unknown users, or users without an email address. Neither does anything
+// happen while the last mail to the user was sent less than the cooldown ago.
+func (au Aurum) ForgotPassword(ctx context.Context, username string) error {
+	user, err := au.db.GetUser(ctx, username)
+	if err == store.ErrNotExists {
+		log.WithField("username", username).Debug("Password reset requested for unknown user")
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "getting user")
+	}
+
+	if user.Email == "" {
+		log.WithField("username", username).Debug("Password reset requested for user without email")
+		return nil
+	}
+
+	// Otherwise anyone could flood the inbox of any user, as the request isn't authenticated
+	if au.resetCooldown > 0 {
+		wait, err := au.db.TakeRateLimitToken(ctx, resetCooldownPrefix+user.Username, 1, au.resetCooldown, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "password reset cooldown")
+		} else if wait > 0 {
+			log.WithField("username", username).Debug("Password reset requested again during cooldown")
+			return nil
+		}
+	}
+
+	token, err := jwt.GenerateResetJWT(user.Username, passwordFingerprint(user.Password), au.sk)
+	if err != nil {
+		return errors.Wrap(err, "jwt generation error")
+	}
+
+	link, err := mailLink(au.resetURL, token)
+	if err != nil {
+		return errors.Wrap(err, "password reset link")
+	}
+
+	return au.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Aurum password",
+		Body:    fmt.Sprintf(resetMailBody, user.Username, int(jwt.ResetExpiry.Minutes()), link),
+	})
+}
+
+// RemoveExpiredCooldowns forgets the password reset cooldowns which have passed, and returns how many
+// were removed. They are kept with the rate limits in the store, so those are removed as well.
+func (au Aurum) RemoveExpiredCooldowns(ctx context.Context) (int, error) {
+	return au.db.RemoveExpiredRateLimits(ctx, time.Now())
+}
+
+// ResetPassword sets a new password with a password reset token. Every token can be used once,
+// and only while the password is still the same as when it was issued. As the old password
+// might have been compromised, the user is signed out everywhere.
+func (au Aurum) ResetPassword(ctx context.Context, reset models.PasswordReset) error {
+	claims, err := jwt.VerifyResetJWT(reset.Token, au.pk)
+	if err != nil {
+		return ErrUnauthorized
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		user, err := tx.GetUser(ctx, claims.Subject)
+		if err == store.ErrNotExists {
+			return ErrUnauthorized
+		} else if err != nil {
+			return err
+		}
+
+		if passwordFingerprint(user.Password) != claims.Fingerprint {
+			return ErrUnauthorized
+		}
+
+		hashed, err := au.changePassword(ctx, tx, user, reset.Password)
+		if err != nil {
+			return err
+		}
+
+		fresh, err := tx.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+		if err != nil {
+			return errors.Wrap(err, "revoking reset token")
+		} else if !fresh {
+			return ErrUnauthorized
+		}
+
+		user.Password = hashed
+		user.PasswordChangedAt = time.Now()
+
+		if _, err := tx.SetUser(ctx, user); err != nil {
+			return err
+		}
+
+		return revokeSessions(ctx, tx, user.Username, "")
+	})
+}
+
+// ChangeExpiredPassword replaces an expired password using the token Login or CompleteChallenge handed
+// out instead, after which a new session is started. Every token can be used once, and only while the
+// password is still the expired one, which can't be chosen again.
+func (au Aurum) ChangeExpiredPassword(ctx context.Context, change models.PasswordChange, client ClientInfo) (models.LoginResponse, error) {
+	claims, err := jwt.VerifyPasswordExpiredJWT(change.Token, au.pk)
+	if err != nil {
+		return models.LoginResponse{}, ErrUnauthorized
+	}
+
+	var user models.User
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		var err error
+		user, err = tx.GetUser(ctx, claims.Subject)
+		if err == store.ErrNotExists {
+			return ErrUnauthorized
+		} else if err != nil {
+			return err
+		}
+
+		if passwordFingerprint(user.Password) != claims.Fingerprint {
+			return ErrUnauthorized
+		}
+
+		// Even when the policy keeps no history, the expired password has to be replaced
+		if au.hashing().Verify(change.Password, user.Password) {
+			return ErrPasswordReused
+		}
+
+		hashed, err := au.changePassword(ctx, tx, user, change.Password)
+		if err != nil {
+			return err
+		}
+
+		fresh, err := tx.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+		if err != nil {
+			return errors.Wrap(err, "revoking password expired token")
+		} else if !fresh {
+			return ErrUnauthorized
+		}
+
+		_, err = tx.SetUser(ctx, models.User{Username: user.Username, Password: hashed, PasswordChangedAt: time.Now()})
+		return err
+	})
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	// The token was only handed out after all factors were supplied
+	return au.finishLogin(ctx, user, false, client)
+}
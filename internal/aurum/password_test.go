@@ -0,0 +1,399 @@
+package aurum
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/internal/mail"
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mailbox is a mail.Mailer which keeps the messages sent through it
+type mailbox struct {
+	messages []mail.Message
+}
+
+func (m *mailbox) Send(_ context.Context, msg mail.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var resetLink = regexp.MustCompile(`https://aurum\.example\.com/reset\?token=\S+`)
+
+func TestAurum_ForgotPassword(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	mb := &mailbox{}
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, mailer: mb, resetURL: "https://aurum.example.com/reset"}
+
+	user := models.User{Username: "bob", Email: "bob@example.com", Password: "hash"}
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil)
+
+	require.NoError(t, au.ForgotPassword(ctx, "bob"))
+	require.Len(t, mb.messages, 1)
+	assert.Equal(t, user.Email, mb.messages[0].To)
+
+	link, err := url.Parse(resetLink.FindString(mb.messages[0].Body))
+	require.NoError(t, err)
+
+	claims, err := jwt.VerifyResetJWT(link.Query().Get("token"), cfg.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", claims.Subject)
+	assert.Equal(t, passwordFingerprint(user.Password), claims.Fingerprint)
+}
+
+func TestAurum_ForgotPasswordCooldown(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	mb := &mailbox{}
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, mailer: mb, resetCooldown: 5 * time.Minute}
+
+	user := models.User{Username: "bob", Email: "bob@example.com", Password: "hash"}
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil).Times(2)
+	gomock.InOrder(
+		ms.EXPECT().TakeRateLimitToken(gomock.Any(), "reset-mail:bob", 1, 5*time.Minute, gomock.Any()).Return(time.Duration(0), nil),
+		ms.EXPECT().TakeRateLimitToken(gomock.Any(), "reset-mail:bob", 1, 5*time.Minute, gomock.Any()).Return(4*time.Minute, nil),
+	)
+
+	// The second request succeeds as well, without sending another mail
+	assert.NoError(t, au.ForgotPassword(ctx, "bob"))
+	assert.NoError(t, au.ForgotPassword(ctx, "bob"))
+	assert.Len(t, mb.messages, 1)
+}
+
+func TestAurum_ForgotPasswordUnknownUser(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	mb := &mailbox{}
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, mailer: mb}
+
+	// Requests for unknown users and users without email succeed, without sending anything
+	ms.EXPECT().GetUser(gomock.Any(), "nobody").Return(models.User{}, store.ErrNotExists)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob"}, nil)
+
+	assert.NoError(t, au.ForgotPassword(ctx, "nobody"))
+	assert.NoError(t, au.ForgotPassword(ctx, "bob"))
+	assert.Empty(t, mb.messages)
+}
+
+func TestAurum_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	user := models.User{Username: "bob", Email: "bob@example.com", Password: "hash"}
+	token, err := jwt.GenerateResetJWT(user.Username, passwordFingerprint(user.Password), cfg.SecretKey)
+	require.NoError(t, err)
+
+	const password = "7da033bd32005113f2208eb87bc94c126a42aadf"
+
+	expectTx(ms).Times(3)
+	ms.EXPECT().GetUser(gomock.Any(), user.Username).Return(user, nil).Times(2)
+	expectUserPolicy(ms, user.Username).Times(2)
+
+	// Weak passwords are refused, without using up the token
+	err = au.ResetPassword(ctx, models.PasswordReset{Token: token, Password: "password"})
+	assert.Equal(t, ErrWeakPassword, err)
+
+	expectNoPasswordHistory(ms, user.Username)
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+		assert.True(t, hash.CheckPasswordHash(password, u.Password))
+		assert.WithinDuration(t, time.Now(), u.PasswordChangedAt, time.Minute)
+		user = u
+		return u, nil
+	})
+
+	// The user is signed out everywhere
+	ms.EXPECT().GetSessions(gomock.Any(), "bob").Return([]models.Session{{ID: "session", Username: "bob"}}, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "bob", "session")
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:session", gomock.Any()).Return(true, nil)
+
+	err = au.ResetPassword(ctx, models.PasswordReset{Token: token, Password: password})
+	assert.NoError(t, err)
+
+	// The token can't be used again, as the password changed
+	ms.EXPECT().GetUser(gomock.Any(), user.Username).Return(user, nil)
+
+	err = au.ResetPassword(ctx, models.PasswordReset{Token: token, Password: password})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_ResetPasswordUsedToken(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	user := models.User{Username: "bob", Password: "hash"}
+	token, err := jwt.GenerateResetJWT(user.Username, passwordFingerprint(user.Password), cfg.SecretKey)
+	require.NoError(t, err)
+
+	expectTx(ms)
+	ms.EXPECT().GetUser(gomock.Any(), user.Username).Return(user, nil)
+	expectUserPolicy(ms, user.Username)
+	expectNoPasswordHistory(ms, user.Username)
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+
+	err = au.ResetPassword(ctx, models.PasswordReset{Token: token, Password: "7da033bd32005113f2208eb87bc94c126a42aadf"})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Login tokens aren't reset tokens
+	login, err := jwt.GenerateJWT(user.Username, false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	err = au.ResetPassword(ctx, models.PasswordReset{Token: login, Password: "7da033bd32005113f2208eb87bc94c126a42aadf"})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+// expectPolicy expects the groups of a user to be looked up for their password policies, with a
+// group which has policy
+func expectPolicy(ms *mock_store.MockAurumStore, username string, policy models.PasswordPolicy) *gomock.Call {
+	return ms.EXPECT().GetGroupsForUser(gomock.Any(), username).Return([]models.GroupWithRole{
+		{Group: models.Group{Name: "group", PasswordPolicy: &policy}, Role: models.RoleUser},
+	}, nil)
+}
+
+func TestAurum_UpdateUserPasswordHistory(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	used := []string{
+		"7da033bd32005113f2208eb87bc94c12",
+		"6a42aadf0c94065b1fa4d9d68e7c318f",
+		"wH6VLfolKTUb",
+		"c2e54e8d5ae2b13d0c4ee9bbd6a6e6fc",
+	}
+
+	hashes := make([]string, len(used))
+	for i, password := range used {
+		hashes[i], err = au.hashing().Hash(password)
+		require.NoError(t, err)
+	}
+
+	// The current password is the first, followed by the history
+	current := models.User{Username: "bob", Password: hashes[0]}
+
+	expectTx(ms).Times(4)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(current, nil).Times(4)
+	expectPolicy(ms, "bob", models.PasswordPolicy{HistoryDepth: 3}).Times(4)
+	ms.EXPECT().GetPasswordHistory(gomock.Any(), "bob").Return(hashes[1:], nil).Times(4)
+
+	// Neither the current password nor the two most recent previous ones can be used again
+	for _, password := range used[:3] {
+		_, err = au.UpdateUser(ctx, token, models.User{Password: password})
+		assert.Equal(t, ErrPasswordReused, err)
+	}
+
+	// Only what the depth requires is kept, with the current password as most recent
+	ms.EXPECT().SetPasswordHistory(gomock.Any(), "bob", hashes[:2])
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).Return(models.User{Username: "bob"}, nil)
+
+	_, err = au.UpdateUser(ctx, token, models.User{Password: used[3]})
+	assert.NoError(t, err)
+}
+
+func TestAurum_LoginPasswordExpired(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	const password = "7da033bd32005113f2208eb87bc94c12"
+
+	hashed, err := au.hashing().Hash(password)
+	require.NoError(t, err)
+
+	user := models.User{Username: "bob", Password: hashed, PasswordChangedAt: time.Now().AddDate(0, 0, -31)}
+
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil)
+	expectPolicy(ms, "bob", models.PasswordPolicy{MaxAgeDays: 30})
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(models.SecondFactor{}, store.ErrNotExists)
+
+	// No session is started, only the password can be changed
+	lr, err := au.Login(ctx, models.User{Username: "bob", Password: password}, ClientInfo{})
+	require.NoError(t, err)
+	assert.Empty(t, lr.LoginToken)
+	assert.Empty(t, lr.RefreshToken)
+
+	claims, err := jwt.VerifyPasswordExpiredJWT(lr.PasswordExpired, cfg.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", claims.Subject)
+	assert.Equal(t, passwordFingerprint(hashed), claims.Fingerprint)
+
+	// Within the maximum age the login succeeds
+	user.PasswordChangedAt = time.Now().AddDate(0, 0, -29)
+
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil)
+	expectPolicy(ms, "bob", models.PasswordPolicy{MaxAgeDays: 30})
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(models.SecondFactor{}, store.ErrNotExists)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	lr, err = au.Login(ctx, models.User{Username: "bob", Password: password}, ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, lr.LoginToken)
+	assert.Empty(t, lr.PasswordExpired)
+}
+
+func TestAurum_LoginPasswordExpiredSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	const expired = "7da033bd32005113f2208eb87bc94c12"
+	const password = "6a42aadf0c94065b1fa4d9d68e7c318f"
+
+	hashed, err := au.hashing().Hash(expired)
+	require.NoError(t, err)
+
+	user := models.User{Username: "bob", Password: hashed, PasswordChangedAt: time.Now().AddDate(0, 0, -31)}
+	factor := models.SecondFactor{Username: "bob", Secret: testSecret, Confirmed: true}
+
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil).Times(2)
+	expectPolicy(ms, "bob", models.PasswordPolicy{MaxAgeDays: 30}).Times(2)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(factor, nil)
+
+	// The password alone only gets a challenge, it doesn't reveal the expiry nor allow changing the password
+	lr, err := au.Login(ctx, models.User{Username: "bob", Password: expired}, ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, lr.Challenge)
+	assert.Empty(t, lr.PasswordExpired)
+	assert.Empty(t, lr.LoginToken)
+
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	expectTx(ms)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "bob").Return(factor, nil)
+	ms.EXPECT().SetSecondFactor(gomock.Any(), gomock.Any())
+
+	// Once the second factor is supplied, the password has to be changed instead of starting a session
+	lr, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: lr.Challenge, Code: currentCode(t)}, ClientInfo{})
+	require.NoError(t, err)
+	assert.Empty(t, lr.LoginToken)
+	assert.Empty(t, lr.RefreshToken)
+
+	claims, err := jwt.VerifyPasswordExpiredJWT(lr.PasswordExpired, cfg.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", claims.Subject)
+
+	// Which starts the session without asking for the second factor again
+	expectTx(ms)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil)
+	expectPolicy(ms, "bob", models.PasswordPolicy{MaxAgeDays: 30})
+	expectNoPasswordHistory(ms, "bob")
+	ms.EXPECT().RevokeToken(gomock.Any(), claims.Id, gomock.Any()).Return(true, nil)
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any())
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	lr, err = au.ChangeExpiredPassword(ctx, models.PasswordChange{Token: lr.PasswordExpired, Password: password}, ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, lr.LoginToken)
+	assert.NotEmpty(t, lr.RefreshToken)
+}
+
+func TestAurum_ChangeExpiredPassword(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	const expired = "7da033bd32005113f2208eb87bc94c12"
+	const password = "6a42aadf0c94065b1fa4d9d68e7c318f"
+
+	hashed, err := au.hashing().Hash(expired)
+	require.NoError(t, err)
+
+	user := models.User{Username: "bob", Password: hashed, PasswordChangedAt: time.Now().AddDate(-1, 0, 0)}
+	token, err := jwt.GeneratePasswordExpiredJWT(user.Username, passwordFingerprint(user.Password), cfg.SecretKey)
+	require.NoError(t, err)
+
+	expectTx(ms).Times(3)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil).Times(2)
+
+	// The expired password can't be kept, without using up the token
+	_, err = au.ChangeExpiredPassword(ctx, models.PasswordChange{Token: token, Password: expired}, ClientInfo{})
+	assert.Equal(t, ErrPasswordReused, err)
+
+	expectUserPolicy(ms, "bob")
+	expectNoPasswordHistory(ms, "bob")
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u models.User) (models.User, error) {
+		assert.True(t, hash.CheckPasswordHash(password, u.Password))
+		assert.WithinDuration(t, time.Now(), u.PasswordChangedAt, time.Minute)
+		user.Password = u.Password
+		return u, nil
+	})
+
+	// After which a session is started
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	lr, err := au.ChangeExpiredPassword(ctx, models.PasswordChange{Token: token, Password: password}, ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, lr.LoginToken)
+	assert.NotEmpty(t, lr.RefreshToken)
+
+	// The token can't be used again, as the password changed
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(user, nil)
+
+	_, err = au.ChangeExpiredPassword(ctx, models.PasswordChange{Token: token, Password: password}, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Login tokens aren't password expired tokens
+	login, err := jwt.GenerateJWT(user.Username, false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	_, err = au.ChangeExpiredPassword(ctx, models.PasswordChange{Token: login, Password: password}, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+}
@@ -0,0 +1,91 @@
+package aurum
+
+import (
+	"context"
+
+	"github.com/finitum/aurum/internal/passwords"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// basePolicy is the password policy of Aurum, which is passwords.DefaultPolicy unless configured
+func (au Aurum) basePolicy() models.PasswordPolicy {
+	if au.policy == nil {
+		return passwords.DefaultPolicy
+	}
+	return *au.policy
+}
+
+// signUpPolicy is the password policy new users have to follow, which is that of Aurum made stricter by
+// the aurum group they join
+func (au Aurum) signUpPolicy(ctx context.Context) (models.PasswordPolicy, error) {
+	policy := au.basePolicy()
+
+	group, err := au.db.GetGroup(ctx, AurumName)
+	if err != nil {
+		return models.PasswordPolicy{}, errors.Wrap(err, "getting aurum group")
+	}
+
+	if group.PasswordPolicy != nil {
+		policy = passwords.Stricter(policy, *group.PasswordPolicy)
+	}
+
+	return policy, nil
+}
+
+// userPolicy is the password policy a user has to follow, which is that of Aurum made stricter by
+// the groups the user is a member of
+func (au Aurum) userPolicy(ctx context.Context, db store.AurumStore, username string) (models.PasswordPolicy, error) {
+	policy := au.basePolicy()
+
+	groups, err := db.GetGroupsForUser(ctx, username)
+	if err == store.ErrNotExists {
+		return policy, nil
+	} else if err != nil {
+		return models.PasswordPolicy{}, errors.Wrap(err, "getting groups of user")
+	}
+
+	for _, group := range groups {
+		if group.PasswordPolicy != nil {
+			policy = passwords.Stricter(policy, *group.PasswordPolicy)
+		}
+	}
+
+	return policy, nil
+}
+
+// GetPasswordPolicy returns the password policy new users have to follow
+func (au Aurum) GetPasswordPolicy(ctx context.Context) (models.PasswordPolicy, error) {
+	return au.signUpPolicy(ctx)
+}
+
+// GetUserPasswordPolicy returns the password policy the user of the token has to follow
+// when changing their password, which depends on the groups they are a member of
+func (au Aurum) GetUserPasswordPolicy(ctx context.Context, token string) (models.PasswordPolicy, error) {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return models.PasswordPolicy{}, err
+	}
+
+	return au.userPolicy(ctx, au.db, claims.Username)
+}
+
+// validPolicy checks that passwords can follow a password policy of a group. As members follow it
+// combined with the policy of Aurum, that combination has to make sense as well, and be hashable.
+func (au Aurum) validPolicy(policy *models.PasswordPolicy) bool {
+	if policy == nil {
+		return true
+	}
+
+	if !passwords.Valid(*policy) {
+		return false
+	}
+
+	combined := passwords.Stricter(au.basePolicy(), *policy)
+	if max := au.hashing().MaxLength(); max > 0 && combined.MinLength > max {
+		return false
+	}
+
+	return passwords.Valid(combined)
+}
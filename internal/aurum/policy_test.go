@@ -0,0 +1,168 @@
+package aurum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/internal/passwords"
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAurum_GetPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	base := models.PasswordPolicy{MinLength: 10, MinScore: 2}
+	au := Aurum{db: ms, policy: &base}
+
+	// Without a policy for the aurum group that of Aurum applies
+	expectSignUpPolicy(ms)
+
+	policy, err := au.GetPasswordPolicy(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, base, policy)
+
+	ms.EXPECT().GetGroup(gomock.Any(), AurumName).Return(&models.Group{
+		Name:           AurumName,
+		PasswordPolicy: &models.PasswordPolicy{MinLength: 8, MinScore: 3},
+	}, nil)
+
+	policy, err = au.GetPasswordPolicy(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PasswordPolicy{MinLength: 10, MinScore: 3}, policy)
+}
+
+func TestAurum_GetUserPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	ms.EXPECT().GetGroupsForUser(gomock.Any(), "bob").Return([]models.GroupWithRole{
+		{Group: models.Group{Name: AurumName}, Role: models.RoleUser},
+		{Group: models.Group{Name: "a", PasswordPolicy: &models.PasswordPolicy{MinLength: 12}}, Role: models.RoleUser},
+		{Group: models.Group{Name: "b", PasswordPolicy: &models.PasswordPolicy{RequireSymbol: true, MaxAgeDays: 90}}, Role: models.RoleAdmin},
+	}, nil)
+
+	policy, err := au.GetUserPasswordPolicy(ctx, token)
+	assert.NoError(t, err)
+
+	expected := passwords.DefaultPolicy
+	expected.MinLength = 12
+	expected.RequireSymbol = true
+	expected.MaxAgeDays = 90
+	assert.Equal(t, expected, policy)
+}
+
+func TestAurum_UpdateUserGroupPolicy(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	// Strong enough for Aurum, but not for the group
+	expectTx(ms)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob"}, nil)
+	ms.EXPECT().GetGroupsForUser(gomock.Any(), "bob").Return([]models.GroupWithRole{
+		{Group: models.Group{Name: "group", PasswordPolicy: &models.PasswordPolicy{MinLength: 16}}, Role: models.RoleUser},
+	}, nil)
+
+	_, err = au.UpdateUser(ctx, token, models.User{Password: "wH6VLfolKTUb"})
+	assert.Equal(t, ErrWeakPassword, err)
+}
+
+func TestAurum_UpdateGroupInvalidPolicy(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleAdmin, nil)
+
+	_, err = au.UpdateGroup(ctx, token, "group", models.GroupUpdate{
+		PasswordPolicy: &models.PasswordPolicy{MinLength: 12, MaxLength: 8},
+	})
+	assert.Equal(t, ErrInvalidInput, err)
+}
+
+func TestAurum_UpdateGroupPolicyConflictsWithAurum(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, policy: &models.PasswordPolicy{MinLength: 12}}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleAdmin, nil)
+
+	// Valid on its own, but no password is both long enough for Aurum and short enough for the group
+	_, err = au.UpdateGroup(ctx, token, "group", models.GroupUpdate{
+		PasswordPolicy: &models.PasswordPolicy{MaxLength: 10},
+	})
+	assert.Equal(t, ErrInvalidInput, err)
+}
+
+func TestAurum_UpdateGroupRemovePolicy(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	current := models.Group{Name: "group", PasswordPolicy: &models.PasswordPolicy{MinLength: 12}}
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), "group", "bob").Return(models.RoleAdmin, nil)
+	expectTx(ms)
+	ms.EXPECT().GetGroup(gomock.Any(), "group").Return(&current, nil)
+	ms.EXPECT().SetGroup(gomock.Any(), models.Group{Name: "group"})
+
+	// The zero policy removes it
+	group, err := au.UpdateGroup(ctx, token, "group", models.GroupUpdate{PasswordPolicy: &models.PasswordPolicy{}})
+	assert.NoError(t, err)
+	assert.Nil(t, group.PasswordPolicy)
+}
@@ -0,0 +1,209 @@
+package aurum
+
+import (
+	"context"
+	"time"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// ClientInfo describes the client a login or refresh came from, it's recorded in the session
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// startSession starts a new session for user, and hands out the first token pair of its family
+func (au Aurum) startSession(ctx context.Context, username string, client ClientInfo) (jwt.TokenPair, error) {
+	family := uuid.New().String()
+
+	tp, err := jwt.GenerateFamilyJWTPair(username, family, au.sk)
+	if err != nil {
+		return jwt.TokenPair{}, errors.Wrap(err, "jwt generation error")
+	}
+
+	now := time.Now().UTC()
+	if err := au.db.CreateSession(ctx, models.Session{
+		ID:          family,
+		Username:    username,
+		CreatedAt:   now,
+		LastRefresh: now,
+		ExpiresAt:   jwt.RefreshTokenExpiry(now),
+		IP:          client.IP,
+		UserAgent:   client.UserAgent,
+	}); err != nil {
+		return jwt.TokenPair{}, errors.Wrap(err, "creating session")
+	}
+
+	return tp, nil
+}
+
+// refreshSession records a refresh of a session. Token families which were started
+// before sessions existed get a session at their first refresh.
+func refreshSession(ctx context.Context, db store.AurumStore, username, id string, client ClientInfo) error {
+	now := time.Now().UTC()
+
+	session, err := db.GetSession(ctx, username, id)
+	exists := err == nil
+	if err == store.ErrNotExists {
+		session = models.Session{ID: id, Username: username, CreatedAt: now}
+	} else if err != nil {
+		return err
+	}
+
+	session.LastRefresh = now
+	session.ExpiresAt = jwt.RefreshTokenExpiry(now)
+	session.IP = client.IP
+	session.UserAgent = client.UserAgent
+
+	if exists {
+		return db.SetSession(ctx, session)
+	}
+
+	return db.CreateSession(ctx, session)
+}
+
+// endSession revokes all tokens of a family and forgets about its session, if it has one
+func endSession(ctx context.Context, db store.AurumStore, username, id string) error {
+	if err := revokeFamily(ctx, db, id); err != nil {
+		return err
+	}
+
+	if err := db.RemoveSession(ctx, username, id); err != nil && err != store.ErrNotExists {
+		return err
+	}
+
+	return nil
+}
+
+// revokeSession ends an existing session. If it doesn't exist ErrNotExists is returned.
+func revokeSession(ctx context.Context, db store.AurumStore, username, id string) error {
+	if err := db.RemoveSession(ctx, username, id); err != nil {
+		return err
+	}
+
+	return revokeFamily(ctx, db, id)
+}
+
+// revokeSessions ends all sessions of a user, except the one with id keep
+func revokeSessions(ctx context.Context, db store.AurumStore, username, keep string) error {
+	sessions, err := db.GetSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		if s.ID == keep {
+			continue
+		}
+
+		if err := revokeSession(ctx, db, username, s.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listSessions gets the sessions of a user, marking the one with id current
+func (au Aurum) listSessions(ctx context.Context, username, current string) ([]models.Session, error) {
+	sessions, err := au.db.GetSessions(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	return sessions, nil
+}
+
+// GetSessions lists the sessions of the user the token belongs to, marking the one it's from
+func (au Aurum) GetSessions(ctx context.Context, token string) ([]models.Session, error) {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return au.listSessions(ctx, claims.Username, claims.Family)
+}
+
+// RevokeSession ends one of the sessions of the user the token belongs to
+func (au Aurum) RevokeSession(ctx context.Context, token, id string) error {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		return revokeSession(ctx, tx, claims.Username, id)
+	})
+}
+
+// RevokeOtherSessions ends all sessions of the user the token belongs to, except the one it's from
+func (au Aurum) RevokeOtherSessions(ctx context.Context, token string) error {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		return revokeSessions(ctx, tx, claims.Username, claims.Family)
+	})
+}
+
+// GetUserSessions lists the sessions of any user. Only admins of Aurum may do so.
+func (au Aurum) GetUserSessions(ctx context.Context, token, username string) ([]models.Session, error) {
+	claims, err := au.requireAdmin(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := au.db.GetUser(ctx, username); err != nil {
+		return nil, err
+	}
+
+	var current string
+	if username == claims.Username {
+		current = claims.Family
+	}
+
+	return au.listSessions(ctx, username, current)
+}
+
+// RevokeUserSession ends a session of any user. Only admins of Aurum may do so.
+func (au Aurum) RevokeUserSession(ctx context.Context, token, username, id string) error {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return err
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		return revokeSession(ctx, tx, username, id)
+	})
+}
+
+// RevokeUserSessions ends all sessions of any user, signing them out everywhere.
+// Only admins of Aurum may do so.
+func (au Aurum) RevokeUserSessions(ctx context.Context, token, username string) error {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return err
+	}
+
+	if _, err := au.db.GetUser(ctx, username); err != nil {
+		return err
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		return revokeSessions(ctx, tx, username, "")
+	})
+}
+
+// RemoveExpiredSessions forgets about sessions which have expired since
+func (au Aurum) RemoveExpiredSessions(ctx context.Context) (int, error) {
+	return au.db.RemoveExpiredSessions(ctx, time.Now())
+}
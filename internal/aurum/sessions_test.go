@@ -0,0 +1,201 @@
+package aurum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAurum_GetSessions(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateFamilyJWT("jeff", false, "current", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetSessions(gomock.Any(), "jeff").Return([]models.Session{
+		{ID: "other", Username: "jeff"},
+		{ID: "current", Username: "jeff"},
+	}, nil)
+
+	// SUT
+	sessions, err := au.GetSessions(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Session{
+		{ID: "other", Username: "jeff"},
+		{ID: "current", Username: "jeff", Current: true},
+	}, sessions)
+}
+
+func TestAurum_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("jeff", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	expectTx(ms)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", "session")
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:session", gomock.Any()).Return(true, nil)
+
+	// SUT
+	err = au.RevokeSession(ctx, token, "session")
+	assert.NoError(t, err)
+}
+
+func TestAurum_RevokeSessionNotExists(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("jeff", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	// Sessions of other users are never found
+	expectTx(ms)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", "session").Return(store.ErrNotExists)
+
+	// SUT
+	err = au.RevokeSession(ctx, token, "session")
+	assert.Equal(t, store.ErrNotExists, err)
+}
+
+func TestAurum_RevokeOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateFamilyJWT("jeff", false, "current", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	expectTx(ms)
+	ms.EXPECT().GetSessions(gomock.Any(), "jeff").Return([]models.Session{
+		{ID: "other", Username: "jeff"},
+		{ID: "current", Username: "jeff"},
+	}, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", "other")
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:other", gomock.Any()).Return(true, nil)
+
+	// SUT
+	err = au.RevokeOtherSessions(ctx, token)
+	assert.NoError(t, err)
+}
+
+func TestAurum_GetUserSessions(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	sessions := []models.Session{{ID: "session", Username: "bob"}}
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob"}, nil)
+	ms.EXPECT().GetSessions(gomock.Any(), "bob").Return(sessions, nil)
+
+	// SUT
+	got, err := au.GetUserSessions(ctx, token, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, sessions, got)
+}
+
+func TestAurum_GetUserSessionsNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("alice", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "alice").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.GetUserSessions(ctx, token, "bob")
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_RevokeUserSessions(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob"}, nil)
+
+	expectTx(ms)
+	ms.EXPECT().GetSessions(gomock.Any(), "bob").Return([]models.Session{
+		{ID: "a", Username: "bob"},
+		{ID: "b", Username: "bob"},
+	}, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "bob", "a")
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:a", gomock.Any()).Return(true, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "bob", "b")
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:b", gomock.Any()).Return(true, nil)
+
+	// SUT
+	err = au.RevokeUserSessions(ctx, token, "bob")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,188 @@
+package aurum
+
+import (
+	"context"
+	"time"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// familyRevocationPrefix prefixes the id under which the revocation of a whole token family is stored
+const familyRevocationPrefix = "family:"
+
+// tokenFamily returns the family a token belongs to. Tokens which were issued before
+// families existed form a family of their own.
+func tokenFamily(claims *jwt.Claims) string {
+	if claims.Family == "" {
+		return claims.Id
+	}
+
+	return claims.Family
+}
+
+// isRevoked checks whether the token with the given id, or the family it belongs to, has been revoked
+func (au Aurum) isRevoked(ctx context.Context, id, family string) (bool, error) {
+	revoked, err := au.db.IsTokenRevoked(ctx, id)
+	if err != nil || revoked || family == "" {
+		return revoked, err
+	}
+
+	return au.db.IsTokenRevoked(ctx, familyRevocationPrefix+family)
+}
+
+// revokeFamily revokes all tokens of a family. Refresh tokens are the longest lived tokens
+// in a family, so the revocation has to last as long as a refresh token issued right now.
+func revokeFamily(ctx context.Context, db store.AurumStore, family string) error {
+	_, err := db.RevokeToken(ctx, familyRevocationPrefix+family, jwt.RefreshTokenExpiry(time.Now()))
+	return err
+}
+
+// checkRefreshToken checks that token is a valid refresh token which hasn't been revoked
+func (au Aurum) checkRefreshToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	if token == "" {
+		return nil, ErrInvalidInput
+	}
+
+	claims, err := au.verifyToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.Refresh {
+		return nil, ErrInvalidInput
+	}
+
+	return claims, nil
+}
+
+// RefreshToken rotates the token pair: the refresh token is replaced by a new one of the same family,
+// and a new login token is handed out. A refresh token can only be used once, so when one which was
+// rotated already is presented again it has leaked, and the whole family is revoked.
+// The refresh is recorded in the session of the family, together with the rotation, so a failed
+// refresh leaves the old refresh token usable. Once the password expired tokens aren't refreshed anymore.
+func (au Aurum) RefreshToken(ctx context.Context, tp *jwt.TokenPair, client ClientInfo) error {
+	if tp.RefreshToken == "" {
+		return ErrInvalidInput
+	}
+
+	// Revocation of the token itself is checked below, it indicates reuse.
+	claims, err := au.parseToken(tp.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	if !claims.Refresh {
+		return ErrInvalidInput
+	}
+
+	family := tokenFamily(claims)
+
+	revoked, err := au.db.IsTokenRevoked(ctx, familyRevocationPrefix+family)
+	if err != nil {
+		return errors.Wrap(err, "checking token revocation")
+	} else if revoked {
+		return ErrUnauthorized
+	}
+
+	user, err := au.db.GetUser(ctx, claims.Username)
+	if err == store.ErrNotExists {
+		return ErrUnauthorized
+	} else if err != nil {
+		return errors.Wrap(err, "getting user from db failed")
+	}
+
+	// Sessions don't outlive the password, once it expired the user has to log in again to change it
+	expired, err := au.passwordExpired(ctx, user, time.Now())
+	if err != nil {
+		return err
+	} else if expired {
+		return ErrUnauthorized
+	}
+
+	// The pair is generated up front, so nothing can fail anymore once the old refresh token is revoked
+	pair, err := jwt.GenerateFamilyJWTPair(claims.Username, family, au.sk)
+	if err != nil {
+		return errors.Wrap(err, "jwt generation error")
+	}
+
+	var rotated bool
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		var err error
+		if rotated, err = tx.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			return errors.Wrap(err, "revoking refresh token")
+		}
+
+		if !rotated {
+			return nil
+		}
+
+		return errors.Wrap(refreshSession(ctx, tx, claims.Username, family, client), "updating session")
+	})
+	if err != nil {
+		return err
+	}
+
+	if !rotated {
+		log.WithFields(log.Fields{
+			"username": claims.Username,
+			"jti":      claims.Id,
+			"family":   family,
+		}).Warn("Refresh token reused, revoking its token family")
+
+		err := au.db.WithTx(ctx, func(tx store.AurumStore) error {
+			return endSession(ctx, tx, claims.Username, family)
+		})
+		if err != nil {
+			return errors.Wrap(err, "revoking token family")
+		}
+
+		return ErrUnauthorized
+	}
+
+	*tp = pair
+
+	return nil
+}
+
+// Logout ends the session of the refresh token of the pair, so neither it nor any login token
+// of its family can be used anymore. The login token is revoked as well when it's present and still valid.
+func (au Aurum) Logout(ctx context.Context, tp jwt.TokenPair) error {
+	claims, err := au.checkRefreshToken(ctx, tp.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := endSession(ctx, tx, claims.Username, tokenFamily(claims)); err != nil {
+			return err
+		}
+
+		// A login token of the same family was revoked with it, unless both are from before
+		// families, in which case neither has one.
+		login, err := jwt.VerifyJWT(tp.LoginToken, au.pk)
+		if err != nil || login.Refresh || login.Username != claims.Username || (login.Family != "" && login.Family == claims.Family) {
+			return nil
+		}
+
+		_, err = tx.RevokeToken(ctx, login.Id, time.Unix(login.ExpiresAt, 0))
+		return err
+	})
+}
+
+// IsTokenRevoked reports whether the token with the given id (its jti claim), or the token
+// family it belongs to, has been revoked. The family may be empty.
+func (au Aurum) IsTokenRevoked(ctx context.Context, id, family string) (bool, error) {
+	if id == "" {
+		return false, ErrInvalidInput
+	}
+
+	return au.isRevoked(ctx, id, family)
+}
+
+// RemoveExpiredRevocations forgets about revoked tokens which have expired since
+func (au Aurum) RemoveExpiredRevocations(ctx context.Context) (int, error) {
+	return au.db.RemoveExpiredRevocations(ctx, time.Now())
+}
@@ -0,0 +1,410 @@
+package aurum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAurum_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	claims, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	session := models.Session{ID: claims.Family, Username: "jeff", IP: "192.0.2.1"}
+
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), "family:"+claims.Family).Return(false, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "jeff").Return(models.User{Username: "jeff"}, nil)
+	expectTx(ms)
+	ms.EXPECT().RevokeToken(gomock.Any(), claims.Id, time.Unix(claims.ExpiresAt, 0)).Return(true, nil)
+	ms.EXPECT().GetSession(gomock.Any(), "jeff", claims.Family).Return(session, nil)
+	ms.EXPECT().SetSession(gomock.Any(), gomock.Any()).Do(func(_ context.Context, s models.Session) {
+		assert.Equal(t, session.ID, s.ID)
+		assert.Equal(t, "192.0.2.2", s.IP)
+		assert.Equal(t, "test", s.UserAgent)
+		assert.True(t, s.LastRefresh.After(session.LastRefresh))
+	})
+
+	// SUT
+	old := tp
+	err = au.RefreshToken(ctx, &tp, ClientInfo{IP: "192.0.2.2", UserAgent: "test"})
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, old.RefreshToken, tp.RefreshToken)
+	assert.NotEqual(t, old.LoginToken, tp.LoginToken)
+
+	lt, err := jwt.VerifyJWT(tp.LoginToken, cfg.PublicKey)
+	assert.NoError(t, err)
+	assert.False(t, lt.Refresh)
+	assert.Equal(t, "jeff", lt.Username)
+	assert.Equal(t, claims.Family, lt.Family)
+
+	rt, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+	assert.True(t, rt.Refresh)
+	assert.Equal(t, claims.Family, rt.Family)
+	assert.NotEqual(t, claims.Id, rt.Id)
+}
+
+func TestAurum_RefreshTokenReused(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	claims, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// The token was rotated before, so the whole family is revoked
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), "family:"+claims.Family).Return(false, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "jeff").Return(models.User{Username: "jeff"}, nil)
+	expectTx(ms).Times(2)
+	ms.EXPECT().RevokeToken(gomock.Any(), claims.Id, gomock.Any()).Return(false, nil)
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:"+claims.Family, gomock.Any()).Return(true, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", claims.Family)
+
+	// SUT
+	old := tp
+	err = au.RefreshToken(ctx, &tp, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+	assert.Equal(t, old, tp)
+}
+
+func TestAurum_RefreshTokenSessionFails(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	claims, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// The revocation is rolled back together with the session update
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), "family:"+claims.Family).Return(false, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "jeff").Return(models.User{Username: "jeff"}, nil)
+	ms.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(tx store.AurumStore) error) error {
+			assert.Error(t, fn(ms))
+			return errors.New("rolled back")
+		})
+	ms.EXPECT().RevokeToken(gomock.Any(), claims.Id, gomock.Any()).Return(true, nil)
+	ms.EXPECT().GetSession(gomock.Any(), "jeff", claims.Family).Return(models.Session{}, errors.New("fail"))
+
+	// SUT
+	old := tp
+	err = au.RefreshToken(ctx, &tp, ClientInfo{})
+	assert.Error(t, err)
+	assert.Equal(t, old, tp)
+}
+
+func TestAurum_RefreshTokenPasswordExpired(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	claims, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// The password expired after the session was started, so it isn't extended
+	user := models.User{Username: "jeff", PasswordChangedAt: time.Now().AddDate(0, 0, -31)}
+
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), "family:"+claims.Family).Return(false, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "jeff").Return(user, nil)
+	expectPolicy(ms, "jeff", models.PasswordPolicy{MaxAgeDays: 30})
+
+	// SUT
+	old := tp
+	err = au.RefreshToken(ctx, &tp, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+	assert.Equal(t, old, tp)
+}
+
+func TestAurum_RefreshTokenFamilyRevoked(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(true, nil)
+
+	// SUT
+	err = au.RefreshToken(ctx, &tp, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_RefreshTokenWithoutFamily(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateFamilyJWT("jeff", true, "", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	claims, err := jwt.VerifyJWT(token, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// Tokens from before families existed start a family named after themselves
+	// They get a session at their first refresh
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), "family:"+claims.Id).Return(false, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "jeff").Return(models.User{Username: "jeff"}, nil)
+	expectTx(ms)
+	ms.EXPECT().RevokeToken(gomock.Any(), claims.Id, gomock.Any()).Return(true, nil)
+	ms.EXPECT().GetSession(gomock.Any(), "jeff", claims.Id).Return(models.Session{}, store.ErrNotExists)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Do(func(_ context.Context, s models.Session) {
+		assert.Equal(t, claims.Id, s.ID)
+		assert.Equal(t, "jeff", s.Username)
+	})
+
+	// SUT
+	tp := jwt.TokenPair{RefreshToken: token}
+	err = au.RefreshToken(ctx, &tp, ClientInfo{})
+	assert.NoError(t, err)
+
+	rt, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, claims.Id, rt.Family)
+}
+
+func TestAurum_RefreshTokenWithLoginToken(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	// SUT
+	err = au.RefreshToken(ctx, &jwt.TokenPair{RefreshToken: tp.LoginToken}, ClientInfo{})
+	assert.Equal(t, ErrInvalidInput, err)
+}
+
+func TestAurum_CheckTokenRevoked(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("jeff", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Return(true, nil)
+
+	// SUT
+	_, err = au.GetUser(ctx, token)
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_Logout(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	refresh, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// The login token belongs to the same family, so ending its session is enough
+	expectTx(ms)
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:"+refresh.Family, gomock.Any()).Return(true, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", refresh.Family)
+
+	// SUT
+	err = au.Logout(ctx, tp)
+	assert.NoError(t, err)
+}
+
+func TestAurum_LogoutOtherUsersLoginToken(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	other, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	refresh, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// Only the session of the refresh token is ended, which is fine to be missing
+	expectTx(ms)
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:"+refresh.Family, gomock.Any()).Return(true, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", refresh.Family).Return(store.ErrNotExists)
+
+	// SUT
+	err = au.Logout(ctx, jwt.TokenPair{LoginToken: other, RefreshToken: tp.RefreshToken})
+	assert.NoError(t, err)
+}
+
+func TestAurum_LogoutLoginTokenOfOtherFamily(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	other, err := jwt.GenerateJWT("jeff", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	refresh, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+	login, err := jwt.VerifyJWT(other, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	expectTx(ms)
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:"+refresh.Family, gomock.Any()).Return(true, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", refresh.Family)
+	ms.EXPECT().RevokeToken(gomock.Any(), login.Id, time.Unix(login.ExpiresAt, 0)).Return(true, nil)
+
+	// SUT
+	err = au.Logout(ctx, jwt.TokenPair{LoginToken: other, RefreshToken: tp.RefreshToken})
+	assert.NoError(t, err)
+}
+
+func TestAurum_LogoutWithoutFamily(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	// Tokens from before families existed have none
+	tp, err := jwt.GenerateFamilyJWTPair("jeff", "", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	refresh, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.NoError(t, err)
+	login, err := jwt.VerifyJWT(tp.LoginToken, cfg.PublicKey)
+	assert.NoError(t, err)
+
+	// So the login token isn't revoked with the family of the refresh token
+	expectTx(ms)
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:"+refresh.Id, gomock.Any()).Return(true, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "jeff", refresh.Id).Return(store.ErrNotExists)
+	ms.EXPECT().RevokeToken(gomock.Any(), login.Id, time.Unix(login.ExpiresAt, 0)).Return(true, nil)
+
+	// SUT
+	err = au.Logout(ctx, tp)
+	assert.NoError(t, err)
+}
+
+func TestAurum_LogoutWithoutRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
+	assert.NoError(t, err)
+
+	// SUT
+	err = au.Logout(ctx, jwt.TokenPair{LoginToken: tp.LoginToken})
+	assert.Equal(t, ErrInvalidInput, err)
+}
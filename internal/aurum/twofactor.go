@@ -0,0 +1,279 @@
+package aurum
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/finitum/aurum/internal/totp"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// totpIssuer is the name authenticator apps show next to the account
+	totpIssuer = "Aurum"
+
+	// recoveryCodeCount is the number of recovery codes handed out when confirming TOTP
+	recoveryCodeCount = 10
+	// recoveryCodeSize is the number of random bytes in a recovery code
+	recoveryCodeSize = 5
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// normalizeCode strips the formatting users may add to codes
+func normalizeCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// hashRecoveryCode hashes a recovery code for storage. The codes are random and long
+// enough that a fast hash suffices.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCodes generates a set of recovery codes, and the hashes to store of them
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, errors.Wrap(err, "random")
+		}
+
+		code := strings.ToLower(recoveryEncoding.EncodeToString(buf))
+		code = code[:len(code)/2] + "-" + code[len(code)/2:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// useCode checks code against factor, which is either a TOTP code or a recovery code. Both
+// can be used only once, so on success factor is updated and has to be stored again.
+func useCode(factor *models.SecondFactor, code string, now time.Time) bool {
+	code = normalizeCode(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(factor.Secret, code, now)
+		if !ok || step <= factor.LastStep {
+			return false
+		}
+
+		factor.LastStep = step
+		return true
+	}
+
+	hashed := hashRecoveryCode(code)
+	for i, h := range factor.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hashed)) == 1 {
+			factor.RecoveryCodes = append(factor.RecoveryCodes[:i:i], factor.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasSecondFactor checks whether a user has confirmed a second factor
+func (au Aurum) hasSecondFactor(ctx context.Context, username string) (bool, error) {
+	factor, err := au.db.GetSecondFactor(ctx, username)
+	if err == store.ErrNotExists {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "getting second factor")
+	}
+
+	return factor.Confirmed, nil
+}
+
+// CompleteChallenge finishes a login which requires a second factor. Every challenge can be
+// attempted only once, a wrong code means logging in again, so codes can't be guessed.
+// Wrong codes count as failed logins as well, so they lock the account and the address.
+// When the password expired, the response holds the token to change it instead of a session.
+func (au Aurum) CompleteChallenge(ctx context.Context, response models.ChallengeResponse, client ClientInfo) (models.LoginResponse, error) {
+	claims, err := jwt.VerifyChallengeJWT(response.Challenge, au.pk)
+	if err != nil {
+		return models.LoginResponse{}, ErrUnauthorized
+	}
+
+	// Either may have been locked since the challenge was handed out
+	if err := au.checkLockout(ctx, claims.Subject, client); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	fresh, err := au.db.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return models.LoginResponse{}, errors.Wrap(err, "revoking challenge")
+	} else if !fresh {
+		return models.LoginResponse{}, ErrUnauthorized
+	}
+
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		factor, err := tx.GetSecondFactor(ctx, claims.Subject)
+		if err == store.ErrNotExists {
+			return ErrUnauthorized
+		} else if err != nil {
+			return err
+		}
+
+		if !factor.Confirmed || !useCode(&factor, response.Code, time.Now()) {
+			return ErrUnauthorized
+		}
+
+		return tx.SetSecondFactor(ctx, factor)
+	})
+	if err == ErrUnauthorized {
+		if err := au.addLoginFailure(ctx, claims.Subject, client); err != nil {
+			return models.LoginResponse{}, err
+		}
+
+		return models.LoginResponse{}, ErrUnauthorized
+	} else if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	user, err := au.db.GetUser(ctx, claims.Subject)
+	if err != nil {
+		return models.LoginResponse{}, errors.Wrap(err, "getting user from db failed")
+	}
+
+	expired, err := au.passwordExpired(ctx, user, time.Now())
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return au.finishLogin(ctx, user, expired, client)
+}
+
+// EnrollTOTP starts setting up TOTP for the user the token belongs to. It isn't required
+// to log in until confirmed with ConfirmTOTP, enrolling again replaces the secret until then.
+func (au Aurum) EnrollTOTP(ctx context.Context, token string) (models.TOTPEnrollment, error) {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		factor, err := tx.GetSecondFactor(ctx, claims.Username)
+		if err == nil && factor.Confirmed {
+			return store.ErrExists
+		} else if err != nil && err != store.ErrNotExists {
+			return err
+		}
+
+		return tx.SetSecondFactor(ctx, models.SecondFactor{
+			Username: claims.Username,
+			Secret:   secret,
+		})
+	})
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+
+	return models.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, claims.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables TOTP for the user the token belongs to with the first code from
+// the authenticator. The recovery codes it returns are never handed out again.
+// Wrong codes count as failed logins, like those completing a challenge.
+func (au Aurum) ConfirmTOTP(ctx context.Context, token, code string, client ClientInfo) (models.RecoveryCodes, error) {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	if err := au.checkLockout(ctx, claims.Username, client); err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		factor, err := tx.GetSecondFactor(ctx, claims.Username)
+		if err != nil {
+			return err
+		}
+
+		if factor.Confirmed {
+			return store.ErrExists
+		}
+
+		if !useCode(&factor, code, time.Now()) {
+			return ErrInvalidInput
+		}
+
+		factor.Confirmed = true
+		factor.RecoveryCodes = hashes
+
+		return tx.SetSecondFactor(ctx, factor)
+	})
+	if err == ErrInvalidInput {
+		if err := au.addLoginFailure(ctx, claims.Username, client); err != nil {
+			return models.RecoveryCodes{}, err
+		}
+
+		return models.RecoveryCodes{}, ErrInvalidInput
+	} else if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+
+	return models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP turns off TOTP for the user the token belongs to. A confirmed second
+// factor can only be disabled with a code, or a recovery code. Wrong codes count as failed logins.
+func (au Aurum) DisableTOTP(ctx context.Context, token, code string, client ClientInfo) error {
+	claims, err := au.checkToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if err := au.checkLockout(ctx, claims.Username, client); err != nil {
+		return err
+	}
+
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		factor, err := tx.GetSecondFactor(ctx, claims.Username)
+		if err != nil {
+			return err
+		}
+
+		if factor.Confirmed && !useCode(&factor, code, time.Now()) {
+			return ErrInvalidInput
+		}
+
+		return tx.RemoveSecondFactor(ctx, claims.Username)
+	})
+	if err == ErrInvalidInput {
+		if err := au.addLoginFailure(ctx, claims.Username, client); err != nil {
+			return err
+		}
+
+		return ErrInvalidInput
+	}
+
+	return err
+}
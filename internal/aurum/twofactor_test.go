@@ -0,0 +1,234 @@
+package aurum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/internal/totp"
+	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/mock_store"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+func currentCode(t *testing.T) string {
+	code, err := totp.Code(testSecret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestUseCode(t *testing.T) {
+	now := time.Now()
+	code, err := totp.Code(testSecret, totp.Step(now))
+	require.NoError(t, err)
+
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	factor := models.SecondFactor{Secret: testSecret, Confirmed: true, RecoveryCodes: hashes}
+
+	assert.False(t, useCode(&factor, "000000", now))
+	assert.True(t, useCode(&factor, code, now))
+	assert.Equal(t, totp.Step(now), factor.LastStep)
+
+	// Codes can't be replayed
+	assert.False(t, useCode(&factor, code, now))
+
+	// Recovery codes work once, with or without formatting
+	assert.True(t, useCode(&factor, codes[0], now))
+	assert.False(t, useCode(&factor, codes[0], now))
+	assert.Len(t, factor.RecoveryCodes, recoveryCodeCount-1)
+
+	assert.True(t, useCode(&factor, " "+normalizeCode(codes[1])+" ", now))
+	assert.Len(t, factor.RecoveryCodes, recoveryCodeCount-2)
+}
+
+func TestAurum_LoginSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	u := models.User{Username: "user", Password: "wH6VLfolKTUb"}
+	hu := u
+	var err error
+	hu.Password, err = hash.HashPassword(u.Password)
+	require.NoError(t, err)
+
+	factor := models.SecondFactor{Username: u.Username, Secret: testSecret, Confirmed: true}
+
+	ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(hu, nil)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), u.Username).Return(factor, nil)
+
+	// No tokens are handed out before the second factor is supplied
+	resp, err := au.Login(ctx, u, ClientInfo{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.LoginToken)
+	assert.Empty(t, resp.RefreshToken)
+
+	challenge, err := jwt.VerifyChallengeJWT(resp.Challenge, cfg.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, u.Username, challenge.Subject)
+
+	// Nor can the challenge be used as a token
+	_, err = au.GetUser(ctx, resp.Challenge)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	ms.EXPECT().RevokeToken(gomock.Any(), challenge.Id, gomock.Any()).Return(true, nil)
+	expectTx(ms)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), u.Username).Return(factor, nil)
+	ms.EXPECT().SetSecondFactor(gomock.Any(), gomock.Any()).Do(func(_ context.Context, f models.SecondFactor) {
+		assert.Equal(t, totp.Step(time.Now()), f.LastStep)
+	})
+	ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(hu, nil)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	tp, err := au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: resp.Challenge, Code: currentCode(t)}, ClientInfo{})
+	require.NoError(t, err)
+
+	claims, err := jwt.VerifyJWT(tp.LoginToken, cfg.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, u.Username, claims.Username)
+
+	// Challenges can only be completed once
+	ms.EXPECT().RevokeToken(gomock.Any(), challenge.Id, gomock.Any()).Return(false, nil)
+	_, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: resp.Challenge, Code: currentCode(t)}, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_CompleteChallengeWrongCode(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	challenge, err := jwt.GenerateChallengeJWT("user", cfg.SecretKey)
+	require.NoError(t, err)
+
+	ms.EXPECT().RevokeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	expectTx(ms)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "user").Return(models.SecondFactor{Username: "user", Secret: testSecret, Confirmed: true}, nil)
+
+	_, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: challenge, Code: "000000"}, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Login tokens aren't challenges
+	token, err := jwt.GenerateJWT("user", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	_, err = au.CompleteChallenge(ctx, models.ChallengeResponse{Challenge: token, Code: currentCode(t)}, ClientInfo{})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_EnrollTOTP(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("user", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	var stored models.SecondFactor
+	expectTx(ms).Times(2)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "user").Return(models.SecondFactor{}, store.ErrNotExists)
+	ms.EXPECT().SetSecondFactor(gomock.Any(), gomock.Any()).Do(func(_ context.Context, f models.SecondFactor) {
+		stored = f
+	})
+
+	enrollment, err := au.EnrollTOTP(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, models.SecondFactor{Username: "user", Secret: enrollment.Secret}, stored)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Aurum:user?")
+
+	// Enrolling again is refused once confirmed
+	stored.Confirmed = true
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "user").Return(stored, nil)
+
+	_, err = au.EnrollTOTP(ctx, token)
+	assert.Equal(t, store.ErrExists, err)
+}
+
+func TestAurum_ConfirmTOTP(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("user", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	factor := models.SecondFactor{Username: "user", Secret: testSecret}
+
+	// A wrong code doesn't confirm it
+	expectTx(ms).Times(2)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "user").Return(factor, nil).Times(2)
+
+	_, err = au.ConfirmTOTP(ctx, token, "000000", ClientInfo{})
+	assert.Equal(t, ErrInvalidInput, err)
+
+	var stored models.SecondFactor
+	ms.EXPECT().SetSecondFactor(gomock.Any(), gomock.Any()).Do(func(_ context.Context, f models.SecondFactor) {
+		stored = f
+	})
+
+	codes, err := au.ConfirmTOTP(ctx, token, currentCode(t), ClientInfo{})
+	require.NoError(t, err)
+	assert.True(t, stored.Confirmed)
+	assert.Len(t, codes.Codes, recoveryCodeCount)
+
+	// Only the hashes of the recovery codes are stored
+	assert.Len(t, stored.RecoveryCodes, recoveryCodeCount)
+	assert.NotContains(t, stored.RecoveryCodes, codes.Codes[0])
+	assert.Contains(t, stored.RecoveryCodes, hashRecoveryCode(codes.Codes[0]))
+}
+
+func TestAurum_DisableTOTP(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("user", false, cfg.SecretKey)
+	require.NoError(t, err)
+
+	factor := models.SecondFactor{Username: "user", Secret: testSecret, Confirmed: true}
+
+	expectTx(ms).Times(2)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), "user").Return(factor, nil).Times(2)
+
+	assert.Equal(t, ErrInvalidInput, au.DisableTOTP(ctx, token, "000000", ClientInfo{}))
+
+	ms.EXPECT().RemoveSecondFactor(gomock.Any(), "user")
+	assert.NoError(t, au.DisableTOTP(ctx, token, currentCode(t), ClientInfo{}))
+}
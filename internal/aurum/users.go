@@ -2,13 +2,14 @@ package aurum
 
 import (
 	"context"
+	"strings"
+	"time"
 
-	"github.com/finitum/aurum/internal/hash"
-	"github.com/finitum/aurum/internal/passwords"
 	"github.com/finitum/aurum/pkg/jwt"
 	"github.com/finitum/aurum/pkg/models"
 	"github.com/finitum/aurum/pkg/store"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func (au Aurum) SignUp(ctx context.Context, user models.User) error {
@@ -16,67 +17,188 @@ func (au Aurum) SignUp(ctx context.Context, user models.User) error {
 		return ErrInvalidInput
 	}
 
-	if !passwords.CheckStrength(user.Password, []string{user.Username, user.Email}) {
-		return ErrWeakPassword
+	policy, err := au.signUpPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := au.checkPassword(user.Password, user, policy); err != nil {
+		return err
 	}
 
-	hashed, err := hash.HashPassword(user.Password)
+	hashed, err := au.hashing().Hash(user.Password)
 	if err != nil {
 		return errors.Wrap(err, "hashing failed")
 	}
 
 	user.Password = hashed
+	user.PasswordChangedAt = time.Now()
 
-	if err := au.db.CreateUser(ctx, user); err != nil {
-		if err == store.ErrExists {
-			return err
+	// The address still has to be verified
+	user.EmailVerified = false
+	user.PendingEmail = ""
+
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateUser(ctx, user); err != nil {
+			if err == store.ErrExists {
+				return err
+			}
+
+			return errors.Wrap(err, "failed creating user in database")
 		}
 
-		return errors.Wrap(err, "failed creating user in database")
+		if err := tx.AddGroupToUser(ctx, user.Username, AurumName, models.RoleUser); err != nil {
+			return errors.Wrap(err, "couldn't add user to aurum group")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if user.Email == "" {
+		return nil
 	}
 
-	if err := au.db.AddGroupToUser(ctx, user.Username, AurumName, models.RoleUser); err != nil {
-		return errors.Wrap(err, "couldn't add user to aurum group")
+	// The account exists by now, so a failing mail shouldn't fail the signup. The user can ask for a new one.
+	if err := au.sendVerification(ctx, user.Username, user.Email); err != nil {
+		log.WithError(err).WithField("username", user.Username).Warn("Couldn't send verification mail")
 	}
 
 	return nil
 }
 
-func (au Aurum) Login(ctx context.Context, user models.User) (jwt.TokenPair, error) {
+// Login checks the credentials of user, and starts a new session for the client. Users who
+// enabled a second factor get a challenge instead, which is completed with CompleteChallenge.
+// Users whose password expired have to change it first with ChangeExpiredPassword, after
+// supplying the second factor. After too many failed logins the account or the address of the client is locked for a while.
+func (au Aurum) Login(ctx context.Context, user models.User, client ClientInfo) (models.LoginResponse, error) {
+	// Locks are checked first, so locked logins don't even cost a password check
+	if err := au.checkLockout(ctx, user.Username, client); err != nil {
+		return models.LoginResponse{}, err
+	}
+
 	dbu, err := au.db.GetUser(ctx, user.Username)
+	if err == store.ErrNotExists {
+		// Unknown accounts are counted too, so locking doesn't reveal which accounts exist
+		if err := au.addLoginFailure(ctx, user.Username, client); err != nil {
+			return models.LoginResponse{}, err
+		}
+
+		return models.LoginResponse{}, errors.Wrap(err, "getting user from db failed")
+	} else if err != nil {
+		return models.LoginResponse{}, errors.Wrap(err, "getting user from db failed")
+	}
+
+	if !au.hashing().Verify(user.Password, dbu.Password) {
+		if err := au.addLoginFailure(ctx, user.Username, client); err != nil {
+			return models.LoginResponse{}, err
+		}
+
+		return models.LoginResponse{}, errors.New("invalid password")
+	}
+
+	expired, err := au.passwordExpired(ctx, dbu, time.Now())
 	if err != nil {
-		return jwt.TokenPair{}, errors.Wrap(err, "getting user from db failed")
+		return models.LoginResponse{}, err
 	}
 
-	if !hash.CheckPasswordHash(user.Password, dbu.Password) {
-		return jwt.TokenPair{}, errors.New("invalid password")
+	// The expired password is replaced anyway, so it isn't rehashed. Only now the password is known,
+	// the hash can be upgraded to the current algorithm and parameters.
+	if !expired && au.hashing().NeedsRehash(dbu.Password) {
+		au.rehash(ctx, dbu.Username, user.Password, dbu.Password)
 	}
 
-	return jwt.GenerateJWTPair(dbu.Username, au.sk)
+	return au.completeLogin(ctx, dbu, expired, client)
 }
 
-func (au Aurum) RefreshToken(tp *jwt.TokenPair) error {
-	if tp.RefreshToken == "" {
-		return ErrInvalidInput
+// completeLogin finishes the login of a user whose password was correct, by handing out a challenge
+// when the user enabled a second factor, or by finishing it right away otherwise. Whether the password
+// expired isn't revealed before the second factor is supplied as well.
+func (au Aurum) completeLogin(ctx context.Context, user models.User, expired bool, client ClientInfo) (models.LoginResponse, error) {
+	twoFactor, err := au.hasSecondFactor(ctx, user.Username)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	if twoFactor {
+		challenge, err := jwt.GenerateChallengeJWT(user.Username, au.sk)
+		if err != nil {
+			return models.LoginResponse{}, errors.Wrap(err, "jwt generation error")
+		}
+
+		return models.LoginResponse{Challenge: challenge}, nil
 	}
 
-	claims, err := jwt.VerifyJWT(tp.RefreshToken, au.pk)
+	return au.finishLogin(ctx, user, expired, client)
+}
+
+// finishLogin finishes the login of a user who supplied all their factors. When the password expired
+// it has to be changed first, otherwise a new session is started.
+func (au Aurum) finishLogin(ctx context.Context, user models.User, expired bool, client ClientInfo) (models.LoginResponse, error) {
+	if expired {
+		token, err := jwt.GeneratePasswordExpiredJWT(user.Username, passwordFingerprint(user.Password), au.sk)
+		if err != nil {
+			return models.LoginResponse{}, errors.Wrap(err, "jwt generation error")
+		}
+
+		return models.LoginResponse{PasswordExpired: token}, nil
+	}
+
+	// The failures are only forgotten once logged in
+	if err := au.resetLoginFailures(ctx, user.Username); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	tp, err := au.startSession(ctx, user.Username, client)
 	if err != nil {
-		return errors.Wrap(err, "verification error")
+		return models.LoginResponse{}, err
 	}
 
-	newtoken, err := jwt.GenerateJWT(claims.Username, false, au.sk)
+	return models.LoginResponse{LoginToken: tp.LoginToken, RefreshToken: tp.RefreshToken}, nil
+}
+
+// rehash replaces the password hash of a user by one made with the current parameters, as long as
+// the stored hash is still current. Failing that isn't fatal, the old hash still works and will be
+// upgraded on the next login.
+func (au Aurum) rehash(ctx context.Context, username, password, current string) {
+	logger := log.WithField("username", username)
+
+	hashed, err := au.hashing().Hash(password)
 	if err != nil {
-		return errors.Wrap(err, "jwt generation error")
+		logger.WithError(err).Warn("Couldn't rehash password")
+		return
 	}
 
-	tp.LoginToken = newtoken
+	var changed bool
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		user, err := tx.GetUser(ctx, username)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		// A password changed since it was checked mustn't be replaced by the old one
+		if changed = user.Password != current; changed {
+			return nil
+		}
+
+		_, err = tx.SetUser(ctx, models.User{Username: username, Password: hashed})
+		return err
+	})
+	if err != nil {
+		logger.WithError(err).Warn("Couldn't store rehashed password")
+		return
+	} else if changed {
+		logger.Debug("Password changed during login, not rehashing it")
+		return
+	}
+
+	logger.Debug("Rehashed password")
 }
 
 func (au Aurum) GetUser(ctx context.Context, token string) (models.User, error) {
-	claims, err := au.checkToken(token)
+	claims, err := au.checkToken(ctx, token)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -92,27 +214,85 @@ func (au Aurum) GetUser(ctx context.Context, token string) (models.User, error)
 }
 
 func (au Aurum) UpdateUser(ctx context.Context, token string, user models.User) (models.User, error) {
-	claims, err := au.checkToken(token)
+	claims, err := au.checkToken(ctx, token)
 	if err != nil {
 		return models.User{}, err
 	}
 
 	user.Username = claims.Username
 
-	if user.Password != "" {
-		if !passwords.CheckStrength(user.Password, []string{user.Username, user.Email}) {
-			return models.User{}, ErrWeakPassword
+	return au.updateUser(ctx, user)
+}
+
+// updateUser changes the password and/or email of a user, leaving out the password in the result
+func (au Aurum) updateUser(ctx context.Context, user models.User) (models.User, error) {
+	password := user.Password
+	user.Password = ""
+	user.PasswordChangedAt = time.Time{}
+
+	// A new email address is pending until it's verified
+	email := user.Email
+	user.Email = ""
+	user.EmailVerified = false
+	user.PendingEmail = ""
+
+	var verify bool
+	var updated models.User
+
+	err := au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		var err error
+
+		if password != "" {
+			curr, err := tx.GetUser(ctx, user.Username)
+			if err != nil {
+				return err
+			}
+
+			// The new address mustn't be part of the password either
+			if email != "" {
+				curr.Email = email
+			}
+
+			if user.Password, err = au.changePassword(ctx, tx, curr, password); err != nil {
+				return err
+			}
+
+			user.PasswordChangedAt = time.Now()
 		}
 
-		hashed, err := hash.HashPassword(user.Password)
-		if err != nil {
-			return models.User{}, err
+		if email != "" {
+			if verify, err = setEmail(ctx, tx, user.Username, email); err != nil {
+				return err
+			}
+		}
+
+		updated, err = tx.SetUser(ctx, user)
+		return err
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user = updated
+
+	if verify {
+		if err := au.sendVerification(ctx, user.Username, user.PendingEmail); err != nil {
+			return models.User{}, errors.Wrap(err, "sending verification mail")
 		}
+	}
 
-		user.Password = hashed
+	user.Password = ""
+
+	return user, nil
+}
+
+// LookupUser retrieves any user by name. Only admins of Aurum may look up other users.
+func (au Aurum) LookupUser(ctx context.Context, token, username string) (models.User, error) {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return models.User{}, err
 	}
 
-	user, err = au.db.SetUser(ctx, user)
+	user, err := au.db.GetUser(ctx, username)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -121,3 +301,67 @@ func (au Aurum) UpdateUser(ctx context.Context, token string, user models.User)
 
 	return user, nil
 }
+
+// AdminUpdateUser resets the password and/or changes the email of any user.
+// Only admins of Aurum may update other users.
+func (au Aurum) AdminUpdateUser(ctx context.Context, token, username string, user models.User) (models.User, error) {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return models.User{}, err
+	}
+
+	user.Username = username
+
+	return au.updateUser(ctx, user)
+}
+
+// RemoveUser removes a user together with all its memberships. Only admins of Aurum may remove users.
+func (au Aurum) RemoveUser(ctx context.Context, token, username string) (models.RemovalSummary, error) {
+	claims, err := au.requireAdmin(ctx, token)
+	if err != nil {
+		return models.RemovalSummary{}, err
+	}
+
+	// Admins can't remove themselves, so there is always at least one admin left
+	if username == claims.Username {
+		return models.RemovalSummary{}, ErrInvalidInput
+	}
+
+	var summary models.RemovalSummary
+	err = au.db.WithTx(ctx, func(tx store.AurumStore) error {
+		// Sign the user out everywhere, its tokens would stay valid otherwise
+		if err := revokeSessions(ctx, tx, username, ""); err != nil {
+			return err
+		}
+
+		// The failed logins are kept by key, which the store doesn't know belongs to the user
+		if err := tx.RemoveLoginFailures(ctx, accountKeyPrefix+username); err != nil && err != store.ErrNotExists {
+			return err
+		}
+
+		summary, err = tx.RemoveUser(ctx, username)
+		return err
+	})
+
+	return summary, err
+}
+
+// GetUsers lists a page of users selected by query. Only admins of Aurum may list users.
+func (au Aurum) GetUsers(ctx context.Context, token string, query models.UserQuery) (models.UserPage, error) {
+	if _, err := au.requireAdmin(ctx, token); err != nil {
+		return models.UserPage{}, err
+	}
+
+	query.Group = strings.ToLower(query.Group)
+	query.Limit = pageSize(query.Limit)
+
+	page, err := au.db.GetUsers(ctx, query)
+	if err != nil {
+		return models.UserPage{}, err
+	}
+
+	for i := range page.Users {
+		page.Users[i].Password = ""
+	}
+
+	return page, nil
+}
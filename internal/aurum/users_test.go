@@ -2,16 +2,27 @@ package aurum
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/finitum/aurum/internal/hash"
+	"github.com/finitum/aurum/internal/passwords"
 	"github.com/finitum/aurum/pkg/config"
 	"github.com/finitum/aurum/pkg/jwt"
 	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
 	"github.com/finitum/aurum/pkg/store/mock_store"
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAurum_SignUp(t *testing.T) {
@@ -20,24 +31,60 @@ func TestAurum_SignUp(t *testing.T) {
 	defer ctrl.Finish()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	cfg := config.EphemeralConfig()
+	mb := &mailbox{}
 
 	u := models.User{
-		Username: "user",
-		Password: "wH6VLfolKTUb",
-		Email:    "email",
+		Username:      "user",
+		Password:      "wH6VLfolKTUb",
+		Email:         "email",
+		EmailVerified: true,
 	}
 
 	ctxT := reflect.TypeOf(ctx)
 
+	expectSignUpPolicy(ms)
+	expectTx(ms)
 	ms.EXPECT().CreateUser(gomock.AssignableToTypeOf(ctxT), gomock.Any()).Do(func(_ context.Context, gu models.User) {
 		assert.True(t, hash.CheckPasswordHash(u.Password, gu.Password))
+		assert.WithinDuration(t, time.Now(), gu.PasswordChangedAt, time.Minute)
+		// Users can't verify their own address
+		assert.False(t, gu.EmailVerified)
 	}).Return(nil)
 	ms.EXPECT().AddGroupToUser(gomock.AssignableToTypeOf(ctxT), u.Username, AurumName, models.RoleUser)
 
-	au := Aurum{db: ms}
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, mailer: mb}
 	// SUT
 	err := au.SignUp(ctx, u)
 	assert.NoError(t, err)
+
+	// A verification link is mailed to the address
+	require.Len(t, mb.messages, 1)
+	assert.Equal(t, u.Email, mb.messages[0].To)
+}
+
+func TestAurum_SignUpAddGroupFails(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
+	u := models.User{
+		Username: "user",
+		Password: "wH6VLfolKTUb",
+		Email:    "email",
+	}
+
+	expectSignUpPolicy(ms)
+	expectTx(ms)
+	ms.EXPECT().CreateUser(gomock.Any(), gomock.Any())
+	ms.EXPECT().AddGroupToUser(gomock.Any(), u.Username, AurumName, models.RoleUser).Return(store.ErrNotExists)
+
+	au := Aurum{db: ms}
+	// SUT
+	err := au.SignUp(ctx, u)
+	assert.Equal(t, store.ErrNotExists, errors.Cause(err))
 }
 
 func TestAurum_Login(t *testing.T) {
@@ -62,10 +109,17 @@ func TestAurum_Login(t *testing.T) {
 	hu.Password, err = hash.HashPassword(u.Password)
 	assert.NoError(t, err)
 
+	client := ClientInfo{IP: "192.0.2.1", UserAgent: "test"}
+
+	var session models.Session
 	ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(hu, nil)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), u.Username).Return(models.SecondFactor{}, store.ErrNotExists)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Do(func(_ context.Context, s models.Session) {
+		session = s
+	})
 
 	// SUT
-	tp, err := au.Login(ctx, u)
+	tp, err := au.Login(ctx, u, client)
 	assert.NoError(t, err)
 
 	lt, err := jwt.VerifyJWT(tp.LoginToken, cfg.PublicKey)
@@ -73,31 +127,125 @@ func TestAurum_Login(t *testing.T) {
 	assert.False(t, lt.Refresh)
 	assert.Equal(t, u.Username, lt.Username)
 
+	// The login started a session for the token family
+	assert.Equal(t, lt.Family, session.ID)
+	assert.Equal(t, u.Username, session.Username)
+	assert.Equal(t, client.IP, session.IP)
+	assert.Equal(t, client.UserAgent, session.UserAgent)
+
 	rt, err := jwt.VerifyJWT(tp.RefreshToken, cfg.PublicKey)
 	assert.NoError(t, err)
 	assert.True(t, rt.Refresh)
 }
 
-func TestAurum_RefreshToken(t *testing.T) {
+func TestAurum_LoginRehash(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+
 	cfg := config.EphemeralConfig()
 
-	au := Aurum{pk: cfg.PublicKey, sk: cfg.SecretKey}
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
 
-	// SUT
-	tp, err := jwt.GenerateJWTPair("jeff", cfg.SecretKey)
-	assert.NoError(t, err)
+	u := models.User{
+		Username: "user",
+		Password: "wH6VLfolKTUb",
+	}
 
-	old := tp
-	err = au.RefreshToken(&tp)
+	// A hash from before argon2id
+	old, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(models.User{Username: u.Username, Password: string(old)}, nil).Times(2)
+	expectTx(ms)
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user models.User) (models.User, error) {
+		assert.Equal(t, u.Username, user.Username)
+		assert.True(t, hash.CheckPasswordHash(u.Password, user.Password))
+		assert.False(t, au.hashing().NeedsRehash(user.Password))
+		return user, nil
+	})
+	ms.EXPECT().GetSecondFactor(gomock.Any(), u.Username).Return(models.SecondFactor{}, store.ErrNotExists)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	_, err = au.Login(ctx, u, ClientInfo{})
 	assert.NoError(t, err)
+}
+
+func TestAurum_LoginRehashPasswordChanged(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
 
-	assert.Equal(t, old.RefreshToken, tp.RefreshToken)
-	assert.NotEqual(t, old.LoginToken, tp.LoginToken)
+	ms := mock_store.NewMockAurumStore(ctrl)
 
-	lt, err := jwt.VerifyJWT(tp.LoginToken, cfg.PublicKey)
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	u := models.User{
+		Username: "user",
+		Password: "wH6VLfolKTUb",
+	}
+
+	old, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	// The password is changed while logging in, which the rehash mustn't undo
+	gomock.InOrder(
+		ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(models.User{Username: u.Username, Password: string(old)}, nil),
+		ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(models.User{Username: u.Username, Password: "changed"}, nil),
+	)
+	expectTx(ms)
+	ms.EXPECT().GetSecondFactor(gomock.Any(), u.Username).Return(models.SecondFactor{}, store.ErrNotExists)
+	ms.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+
+	_, err = au.Login(ctx, u, ClientInfo{})
 	assert.NoError(t, err)
-	assert.False(t, lt.Refresh)
-	assert.Equal(t, "jeff", lt.Username)
+}
+
+func TestAurum_SignUpBcryptMaxLength(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectSignUpPolicy(ms)
+
+	au := Aurum{db: ms, hasher: hash.Params{Algorithm: hash.Bcrypt, Cost: bcrypt.MinCost}}
+
+	long := "7da033bd32005113f2208eb87bc94c126a42aadf0c94065b1fa4d9d68e7c318f7da033bd32"
+	assert.Equal(t, ErrWeakPassword, au.SignUp(ctx, models.User{Username: "user", Password: long}))
+}
+
+func TestAurum_SignUpBreachedPassword(t *testing.T) {
+	const password = "7da033bd32005113f2208eb87bc94c12"
+
+	digest := sha1.Sum([]byte(password))
+	f, err := ioutil.TempFile("", "breached")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = passwords.ConvertBreachedList(f, strings.NewReader(hex.EncodeToString(digest[:])))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	breached, err := passwords.OpenBreachedList(f.Name())
+	require.NoError(t, err)
+	defer breached.Close()
+
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectSignUpPolicy(ms)
+
+	au := Aurum{db: ms, breached: breached}
+
+	err = au.SignUp(ctx, models.User{Username: "user", Password: password})
+	assert.Equal(t, ErrBreachedPassword, err)
 }
 
 func TestAurum_GetUser(t *testing.T) {
@@ -106,6 +254,7 @@ func TestAurum_GetUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	cfg := config.EphemeralConfig()
 
@@ -137,28 +286,344 @@ func TestAurum_UpdateUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
 
 	cfg := config.EphemeralConfig()
 
-	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+	mb := &mailbox{}
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey, mailer: mb}
 
 	u := models.User{
 		Username: "user",
 		Password: "wH6VLfolKTUb",
-		Email:    "email",
+		Email:    "new@example.com",
 	}
 
-	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).Do(func(_ context.Context, user models.User) {
+	expectUserPolicy(ms, u.Username)
+	expectNoPasswordHistory(ms, u.Username)
+
+	// The new address is pending, the old one stays in effect until it's verified
+	expectTx(ms)
+	ms.EXPECT().GetUser(gomock.Any(), u.Username).Return(models.User{Username: u.Username, Email: "old@example.com", EmailVerified: true}, nil).Times(2)
+	ms.EXPECT().SetUserEmail(gomock.Any(), u.Username, "old@example.com", u.Email, true)
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user models.User) (models.User, error) {
 		assert.True(t, hash.CheckPasswordHash(u.Password, user.Password))
-	}).Return(u, nil)
+		assert.WithinDuration(t, time.Now(), user.PasswordChangedAt, time.Minute)
+		assert.Empty(t, user.Email)
+		return models.User{Username: u.Username, Password: user.Password, Email: "old@example.com", EmailVerified: true, PendingEmail: u.Email}, nil
+	})
 
 	tp, err := jwt.GenerateJWTPair(u.Username, cfg.SecretKey)
 	assert.NoError(t, err)
 	// SUT
 	gu, err := au.UpdateUser(ctx, tp.LoginToken, u)
+	assert.NoError(t, err)
 
 	assert.Equal(t, models.User{
-		Username: u.Username,
-		Email:    u.Email,
+		Username:      u.Username,
+		Email:         "old@example.com",
+		EmailVerified: true,
+		PendingEmail:  u.Email,
 	}, gu)
+
+	require.Len(t, mb.messages, 1)
+	assert.Equal(t, u.Email, mb.messages[0].To)
+}
+
+func TestAurum_RemoveUser(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	summary := models.RemovalSummary{
+		Users: []string{"bob"},
+		Memberships: []models.Membership{
+			{Username: "bob", GroupName: AurumName, Role: models.RoleUser},
+		},
+	}
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+
+	// The sessions of the user are ended
+	expectTx(ms)
+	ms.EXPECT().GetSessions(gomock.Any(), "bob").Return([]models.Session{{ID: "session", Username: "bob"}}, nil)
+	ms.EXPECT().RemoveSession(gomock.Any(), "bob", "session")
+	ms.EXPECT().RevokeToken(gomock.Any(), "family:session", gomock.Any()).Return(true, nil)
+	// And its failed logins forgotten, the store removes the rest of its state
+	ms.EXPECT().RemoveLoginFailures(gomock.Any(), "user:bob").Return(store.ErrNotExists)
+	ms.EXPECT().RemoveUser(gomock.Any(), "bob").Return(summary, nil)
+
+	// SUT
+	removed, err := au.RemoveUser(ctx, token, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, summary, removed)
+}
+
+func TestAurum_RemoveUserNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("alice", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "alice").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.RemoveUser(ctx, token, "bob")
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_RemoveUserSelf(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+
+	// SUT
+	_, err = au.RemoveUser(ctx, token, "admin")
+	assert.Equal(t, ErrInvalidInput, err)
+}
+
+func TestAurum_LookupUser(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{
+		Username: "bob",
+		Password: "hash",
+		Email:    "bob@example.com",
+	}, nil)
+
+	// SUT
+	user, err := au.LookupUser(ctx, token, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{Username: "bob", Email: "bob@example.com"}, user)
+}
+
+func TestAurum_LookupUserNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("alice", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "alice").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.LookupUser(ctx, token, "bob")
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_AdminUpdateUser(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	u := models.User{
+		Username: "someone else",
+		Password: "wH6VLfolKTUb",
+		Email:    "bob@example.com",
+	}
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	expectUserPolicy(ms, "bob")
+	expectNoPasswordHistory(ms, "bob")
+
+	// Changing back to the current address cancels the pending change
+	expectTx(ms)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob", Email: u.Email, PendingEmail: "other@example.com"}, nil).Times(2)
+	ms.EXPECT().SetUserEmail(gomock.Any(), "bob", u.Email, "", false)
+	ms.EXPECT().SetUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user models.User) (models.User, error) {
+		assert.Equal(t, "bob", user.Username)
+		assert.True(t, hash.CheckPasswordHash(u.Password, user.Password))
+		assert.WithinDuration(t, time.Now(), user.PasswordChangedAt, time.Minute)
+		return models.User{Username: "bob", Password: user.Password, Email: u.Email}, nil
+	})
+
+	// SUT
+	user, err := au.AdminUpdateUser(ctx, token, "bob", u)
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{Username: "bob", Email: u.Email}, user)
+}
+
+func TestAurum_AdminUpdateUserWeakPassword(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	expectTx(ms)
+	ms.EXPECT().GetUser(gomock.Any(), "bob").Return(models.User{Username: "bob"}, nil)
+	expectUserPolicy(ms, "bob")
+
+	// SUT
+	_, err = au.AdminUpdateUser(ctx, token, "bob", models.User{Password: "bob"})
+	assert.Equal(t, ErrWeakPassword, err)
+}
+
+func TestAurum_AdminUpdateUserNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("alice", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "alice").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.AdminUpdateUser(ctx, token, "bob", models.User{Email: "alice@example.com"})
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestAurum_GetUsers(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetUsers(gomock.Any(), models.UserQuery{
+		Search: "bob",
+		Group:  "group",
+		Limit:  DefaultPageSize,
+	}).Return(models.UserPage{
+		Users: []models.User{{Username: "bob", Password: "hash"}},
+	}, nil)
+
+	// SUT
+	page, err := au.GetUsers(ctx, token, models.UserQuery{Search: "bob", Group: "Group"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.User{{Username: "bob"}}, page.Users)
+}
+
+func TestAurum_GetUsersLimit(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("admin", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "admin").Return(models.RoleAdmin, nil)
+	ms.EXPECT().GetUsers(gomock.Any(), models.UserQuery{Limit: MaxPageSize})
+
+	// SUT
+	_, err = au.GetUsers(ctx, token, models.UserQuery{Limit: 10 * MaxPageSize})
+	assert.NoError(t, err)
+}
+
+func TestAurum_GetUsersNotAdmin(t *testing.T) {
+	ctx := context.Background()
+	ctrl, ctx := gomock.WithContext(ctx, t)
+	defer ctrl.Finish()
+
+	ms := mock_store.NewMockAurumStore(ctrl)
+	expectNotRevoked(ms)
+
+	cfg := config.EphemeralConfig()
+
+	au := Aurum{db: ms, pk: cfg.PublicKey, sk: cfg.SecretKey}
+
+	token, err := jwt.GenerateJWT("bob", false, cfg.SecretKey)
+	assert.NoError(t, err)
+
+	ms.EXPECT().GetGroupRole(gomock.Any(), AurumName, "bob").Return(models.RoleUser, nil)
+
+	// SUT
+	_, err = au.GetUsers(ctx, token, models.UserQuery{})
+	assert.Equal(t, ErrUnauthorized, err)
 }
@@ -1,13 +1,231 @@
+// Package hash hashes passwords. Hashes describe the algorithm and parameters they were made with,
+// so they can be verified after the parameters changed, and upgraded when the user logs in.
 package hash
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
 
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+
+	// bcryptMaxLength is the number of bytes of a password bcrypt uses, the rest is ignored
+	bcryptMaxLength = 72
+
+	// argon2MaxMemory (in KiB) and argon2MaxTime bound what verifying a single stored hash may cost
+	argon2MaxMemory = 4 * 1024 * 1024
+	argon2MaxTime   = 64
+)
+
+// Hasher hashes and verifies passwords
+type Hasher interface {
+	// Hash hashes a password with a fresh salt
+	Hash(password string) (string, error)
+	// Verify tells whether the hash was made from the password
+	Verify(password, hash string) bool
+	// NeedsRehash tells whether a hash should be replaced by a new one, because it wasn't made the way
+	// the Hasher makes them now
+	NeedsRehash(hash string) bool
+	// MaxLength is the maximum length in bytes of the passwords which can be hashed, or 0 when there is none
+	MaxLength() int
+}
+
+// Params selects the algorithm new hashes are made with, and how expensive they are
+type Params struct {
+	// Algorithm is either Argon2id or Bcrypt
+	Algorithm string
+
+	// Memory (in KiB), Time (number of passes) and Parallelism (number of lanes) of argon2id
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+
+	// Cost of bcrypt
+	Cost int
+}
+
+// DefaultParams follows the second recommendation of RFC 9106. The zero Params means DefaultParams.
+var DefaultParams = Params{
+	Algorithm:   Argon2id,
+	Memory:      64 * 1024,
+	Time:        3,
+	Parallelism: 4,
+	Cost:        bcrypt.DefaultCost,
+}
+
+func (p Params) orDefault() Params {
+	if p == (Params{}) {
+		return DefaultParams
+	}
+	return p
+}
+
+// Validate checks that hashes can be made with p
+func (p Params) Validate() error {
+	p = p.orDefault()
+
+	switch p.Algorithm {
+	case Argon2id:
+		if p.Time < 1 || p.Parallelism < 1 {
+			return errors.New("argon2id needs at least one pass and one lane")
+		}
+		if p.Memory < 8*uint32(p.Parallelism) {
+			return errors.New("argon2id needs at least 8 KiB of memory per lane")
+		}
+		if p.Memory > argon2MaxMemory || p.Time > argon2MaxTime {
+			return errors.Errorf("argon2id can use at most %d KiB of memory and %d passes", argon2MaxMemory, argon2MaxTime)
+		}
+	case Bcrypt:
+		if p.Cost > bcrypt.MaxCost {
+			return errors.Errorf("bcrypt cost can't be more than %d", bcrypt.MaxCost)
+		}
+	default:
+		return errors.Errorf("unknown hashing algorithm %q", p.Algorithm)
+	}
+
+	return nil
+}
+
+// Hash hashes a password with a fresh salt
+func (p Params) Hash(password string) (string, error) {
+	p = p.orDefault()
+
+	switch p.Algorithm {
+	case Argon2id:
+		salt := make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", errors.Wrap(err, "random")
+		}
+
+		key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Parallelism, keyLength)
+		return encodeArgon2id(p, salt, key), nil
+	case Bcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.Cost)
+		return string(bytes), err
+	default:
+		return "", errors.Errorf("unknown hashing algorithm %q", p.Algorithm)
+	}
+}
+
+// Verify tells whether the hash was made from the password, whichever algorithm and parameters
+// it was made with
+func (p Params) Verify(password, hash string) bool {
+	return CheckPasswordHash(password, hash)
+}
+
+// NeedsRehash tells whether a hash was made with another algorithm or other parameters than p
+func (p Params) NeedsRehash(hash string) bool {
+	p = p.orDefault()
+
+	switch p.Algorithm {
+	case Argon2id:
+		hp, _, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+
+		return hp.Memory != p.Memory || hp.Time != p.Time || hp.Parallelism != p.Parallelism ||
+			len(key) != keyLength
+	case Bcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return true
+		}
+
+		want := p.Cost
+		if want < bcrypt.MinCost {
+			want = bcrypt.DefaultCost
+		}
+
+		return cost != want
+	default:
+		return false
+	}
+}
+
+// MaxLength is 72 for bcrypt, which ignores the rest of a password
+func (p Params) MaxLength() int {
+	if p.orDefault().Algorithm == Bcrypt {
+		return bcryptMaxLength
+	}
+	return 0
+}
+
+// HashPassword hashes a password with the DefaultParams
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return DefaultParams.Hash(password)
 }
 
+// CheckPasswordHash tells whether the hash was made from the password, whichever
+// algorithm and parameters it was made with
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, "$"+Argon2id+"$") {
+		p, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+
+		other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// encodeArgon2id encodes an argon2id hash in the PHC string format, like
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func encodeArgon2id(p Params, salt, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", Argon2id, argon2.Version, p.Memory, p.Time, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(hash string) (Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != Argon2id {
+		return Params{}, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Params{}, nil, nil, errors.Wrap(err, "invalid version")
+	} else if version != argon2.Version {
+		return Params{}, nil, nil, errors.Errorf("unsupported argon2 version %d", version)
+	}
+
+	p := Params{Algorithm: Argon2id}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Parallelism); err != nil {
+		return Params{}, nil, nil, errors.Wrap(err, "invalid parameters")
+	}
+
+	// Argon2 panics on some parameters, and others would take too much to verify
+	if err := p.Validate(); err != nil {
+		return Params{}, nil, nil, errors.Wrap(err, "invalid parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, errors.Wrap(err, "invalid salt")
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, errors.New("invalid key")
+	}
+
+	return p, salt, key, nil
+}
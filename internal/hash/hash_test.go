@@ -1,14 +1,112 @@
 package hash
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// cheap keeps the tests fast
+var cheap = Params{Algorithm: Argon2id, Memory: 64, Time: 1, Parallelism: 1}
+
 func TestHashPassword(t *testing.T) {
 	hash, err := HashPassword("yeet")
 	assert.Nil(t, err)
 
 	assert.True(t, CheckPasswordHash("yeet", hash))
 }
+
+func TestArgon2id(t *testing.T) {
+	hash, err := cheap.Hash("yeet")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+	assert.True(t, CheckPasswordHash("yeet", hash))
+	assert.False(t, CheckPasswordHash("yoink", hash))
+
+	// Every hash has its own salt
+	other, err := cheap.Hash("yeet")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestBcrypt(t *testing.T) {
+	p := Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost}
+
+	hash, err := p.Hash("yeet")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$2a$"))
+	assert.True(t, CheckPasswordHash("yeet", hash))
+	assert.False(t, CheckPasswordHash("yoink", hash))
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	_, err := Params{Algorithm: "md5"}.Hash("yeet")
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Params{}.Validate())
+	assert.NoError(t, cheap.Validate())
+	assert.NoError(t, Params{Algorithm: Bcrypt}.Validate())
+
+	assert.Error(t, Params{Algorithm: "md5"}.Validate())
+	assert.Error(t, Params{Algorithm: Argon2id, Memory: 64, Parallelism: 1}.Validate())
+	assert.Error(t, Params{Algorithm: Argon2id, Memory: 64, Time: 1}.Validate())
+	assert.Error(t, Params{Algorithm: Argon2id, Memory: 8, Time: 1, Parallelism: 2}.Validate())
+	assert.Error(t, Params{Algorithm: Argon2id, Memory: 8 * 1024 * 1024, Time: 1, Parallelism: 1}.Validate())
+	assert.Error(t, Params{Algorithm: Argon2id, Memory: 64, Time: 100, Parallelism: 1}.Validate())
+	assert.Error(t, Params{Algorithm: Bcrypt, Cost: 32}.Validate())
+}
+
+func TestCheckPasswordHashInvalid(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"yeet",
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$",
+		"$argon2id$v=19$m=64;t=1;p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=8,t=1,p=2$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=4294967295,p=1$c2FsdHNhbHQ$a2V5",
+	} {
+		assert.False(t, CheckPasswordHash("yeet", hash), hash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	old, err := bcrypt.GenerateFromPassword([]byte("yeet"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	hash, err := cheap.Hash("yeet")
+	require.NoError(t, err)
+
+	assert.True(t, cheap.NeedsRehash(string(old)))
+	assert.False(t, cheap.NeedsRehash(hash))
+	assert.True(t, cheap.NeedsRehash("yeet"))
+
+	stronger := cheap
+	stronger.Time = 2
+	assert.True(t, stronger.NeedsRehash(hash))
+
+	// Bcrypt hashes only need a rehash when the cost changed
+	p := Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost}
+	assert.False(t, p.NeedsRehash(string(old)))
+	assert.True(t, p.NeedsRehash(hash))
+
+	p.Cost++
+	assert.True(t, p.NeedsRehash(string(old)))
+}
+
+func TestMaxLength(t *testing.T) {
+	assert.Equal(t, 0, Params{}.MaxLength())
+	assert.Equal(t, 0, cheap.MaxLength())
+	assert.Equal(t, 72, Params{Algorithm: Bcrypt}.MaxLength())
+}
@@ -0,0 +1,112 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// pepperPrefix starts the hashes of peppered passwords, followed by the version of the pepper
+const pepperPrefix = "$pepper="
+
+// Peppered is a Hasher which mixes a secret pepper into passwords before hashing them. Unlike the salt,
+// the pepper isn't stored in the database, so a leaked database alone isn't enough to crack the hashes.
+//
+// Hashes record the version of the pepper they were made with, so the pepper can be rotated by adding a
+// new version. Hashes made with older versions, or without pepper, can still be verified, and need a rehash.
+type Peppered struct {
+	Params
+
+	peppers map[int][]byte
+	current int
+}
+
+// NewHasher creates a Hasher making hashes with p, peppered with the highest version of peppers.
+// Without peppers passwords aren't peppered at all.
+func NewHasher(p Params, peppers map[int][]byte) Hasher {
+	if len(peppers) == 0 {
+		return p
+	}
+
+	current := 0
+	for version := range peppers {
+		if version > current {
+			current = version
+		}
+	}
+
+	return Peppered{Params: p, peppers: peppers, current: current}
+}
+
+// PepperPrefix is the prefix of hashes made with the pepper with version, or of all peppered hashes
+// when version is 0.
+func PepperPrefix(version int) string {
+	if version == 0 {
+		return pepperPrefix
+	}
+
+	return pepperPrefix + strconv.Itoa(version) + "$"
+}
+
+// pepper mixes the pepper into a password. The result has a fixed length, short enough for bcrypt.
+func pepper(password string, pepper []byte) string {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// splitPepper splits a hash into the version of its pepper and the hash of the peppered password.
+// Hashes without pepper have version 0.
+func splitPepper(hash string) (int, string) {
+	if !strings.HasPrefix(hash, pepperPrefix) {
+		return 0, hash
+	}
+
+	rest := strings.TrimPrefix(hash, pepperPrefix)
+	i := strings.Index(rest, "$")
+	if i < 0 {
+		return -1, ""
+	}
+
+	version, err := strconv.Atoi(rest[:i])
+	if err != nil || version <= 0 {
+		return -1, ""
+	}
+
+	return version, rest[i:]
+}
+
+func (h Peppered) Hash(password string) (string, error) {
+	hash, err := h.Params.Hash(pepper(password, h.peppers[h.current]))
+	if err != nil {
+		return "", err
+	}
+
+	return pepperPrefix + strconv.Itoa(h.current) + hash, nil
+}
+
+func (h Peppered) Verify(password, hash string) bool {
+	version, hash := splitPepper(hash)
+	if version == 0 {
+		return h.Params.Verify(password, hash)
+	}
+
+	p, ok := h.peppers[version]
+	if !ok {
+		return false
+	}
+
+	return h.Params.Verify(pepper(password, p), hash)
+}
+
+func (h Peppered) NeedsRehash(hash string) bool {
+	version, hash := splitPepper(hash)
+	return version != h.current || h.Params.NeedsRehash(hash)
+}
+
+// MaxLength is 0, as peppered passwords always fit
+func (h Peppered) MaxLength() int {
+	return 0
+}
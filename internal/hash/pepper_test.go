@@ -0,0 +1,80 @@
+package hash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewHasherWithoutPepper(t *testing.T) {
+	assert.Equal(t, cheap, NewHasher(cheap, nil))
+}
+
+func TestPeppered(t *testing.T) {
+	h := NewHasher(cheap, map[int][]byte{1: []byte("first pepper"), 2: []byte("second pepper")})
+
+	hash, err := h.Hash("yeet")
+	require.NoError(t, err)
+
+	// The highest version is used
+	assert.True(t, strings.HasPrefix(hash, "$pepper=2$argon2id$"))
+	assert.True(t, strings.HasPrefix(hash, PepperPrefix(2)))
+	assert.True(t, h.Verify("yeet", hash))
+	assert.False(t, h.Verify("yoink", hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	// Without the pepper the hash is useless
+	assert.False(t, CheckPasswordHash("yeet", strings.TrimPrefix(hash, "$pepper=2")))
+
+	other := NewHasher(cheap, map[int][]byte{2: []byte("another pepper")})
+	assert.False(t, other.Verify("yeet", hash))
+}
+
+func TestPepperedRotation(t *testing.T) {
+	old, err := NewHasher(cheap, map[int][]byte{1: []byte("first pepper")}).Hash("yeet")
+	require.NoError(t, err)
+
+	h := NewHasher(cheap, map[int][]byte{1: []byte("first pepper"), 2: []byte("second pepper")})
+	assert.True(t, h.Verify("yeet", old))
+	assert.True(t, h.NeedsRehash(old))
+
+	// Versions which are gone can't be verified anymore
+	h = NewHasher(cheap, map[int][]byte{2: []byte("second pepper")})
+	assert.False(t, h.Verify("yeet", old))
+}
+
+func TestPepperedUnpeppered(t *testing.T) {
+	old, err := cheap.Hash("yeet")
+	require.NoError(t, err)
+
+	h := NewHasher(cheap, map[int][]byte{1: []byte("first pepper")})
+	assert.True(t, h.Verify("yeet", old))
+	assert.False(t, h.Verify("yoink", old))
+	assert.True(t, h.NeedsRehash(old))
+}
+
+func TestPepperedInvalid(t *testing.T) {
+	h := NewHasher(cheap, map[int][]byte{1: []byte("first pepper")})
+
+	for _, hash := range []string{"$pepper=", "$pepper=1", "$pepper=x$argon2id$", "$pepper=-1$argon2id$"} {
+		assert.False(t, h.Verify("yeet", hash), hash)
+		assert.True(t, h.NeedsRehash(hash), hash)
+	}
+}
+
+func TestPepperedBcrypt(t *testing.T) {
+	h := NewHasher(Params{Algorithm: Bcrypt, Cost: bcrypt.MinCost}, map[int][]byte{1: []byte("first pepper")})
+
+	// Long passwords can be hashed, as the pepper makes every password equally long
+	long := strings.Repeat("a", 80)
+
+	hash, err := h.Hash(long)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, h.MaxLength())
+	assert.True(t, h.Verify(long, hash))
+	assert.False(t, h.Verify(long[:72], hash))
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileMailer appends messages to a file instead of delivering them, for development and testing
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+// NewFileMailer creates a mailer which appends the messages it sends to the file at path
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+
+	// Messages are separated by a "From " line, like in an mbox file
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From %s %s\r\n", m.from, now.Format(time.ANSIC))
+	buf.Write(format(m.from, msg, now))
+	buf.WriteString("\r\n\r\n")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "opening mail log")
+	}
+
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return errors.Wrap(err, "writing mail log")
+}
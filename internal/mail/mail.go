@@ -0,0 +1,50 @@
+// Package mail delivers the mail Aurum sends to its users, like password reset links
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message is a plain text mail to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// headerSanitizer strips line breaks from header values, so they can't inject headers
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// format renders msg as an RFC 5322 message sent by from
+func format(from string, msg Message, date time.Time) []byte {
+	var buf bytes.Buffer
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headerSanitizer.Replace(value))
+	}
+
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	buf.WriteString("\r\n")
+
+	// Lines in the body must be terminated by CRLF as well
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return buf.Bytes()
+}
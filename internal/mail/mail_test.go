@@ -0,0 +1,139 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var message = Message{
+	To:      "bob@example.com",
+	Subject: "Reset your password",
+	Body:    "Hi bob,\n\nHere's your link.\n",
+}
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	msg := string(format("aurum@example.com", Message{
+		To:      "bob@example.com\r\nBcc: eve@example.com",
+		Subject: "Héllo",
+		Body:    "a\nb",
+	}, date))
+
+	assert.Contains(t, msg, "From: aurum@example.com\r\n")
+	assert.Contains(t, msg, "To: bob@example.comBcc: eve@example.com\r\n")
+	assert.Contains(t, msg, "Subject: =?utf-8?q?H=C3=A9llo?=\r\n")
+	assert.Contains(t, msg, "Date: Thu, 01 Oct 2020 12:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(msg, "\r\n\r\na\r\nb"))
+}
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aurum-mail")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "mail.log")
+	m := NewFileMailer(path, "aurum@example.com")
+
+	require.NoError(t, m.Send(context.Background(), message))
+	require.NoError(t, m.Send(context.Background(), message))
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, strings.Count(string(contents), "From aurum@example.com "))
+	assert.Equal(t, 2, strings.Count(string(contents), "Here's your link."))
+}
+
+// smtpSink accepts a single message over SMTP, and returns the recipient and data of it
+func smtpSink(l net.Listener) <-chan []string {
+	received := make(chan []string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+		var rcpt string
+		var data []string
+
+		reply("220 localhost ESMTP sink")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+
+			switch {
+			case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(line, "RCPT TO:"):
+				rcpt = strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+				reply("250 OK")
+			case line == "DATA":
+				reply("354 Go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimRight(line, "\r\n")
+					if line == "." {
+						break
+					}
+					data = append(data, line)
+				}
+				reply("250 OK")
+			case line == "QUIT":
+				reply("221 Bye")
+				received <- append([]string{rcpt}, data...)
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := smtpSink(l)
+
+	m, err := NewSMTPMailer(l.Addr().String(), "aurum@example.com", "", "")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), message))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, message.To, msg[0])
+		assert.Contains(t, msg, "Subject: Reset your password")
+		assert.Contains(t, msg, "Here's your link.")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestNewSMTPMailerInvalidAddress(t *testing.T) {
+	_, err := NewSMTPMailer("localhost", "aurum@example.com", "", "")
+	assert.Error(t, err)
+}
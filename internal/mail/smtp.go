@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SMTPMailer delivers messages through an SMTP server
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer which sends mail from the given address through the SMTP server at
+// addr (host:port). Without a username no authentication is used, which suits a local SMTP sink.
+func NewSMTPMailer(addr, from, username, password string) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid smtp address")
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{addr: addr, from: from, auth: auth}, nil
+}
+
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, format(m.from, msg, time.Now()))
+	return errors.Wrap(err, "sending mail")
+}
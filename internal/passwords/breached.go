@@ -0,0 +1,131 @@
+package passwords
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// digestSize is the size of the SHA-1 digests in a breached password list
+const digestSize = sha1.Size
+
+// BreachedList is a list of passwords which appeared in data breaches, like the Pwned Passwords list of
+// haveibeenpwned.com. It's a file of the SHA-1 digests of the passwords, sorted and concatenated.
+// Lookups search the file directly, so the list isn't loaded into memory however large it is.
+type BreachedList struct {
+	f *os.File
+	n int64
+}
+
+// OpenBreachedList opens a breached password list, made by ConvertBreachedList
+func OpenBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open breached password list")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrap(err, "couldn't open breached password list")
+	}
+
+	if info.Size()%digestSize != 0 {
+		_ = f.Close()
+		return nil, errors.Errorf("breached password list isn't a list of %d byte digests", digestSize)
+	}
+
+	return &BreachedList{f: f, n: info.Size() / digestSize}, nil
+}
+
+// Len is the number of passwords in the list
+func (l *BreachedList) Len() int64 {
+	return l.n
+}
+
+// Contains tells whether the password is in the list
+func (l *BreachedList) Contains(password string) (bool, error) {
+	digest := sha1.Sum([]byte(password))
+	buf := make([]byte, digestSize)
+
+	// Binary search, reading a single digest at every step
+	lo, hi := int64(0), l.n
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		if _, err := l.f.ReadAt(buf, mid*digestSize); err != nil {
+			return false, errors.Wrap(err, "couldn't read breached password list")
+		}
+
+		switch bytes.Compare(buf, digest[:]) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+func (l *BreachedList) Close() error {
+	return l.f.Close()
+}
+
+// ConvertBreachedList converts a list of hex encoded SHA-1 digests, one per line and optionally followed by
+// a colon and a count like the Pwned Passwords list "ordered by hash", to a list for OpenBreachedList.
+// The input must be sorted, it's converted line by line.
+func ConvertBreachedList(w io.Writer, r io.Reader) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	bw := bufio.NewWriter(w)
+
+	var n int64
+	prev := make([]byte, digestSize)
+	digest := make([]byte, digestSize)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if line == "" {
+			continue
+		}
+
+		if len(line) != 2*digestSize {
+			return n, errors.Errorf("line %d isn't a SHA-1 digest", lineNo)
+		}
+		if _, err := hex.Decode(digest, []byte(line)); err != nil {
+			return n, errors.Wrapf(err, "line %d isn't a SHA-1 digest", lineNo)
+		}
+
+		if n > 0 {
+			if c := bytes.Compare(prev, digest); c > 0 {
+				return n, errors.Errorf("line %d isn't sorted", lineNo)
+			} else if c == 0 {
+				continue
+			}
+		}
+
+		if _, err := bw.Write(digest); err != nil {
+			return n, err
+		}
+
+		copy(prev, digest)
+		n++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+
+	return n, bw.Flush()
+}
@@ -0,0 +1,112 @@
+package passwords
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pwnedList formats passwords like the Pwned Passwords list ordered by hash
+func pwnedList(passwords ...string) string {
+	var lines []string
+	for i, password := range passwords {
+		digest := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(digest[:])), i+1))
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\r\n")
+}
+
+func writeBreachedList(t *testing.T, passwords ...string) string {
+	var buf bytes.Buffer
+	n, err := ConvertBreachedList(&buf, strings.NewReader(pwnedList(passwords...)))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(passwords)), n)
+
+	f, err := ioutil.TempFile("", "breached")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(f.Name()) })
+
+	_, err = f.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	return f.Name()
+}
+
+func TestBreachedList(t *testing.T) {
+	breached := []string{"password", "123456", "qwerty", "hunter2", "correct horse battery staple"}
+
+	l, err := OpenBreachedList(writeBreachedList(t, breached...))
+	require.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, int64(len(breached)), l.Len())
+
+	for _, password := range breached {
+		ok, err := l.Contains(password)
+		assert.NoError(t, err)
+		assert.True(t, ok, password)
+	}
+
+	for _, password := range []string{"", "Password", "7da033bd32005113f2208eb87bc94c12"} {
+		ok, err := l.Contains(password)
+		assert.NoError(t, err)
+		assert.False(t, ok, password)
+	}
+}
+
+func TestBreachedListEmpty(t *testing.T) {
+	l, err := OpenBreachedList(writeBreachedList(t))
+	require.NoError(t, err)
+	defer l.Close()
+
+	ok, err := l.Contains("password")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestOpenBreachedListInvalid(t *testing.T) {
+	_, err := OpenBreachedList(filepath.Join(os.TempDir(), "non-existing-breached-list"))
+	assert.Error(t, err)
+
+	f, err := ioutil.TempFile("", "breached")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.Write([]byte("not a list of digests"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = OpenBreachedList(f.Name())
+	assert.Error(t, err)
+}
+
+func TestConvertBreachedList(t *testing.T) {
+	// Duplicates are skipped
+	var buf bytes.Buffer
+	n, err := ConvertBreachedList(&buf, strings.NewReader(pwnedList("password", "password", "qwerty")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, 2*sha1.Size, buf.Len())
+
+	for _, list := range []string{
+		"not a digest",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FDZ:1",
+		"B1B3773A05C0ED0176787A4F1574FF0075F7521E\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8",
+	} {
+		_, err := ConvertBreachedList(ioutil.Discard, strings.NewReader(list))
+		assert.Error(t, err, list)
+	}
+}
@@ -1,26 +1,137 @@
 package passwords
 
 import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/finitum/aurum/pkg/models"
 	"github.com/trustelem/zxcvbn"
 )
 
-var commonTerms = []string{
-	"aurum",
-	"finitum",
+const (
+	// MaxPolicyLength bounds the lengths a policy can demand
+	MaxPolicyLength = 1024
+	// MaxHistoryDepth bounds the history a policy can keep, as every new password is checked against all of it
+	MaxHistoryDepth = 24
+	// MaxAgeDays bounds the maximum age a policy can give passwords
+	MaxAgeDays = 10 * 365
+)
+
+// DefaultPolicy is the policy of Aurum unless configured otherwise
+var DefaultPolicy = models.PasswordPolicy{
+	MinLength:   8,
+	MinScore:    3,
+	BannedWords: []string{"aurum", "finitum"},
 }
 
+// CheckStrength checks a password against the DefaultPolicy
 func CheckStrength(password string, userinput []string) bool {
-	if len(password) < 8 {
+	return Check(DefaultPolicy, password, userinput)
+}
+
+// Check tells whether a password follows the policy. The userinput are words related to the user, like
+// their username, which make passwords easier to guess.
+func Check(policy models.PasswordPolicy, password string, userinput []string) bool {
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength || (policy.MaxLength > 0 && length > policy.MaxLength) {
 		return false
 	}
 
-	// Max length for bcrypt
-	if len(password) > 72 {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if (policy.RequireLower && !lower) || (policy.RequireUpper && !upper) ||
+		(policy.RequireDigit && !digit) || (policy.RequireSymbol && !symbol) {
 		return false
 	}
 
-	disallowed := append(commonTerms, userinput...)
+	folded := strings.ToLower(password)
+	for _, word := range policy.BannedWords {
+		if word != "" && strings.Contains(folded, strings.ToLower(word)) {
+			return false
+		}
+	}
+
+	if policy.MinScore <= 0 {
+		return true
+	}
+
+	disallowed := make([]string, 0, len(policy.BannedWords)+len(userinput))
+	disallowed = append(disallowed, policy.BannedWords...)
+	disallowed = append(disallowed, userinput...)
+
 	res := zxcvbn.PasswordStrength(password, disallowed)
+	return res.Score >= policy.MinScore
+}
+
+// Valid tells whether a policy makes sense, so passwords can follow it
+func Valid(policy models.PasswordPolicy) bool {
+	if policy.MinLength < 0 || policy.MaxLength < 0 || policy.HistoryDepth < 0 || policy.MaxAgeDays < 0 {
+		return false
+	}
+
+	if policy.MinLength > MaxPolicyLength || policy.MaxLength > MaxPolicyLength ||
+		policy.HistoryDepth > MaxHistoryDepth || policy.MaxAgeDays > MaxAgeDays {
+		return false
+	}
+
+	if policy.MinScore < 0 || policy.MinScore > 4 {
+		return false
+	}
+
+	return policy.MaxLength == 0 || policy.MaxLength >= policy.MinLength
+}
+
+// Stricter combines two policies into one, which only allows passwords both allow
+func Stricter(a, b models.PasswordPolicy) models.PasswordPolicy {
+	p := models.PasswordPolicy{
+		MinLength:     max(a.MinLength, b.MinLength),
+		MaxLength:     minNonZero(a.MaxLength, b.MaxLength),
+		MinScore:      max(a.MinScore, b.MinScore),
+		RequireLower:  a.RequireLower || b.RequireLower,
+		RequireUpper:  a.RequireUpper || b.RequireUpper,
+		RequireDigit:  a.RequireDigit || b.RequireDigit,
+		RequireSymbol: a.RequireSymbol || b.RequireSymbol,
+		HistoryDepth:  max(a.HistoryDepth, b.HistoryDepth),
+		MaxAgeDays:    minNonZero(a.MaxAgeDays, b.MaxAgeDays),
+	}
 
-	return res.Score > 2
+	seen := make(map[string]bool)
+	for _, words := range [][]string{a.BannedWords, b.BannedWords} {
+		for _, word := range words {
+			if folded := strings.ToLower(word); folded != "" && !seen[folded] {
+				seen[folded] = true
+				p.BannedWords = append(p.BannedWords, word)
+			}
+		}
+	}
+
+	return p
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// minNonZero is the minimum of a and b, where 0 means there's no limit
+func minNonZero(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
 }
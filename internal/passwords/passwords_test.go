@@ -3,6 +3,7 @@ package passwords
 import (
 	"testing"
 
+	"github.com/finitum/aurum/pkg/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -10,8 +11,8 @@ func TestVerifyPasswordTooShort(t *testing.T) {
 	assert.False(t, CheckStrength("18828", nil))
 }
 
-func TestVerifyPasswordTooLong(t *testing.T) {
-	assert.False(t, CheckStrength("4b93310ed64ce510889be78f32203f9768c4054b9af08489ed90a59465616ef64b93310ed64ce510889be78f32203f9768c4054b9af08489ed90a59465616ef6", nil))
+func TestVerifyPasswordLong(t *testing.T) {
+	assert.True(t, CheckStrength("4b93310ed64ce510889be78f32203f9768c4054b9af08489ed90a59465616ef64b93310ed64ce510889be78f32203f9768c4054b9af08489ed90a59465616ef6", nil))
 }
 
 func TestVerifyPasswordTooCommon(t *testing.T) {
@@ -32,3 +33,97 @@ func TestVerifyPasswordSameAsUserInput(t *testing.T) {
 func TestValidPassword(t *testing.T) {
 	assert.True(t, CheckStrength("7da033bd32005113f2208eb87bc94c126a42aadf0c94065b1fa4d9d68e7c318f", nil))
 }
+
+func TestCheckLength(t *testing.T) {
+	policy := models.PasswordPolicy{MinLength: 4, MaxLength: 6}
+
+	assert.False(t, Check(policy, "abc", nil))
+	assert.True(t, Check(policy, "abcd", nil))
+	assert.True(t, Check(policy, "abcdef", nil))
+	assert.False(t, Check(policy, "abcdefg", nil))
+
+	// Lengths are in characters
+	assert.True(t, Check(policy, "ŭŭŭŭŭŭ", nil))
+}
+
+func TestCheckCharacterClasses(t *testing.T) {
+	policy := models.PasswordPolicy{RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+
+	assert.True(t, Check(policy, "aA1!", nil))
+	assert.False(t, Check(policy, "A1!", nil))
+	assert.False(t, Check(policy, "a1!", nil))
+	assert.False(t, Check(policy, "aA!", nil))
+	assert.False(t, Check(policy, "aA1", nil))
+	assert.False(t, Check(policy, "aA1 ", nil))
+}
+
+func TestCheckBannedWords(t *testing.T) {
+	policy := models.PasswordPolicy{BannedWords: []string{"Secret"}}
+
+	assert.False(t, Check(policy, "mysecret", nil))
+	assert.False(t, Check(policy, "MYSECRETPASSWORD", nil))
+	assert.True(t, Check(policy, "mysecre7", nil))
+}
+
+func TestCheckScore(t *testing.T) {
+	assert.True(t, Check(models.PasswordPolicy{}, "password", nil))
+	assert.False(t, Check(models.PasswordPolicy{MinScore: 1}, "password", nil))
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(models.PasswordPolicy{}))
+	assert.True(t, Valid(DefaultPolicy))
+	assert.True(t, Valid(models.PasswordPolicy{MinLength: 8, MaxLength: 8, MinScore: 4}))
+
+	assert.False(t, Valid(models.PasswordPolicy{MinLength: -1}))
+	assert.False(t, Valid(models.PasswordPolicy{MinScore: 5}))
+	assert.False(t, Valid(models.PasswordPolicy{MinLength: 8, MaxLength: 7}))
+	assert.False(t, Valid(models.PasswordPolicy{HistoryDepth: -1}))
+	assert.False(t, Valid(models.PasswordPolicy{MaxAgeDays: -1}))
+
+	// Limits which would make checking passwords too expensive, or make no sense
+	assert.True(t, Valid(models.PasswordPolicy{MinLength: MaxPolicyLength, HistoryDepth: MaxHistoryDepth, MaxAgeDays: MaxAgeDays}))
+	assert.False(t, Valid(models.PasswordPolicy{MinLength: MaxPolicyLength + 1}))
+	assert.False(t, Valid(models.PasswordPolicy{MaxLength: MaxPolicyLength + 1}))
+	assert.False(t, Valid(models.PasswordPolicy{HistoryDepth: 1e6}))
+	assert.False(t, Valid(models.PasswordPolicy{MaxAgeDays: MaxAgeDays + 1}))
+}
+
+func TestStricter(t *testing.T) {
+	a := models.PasswordPolicy{
+		MinLength:    8,
+		MaxLength:    64,
+		MinScore:     3,
+		RequireLower: true,
+		BannedWords:  []string{"aurum", "finitum"},
+		HistoryDepth: 5,
+	}
+	b := models.PasswordPolicy{
+		MinLength:    12,
+		MinScore:     2,
+		RequireDigit: true,
+		BannedWords:  []string{"Aurum", "acme"},
+		MaxAgeDays:   90,
+	}
+
+	expected := models.PasswordPolicy{
+		MinLength:    12,
+		MaxLength:    64,
+		MinScore:     3,
+		RequireLower: true,
+		RequireDigit: true,
+		BannedWords:  []string{"aurum", "finitum", "acme"},
+		HistoryDepth: 5,
+		MaxAgeDays:   90,
+	}
+
+	assert.Equal(t, expected, Stricter(a, b))
+
+	// Only the order of the banned words depends on the order of the policies
+	reversed := Stricter(b, a)
+	assert.Equal(t, []string{"Aurum", "acme", "finitum"}, reversed.BannedWords)
+	reversed.BannedWords = expected.BannedWords
+	assert.Equal(t, expected, reversed)
+
+	assert.Equal(t, a, Stricter(a, models.PasswordPolicy{}))
+}
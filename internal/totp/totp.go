@@ -0,0 +1,133 @@
+// Package totp implements time-based one-time passwords (RFC 6238), the codes shown by
+// authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Digits is the number of digits of a code
+	Digits = 6
+	// Period is how long a single code is valid
+	Period = 30 * time.Second
+
+	// skew is the number of periods a code may be off, to allow for clock drift
+	// and the time it takes to type a code
+	skew = 1
+
+	// secretSize is the size of a secret in bytes, the size of the SHA-1 output as RFC 4226 recommends
+	secretSize = 20
+)
+
+// encoding is how secrets are presented, authenticator apps expect base32 without padding
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrInvalidSecret is returned when a secret isn't valid base32
+var ErrInvalidSecret = errors.New("invalid totp secret")
+
+// GenerateSecret generates a new random secret, encoded in base32
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "generating secret")
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI creates the otpauth URI with which authenticator apps are set up, usually shown as a QR code.
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func URI(issuer, account, secret string) string {
+	params := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(Digits)},
+		"period":    {fmt.Sprint(int(Period / time.Second))},
+	}
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: params.Encode(),
+	}
+
+	return u.String()
+}
+
+// Step is the time step t falls in, the moving factor of TOTP
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code generates the code for the given time step
+func Code(secret string, step int64) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, uint64(step), Digits), nil
+}
+
+// Validate checks whether code is valid at time t. It returns the time step the code
+// belongs to, so callers can reject codes which were used before.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+
+	now := Step(t)
+	for step := now - skew; step <= now+skew; step++ {
+		expected := hotp(key, uint64(step), Digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	return key, nil
+}
+
+// hotp generates an HMAC-SHA-1 based one-time password (RFC 4226)
+func hotp(key []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the secret used by the test vectors of RFC 4226 and RFC 6238
+const rfcSecret = "12345678901234567890"
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226, appendix D
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range expected {
+		assert.Equal(t, code, hotp([]byte(rfcSecret), uint64(counter), 6))
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238, appendix B (SHA-1)
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.time, 0))
+		assert.Equal(t, tt.code, hotp([]byte(rfcSecret), uint64(step), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	step := Step(now)
+
+	code, err := Code(secret, step)
+	assert.NoError(t, err)
+
+	got, ok := Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	// Codes of the previous period are still accepted
+	got, ok = Validate(secret, code, now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	_, ok = Validate(secret, code, now.Add(3*Period))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	assert.False(t, ok)
+
+	_, ok = Validate("not base32!", code, now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Aurum", "bob", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Aurum:bob", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "Aurum", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	"github.com/finitum/aurum/internal/aurum"
 	"github.com/finitum/aurum/pkg/jwt"
@@ -28,6 +29,93 @@ func AddGroup(host string, tp *jwt.TokenPair, group *models.Group) error {
 	return err
 }
 
+// GetGroups lists a page of groups. Unless the token belongs to an admin, only groups
+// which allow registration are listed.
+func GetGroups(host string, tp *jwt.TokenPair, query models.GroupQuery) (*models.GroupPage, error) {
+	req, err := http.NewRequest(http.MethodGet, host+"/groups?"+query.Values().Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var page models.GroupPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// GetGroup retrieves a group the token's user is allowed to see
+func GetGroup(host string, tp *jwt.TokenPair, group string) (*models.Group, error) {
+	req, err := http.NewRequest(http.MethodGet, host+"/group/"+url.PathEscape(group), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var g models.Group
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// UpdateGroup changes the settings of a group, which requires the token to belong to an admin of the group
+func UpdateGroup(host string, tp *jwt.TokenPair, group string, update models.GroupUpdate) (*models.Group, error) {
+	body, err := json.Marshal(&update)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling json")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, host+"/group/"+url.PathEscape(group), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "building update group request")
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, errors.Wrap(err, "update group")
+	}
+
+	var g models.Group
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		return nil, errors.Wrap(err, "json decoding response")
+	}
+
+	return &g, nil
+}
+
+// GetGroupMembers lists a page of the members of a group. Unless the token belongs to an admin
+// of the group, only the group's admins are listed.
+func GetGroupMembers(host string, tp *jwt.TokenPair, group string, query models.MemberQuery) (*models.MemberPage, error) {
+	req, err := http.NewRequest(http.MethodGet, host+"/group/"+url.PathEscape(group)+"/members?"+query.Values().Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var page models.MemberPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
 func RemoveGroup(host string, tp *jwt.TokenPair, group string) error {
 	req, err := http.NewRequest(http.MethodDelete, host+"/group/"+group, nil)
 	if err != nil {
@@ -44,6 +44,148 @@ func TestAddGroup(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetGroups(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	query := models.GroupQuery{
+		Search: "group",
+		Match:  models.MatchPrefix,
+		Limit:  2,
+	}
+
+	expected := models.GroupPage{
+		Groups: []models.Group{{Name: "group-a"}, {Name: "group-b"}},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/groups", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		recv, err := models.ParseGroupQuery(r.URL.Query())
+		assert.NoError(t, err)
+		assert.Equal(t, query, recv)
+
+		err = json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	page, err := GetGroups(ts.URL, &tp, query)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, page)
+}
+
+func TestGetGroup(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := models.Group{Name: "group", AllowRegistration: true}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/group/group", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	group, err := GetGroup(ts.URL, &tp, "group")
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, group)
+}
+
+func TestUpdateGroup(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	name := "Group"
+	update := models.GroupUpdate{
+		DisplayName: &name,
+		Metadata:    map[string]string{"team": "alpha"},
+	}
+
+	expected := models.Group{
+		Name:        "group",
+		DisplayName: name,
+		Metadata:    map[string]string{"team": "alpha"},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/group/group", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		var recv models.GroupUpdate
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, update, recv)
+
+		err = json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	group, err := UpdateGroup(ts.URL, &tp, "group", update)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, group)
+}
+
+func TestGetGroupMembers(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	query := models.MemberQuery{
+		Search: "bob",
+		Role:   models.RoleAdmin,
+		Limit:  2,
+	}
+
+	expected := models.MemberPage{
+		Members: []models.UserWithRole{
+			{User: models.User{Username: "bob", Email: "bob@example.com"}, Role: models.RoleAdmin},
+		},
+		NextCursor: "next",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/group/group/members", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		recv, err := models.ParseMemberQuery(r.URL.Query())
+		assert.NoError(t, err)
+		assert.Equal(t, query, recv)
+
+		err = json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	page, err := GetGroupMembers(ts.URL, &tp, "group", query)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, page)
+}
+
 func TestRemoveGroup(t *testing.T) {
 	group := "group"
 
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// ErrLockedOut is returned by Login when the account, or the address it's called from,
+// is locked because of too many failed logins
+var ErrLockedOut = errors.New("too many failed logins, try again later")
+
+// ErrRateLimited is returned by Login when too many requests were made, unlike locked out
+// responses these come with a Retry-After header
+var ErrRateLimited = errors.New("too many requests, try again later")
+
+func getLockout(tp *jwt.TokenPair, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func unlock(tp *jwt.TokenPair, url string) error {
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = authenticatedRequest(req, tp)
+	return err
+}
+
+// GetLockouts lists the locked accounts and addresses, which requires the token to belong to an admin
+func GetLockouts(host string, tp *jwt.TokenPair) ([]models.Lockout, error) {
+	var lockouts []models.Lockout
+	if err := getLockout(tp, host+"/lockouts", &lockouts); err != nil {
+		return nil, err
+	}
+
+	return lockouts, nil
+}
+
+// GetUserLockout gets the failed logins to an account, which requires the token to belong to an admin
+func GetUserLockout(host string, tp *jwt.TokenPair, user string) (*models.Lockout, error) {
+	var lockout models.Lockout
+	if err := getLockout(tp, host+"/user/"+url.PathEscape(user)+"/lockout", &lockout); err != nil {
+		return nil, err
+	}
+
+	return &lockout, nil
+}
+
+// UnlockUser forgets the failed logins to an account, which requires the token to belong to an admin
+func UnlockUser(host string, tp *jwt.TokenPair, user string) error {
+	return unlock(tp, host+"/user/"+url.PathEscape(user)+"/lockout")
+}
+
+// UnlockAddress forgets the failed logins from an address, which requires the token to belong to an admin
+func UnlockAddress(host string, tp *jwt.TokenPair, address string) error {
+	return unlock(tp, host+"/lockouts/"+url.PathEscape(address))
+}
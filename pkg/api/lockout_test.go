@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLockouts(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	until := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	expected := []models.Lockout{
+		{Address: "192.0.2.1", Failures: 20, LockedUntil: until},
+		{Username: "bob", Failures: 5, LockedUntil: until},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/lockouts", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "Bearer "+tp.LoginToken, r.Header.Get("Authorization"))
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	lockouts, err := GetLockouts(ts.URL, &tp)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, lockouts)
+}
+
+func TestGetUserLockout(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := models.Lockout{Username: "bob", Failures: 2}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob/lockout", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	lockout, err := GetUserLockout(ts.URL, &tp, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, lockout)
+}
+
+func TestUnlock(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "Bearer "+tp.LoginToken, r.Header.Get("Authorization"))
+		paths = append(paths, r.URL.Path)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	assert.NoError(t, UnlockUser(ts.URL, &tp, "bob"))
+	assert.NoError(t, UnlockAddress(ts.URL, &tp, "2001:db8::1"))
+	assert.Equal(t, []string{"/user/bob/lockout", "/lockouts/2001:db8::1"}, paths)
+}
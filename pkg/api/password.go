@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// PasswordExpiredError is returned by Login and CompleteChallenge when the password of the user expired
+type PasswordExpiredError struct {
+	// Token is passed to ChangeExpiredPassword together with the new password
+	Token string
+}
+
+func (e *PasswordExpiredError) Error() string {
+	return "password expired"
+}
+
+// ChangeExpiredPassword replaces an expired password, after which the tokens are handed out.
+// A token can only be used once.
+func ChangeExpiredPassword(host string, token, password string) (*jwt.TokenPair, error) {
+	pcb, err := json.Marshal(&models.PasswordChange{Token: token, Password: password})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't marshal password change")
+	}
+
+	resp, err := http.Post(host+"/login/password", "application/json", bytes.NewReader(pcb))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't post password change")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return nil, errors.Errorf("Unexpected status code (%v): %v", resp.StatusCode, string(body))
+	}
+
+	return decodeLoginResponse(resp)
+}
+
+// GetPasswordPolicy gets the rules the passwords of new users have to follow
+func GetPasswordPolicy(host string) (*models.PasswordPolicy, error) {
+	resp, err := http.Get(host + "/password/policy")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get password policy")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return nil, errors.Errorf("Unexpected status code (%v): %v", resp.StatusCode, string(body))
+	}
+
+	var policy models.PasswordPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode json body")
+	}
+
+	return &policy, nil
+}
+
+// GetUserPasswordPolicy gets the rules a new password of the user has to follow,
+// which include those of the groups the user is a member of
+func GetUserPasswordPolicy(host string, tp *jwt.TokenPair) (*models.PasswordPolicy, error) {
+	req, err := http.NewRequest(http.MethodGet, host+"/password/policy/user", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy models.PasswordPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginPasswordExpired(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login", r.URL.Path)
+
+		err := json.NewEncoder(w).Encode(&models.LoginResponse{PasswordExpired: "expired"})
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	tp, err := Login(ts.URL, models.User{Username: "user", Password: "pass"})
+	assert.Nil(t, tp)
+
+	var pe *PasswordExpiredError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, "expired", pe.Token)
+	}
+}
+
+func TestChangeExpiredPassword(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login/password", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv models.PasswordChange
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, models.PasswordChange{Token: "expired", Password: "new password"}, recv)
+
+		err = json.NewEncoder(w).Encode(&tp)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	rtp, err := ChangeExpiredPassword(ts.URL, "expired", "new password")
+	assert.NoError(t, err)
+	assert.Equal(t, &tp, rtp)
+}
+
+func TestGetPasswordPolicy(t *testing.T) {
+	expected := models.PasswordPolicy{MinLength: 8, MinScore: 3, BannedWords: []string{"aurum"}}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/password/policy", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	policy, err := GetPasswordPolicy(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, policy)
+}
+
+func TestGetUserPasswordPolicy(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := models.PasswordPolicy{MinLength: 12, RequireSymbol: true, MaxAgeDays: 90}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/password/policy/user", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "Bearer "+tp.LoginToken, r.Header.Get("Authorization"))
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	policy, err := GetUserPasswordPolicy(ts.URL, &tp)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, policy)
+}
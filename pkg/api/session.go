@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+)
+
+func getSessions(tp *jwt.TokenPair, url string) ([]models.Session, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []models.Session
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func revokeSessions(tp *jwt.TokenPair, url string) error {
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = authenticatedRequest(req, tp)
+	return err
+}
+
+// GetSessions lists the sessions of the user the token belongs to
+func GetSessions(host string, tp *jwt.TokenPair) ([]models.Session, error) {
+	return getSessions(tp, host+"/sessions")
+}
+
+// RevokeSession ends one of the sessions of the user the token belongs to
+func RevokeSession(host string, tp *jwt.TokenPair, id string) error {
+	return revokeSessions(tp, host+"/sessions/"+url.PathEscape(id))
+}
+
+// RevokeOtherSessions ends all sessions of the user the token belongs to, except the one of the token
+func RevokeOtherSessions(host string, tp *jwt.TokenPair) error {
+	return revokeSessions(tp, host+"/sessions")
+}
+
+// GetUserSessions lists the sessions of any user, which requires the token to belong to an admin
+func GetUserSessions(host string, tp *jwt.TokenPair, user string) ([]models.Session, error) {
+	return getSessions(tp, host+"/user/"+url.PathEscape(user)+"/sessions")
+}
+
+// RevokeUserSession ends a session of any user, which requires the token to belong to an admin
+func RevokeUserSession(host string, tp *jwt.TokenPair, user, id string) error {
+	return revokeSessions(tp, host+"/user/"+url.PathEscape(user)+"/sessions/"+url.PathEscape(id))
+}
+
+// RevokeUserSessions ends all sessions of any user, which requires the token to belong to an admin
+func RevokeUserSessions(host string, tp *jwt.TokenPair, user string) error {
+	return revokeSessions(tp, host+"/user/"+url.PathEscape(user)+"/sessions")
+}
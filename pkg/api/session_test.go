@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSessions(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	now := time.Now().Truncate(time.Second).UTC()
+	expected := []models.Session{{
+		ID:          "session",
+		Username:    "bob",
+		CreatedAt:   now,
+		LastRefresh: now,
+		ExpiresAt:   now.Add(time.Hour),
+		IP:          "192.0.2.1",
+		UserAgent:   "test",
+		Current:     true,
+	}}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sessions", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	sessions, err := GetSessions(ts.URL, &tp)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sessions)
+}
+
+func TestRevokeSession(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sessions/session", r.URL.Path)
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := RevokeSession(ts.URL, &tp, "session")
+	assert.NoError(t, err)
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sessions", r.URL.Path)
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := RevokeOtherSessions(ts.URL, &tp)
+	assert.NoError(t, err)
+}
+
+func TestGetUserSessions(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := []models.Session{{ID: "session", Username: "bob"}}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob/sessions", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	sessions, err := GetUserSessions(ts.URL, &tp, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sessions)
+}
+
+func TestRevokeUserSession(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob/sessions/session", r.URL.Path)
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := RevokeUserSession(ts.URL, &tp, "bob", "session")
+	assert.NoError(t, err)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob/sessions", r.URL.Path)
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := RevokeUserSessions(ts.URL, &tp, "bob")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// ChallengeError is returned by Login when the user has to supply a second factor
+type ChallengeError struct {
+	// Challenge is completed by passing it to CompleteChallenge together with a code
+	Challenge string
+}
+
+func (e *ChallengeError) Error() string {
+	return "second factor required"
+}
+
+// CompleteChallenge finishes a login which requires a second factor, with a code from
+// an authenticator app or a recovery code. When the password expired a *PasswordExpiredError
+// is returned, like Login does. A challenge can only be attempted once.
+func CompleteChallenge(host string, challenge, code string) (*jwt.TokenPair, error) {
+	crb, err := json.Marshal(&models.ChallengeResponse{Challenge: challenge, Code: code})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't marshal challenge response")
+	}
+
+	resp, err := http.Post(host+"/login/challenge", "application/json", bytes.NewReader(crb))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't post challenge response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return nil, errors.Errorf("Unexpected status code (%v): %v", resp.StatusCode, string(body))
+	}
+
+	return decodeLoginResponse(resp)
+}
+
+// EnrollTOTP starts setting up TOTP for the user the token belongs to
+func EnrollTOTP(host string, tp *jwt.TokenPair) (*models.TOTPEnrollment, error) {
+	req, err := http.NewRequest(http.MethodPost, host+"/totp", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var enrollment models.TOTPEnrollment
+	if err := json.NewDecoder(resp.Body).Decode(&enrollment); err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+func postCode(tp *jwt.TokenPair, url, code string) (*http.Response, error) {
+	codeb, err := json.Marshal(&models.TOTPCode{Code: code})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling json")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(codeb))
+	if err != nil {
+		return nil, err
+	}
+
+	return authenticatedRequest(req, tp)
+}
+
+// ConfirmTOTP enables TOTP with the first code from the authenticator, returning the recovery codes
+func ConfirmTOTP(host string, tp *jwt.TokenPair, code string) (*models.RecoveryCodes, error) {
+	resp, err := postCode(tp, host+"/totp/confirm", code)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes models.RecoveryCodes
+	if err := json.NewDecoder(resp.Body).Decode(&codes); err != nil {
+		return nil, err
+	}
+
+	return &codes, nil
+}
+
+// DisableTOTP turns off TOTP, which requires a code or a recovery code once it's been confirmed
+func DisableTOTP(host string, tp *jwt.TokenPair, code string) error {
+	_, err := postCode(tp, host+"/totp/disable", code)
+	return err
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginChallenge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login", r.URL.Path)
+
+		err := json.NewEncoder(w).Encode(&models.LoginResponse{Challenge: "challenge"})
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	tp, err := Login(ts.URL, models.User{Username: "user", Password: "pass"})
+	assert.Nil(t, tp)
+
+	var ce *ChallengeError
+	if assert.True(t, errors.As(err, &ce)) {
+		assert.Equal(t, "challenge", ce.Challenge)
+	}
+}
+
+func TestCompleteChallenge(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login/challenge", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv models.ChallengeResponse
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChallengeResponse{Challenge: "challenge", Code: "123456"}, recv)
+
+		err = json.NewEncoder(w).Encode(&models.LoginResponse{LoginToken: tp.LoginToken, RefreshToken: tp.RefreshToken})
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	rtp, err := CompleteChallenge(ts.URL, "challenge", "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, &tp, rtp)
+}
+
+func TestCompleteChallengePasswordExpired(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewEncoder(w).Encode(&models.LoginResponse{PasswordExpired: "token"})
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	tp, err := CompleteChallenge(ts.URL, "challenge", "123456")
+	assert.Nil(t, tp)
+
+	var pe *PasswordExpiredError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, "token", pe.Token)
+	}
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := models.TOTPEnrollment{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/Aurum:user?secret=JBSWY3DPEHPK3PXP",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/totp", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer "+tp.LoginToken, r.Header.Get("Authorization"))
+
+		w.WriteHeader(http.StatusCreated)
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	enrollment, err := EnrollTOTP(ts.URL, &tp)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, enrollment)
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := models.RecoveryCodes{Codes: []string{"abcde-fghij"}}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/totp/confirm", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer "+tp.LoginToken, r.Header.Get("Authorization"))
+
+		var recv models.TOTPCode
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, "123456", recv.Code)
+
+		err = json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	codes, err := ConfirmTOTP(ts.URL, &tp, "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, codes)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/totp/disable", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv models.TOTPCode
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, "abcde-fghij", recv.Code)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := DisableTOTP(ts.URL, &tp, "abcde-fghij")
+	assert.NoError(t, err)
+}
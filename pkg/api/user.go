@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	"github.com/finitum/aurum/pkg/jwt"
 	"github.com/finitum/aurum/pkg/models"
@@ -31,6 +32,9 @@ func SignUp(host string, user models.User) error {
 	return nil
 }
 
+// Login logs a user in. When the user has to supply a second factor a *ChallengeError
+// is returned, after which the login is completed by CompleteChallenge. Likewise, when the
+// password expired a *PasswordExpiredError is returned, see ChangeExpiredPassword.
 func Login(host string, user models.User) (*jwt.TokenPair, error) {
 	userb, err := json.Marshal(&user)
 	if err != nil {
@@ -42,18 +46,37 @@ func Login(host string, user models.User) (*jwt.TokenPair, error) {
 		return nil, errors.Wrap(err, "couldn't post login request")
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if resp.Header.Get("Retry-After") != "" {
+			return nil, ErrRateLimited
+		}
+		return nil, ErrLockedOut
+	} else if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
 
 		return nil, errors.Errorf("Unexpected status code (%v): %v", resp.StatusCode, string(body))
 	}
 
-	var tp jwt.TokenPair
-	if err := json.NewDecoder(resp.Body).Decode(&tp); err != nil {
+	return decodeLoginResponse(resp)
+}
+
+// decodeLoginResponse reads the tokens from a login response, or the error
+// telling what has to happen before they are handed out
+func decodeLoginResponse(resp *http.Response) (*jwt.TokenPair, error) {
+	var lr models.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
 		return nil, errors.Wrap(err, "couldn't decode json body")
 	}
 
-	return &tp, err
+	if lr.PasswordExpired != "" {
+		return nil, &PasswordExpiredError{Token: lr.PasswordExpired}
+	}
+
+	if lr.Challenge != "" {
+		return nil, &ChallengeError{Challenge: lr.Challenge}
+	}
+
+	return &jwt.TokenPair{LoginToken: lr.LoginToken, RefreshToken: lr.RefreshToken}, nil
 }
 
 func Refresh(host string, tp *jwt.TokenPair) error {
@@ -79,9 +102,136 @@ func Refresh(host string, tp *jwt.TokenPair) error {
 	}
 
 	tp.LoginToken = newtp.LoginToken
+	// Refresh tokens are rotated, the old one can't be used anymore
+	if newtp.RefreshToken != "" {
+		tp.RefreshToken = newtp.RefreshToken
+	}
+
+	return nil
+}
+
+// Logout revokes the refresh token, and the login token when it's still valid
+func Logout(host string, tp *jwt.TokenPair) error {
+	tpb, err := json.Marshal(tp)
+	if err != nil {
+		return errors.Wrap(err, "couldn't marshal token")
+	}
+
+	resp, err := http.Post(host+"/logout", "application/json", bytes.NewReader(tpb))
+	if err != nil {
+		return errors.Wrap(err, "couldn't post logout request")
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return errors.Errorf("Unexpected status code (%v), (%v)", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// ForgotPassword asks Aurum to mail a password reset token to the user
+func ForgotPassword(host string, username string) error {
+	pfb, err := json.Marshal(&models.PasswordForgot{Username: username})
+	if err != nil {
+		return errors.Wrap(err, "couldn't marshal request")
+	}
+
+	resp, err := http.Post(host+"/password/forgot", "application/json", bytes.NewReader(pfb))
+	if err != nil {
+		return errors.Wrap(err, "couldn't post forgot password request")
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return errors.Errorf("Unexpected status code (%v), (%v)", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with the token from a password reset mail
+func ResetPassword(host string, token, password string) error {
+	prb, err := json.Marshal(&models.PasswordReset{Token: token, Password: password})
+	if err != nil {
+		return errors.Wrap(err, "couldn't marshal request")
+	}
+
+	resp, err := http.Post(host+"/password/reset", "application/json", bytes.NewReader(prb))
+	if err != nil {
+		return errors.Wrap(err, "couldn't post reset password request")
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return errors.Errorf("Unexpected status code (%v), (%v)", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// VerifyEmail confirms an email address with the token from a verification mail
+func VerifyEmail(host string, token string) error {
+	evb, err := json.Marshal(&models.EmailVerification{Token: token})
+	if err != nil {
+		return errors.Wrap(err, "couldn't marshal request")
+	}
+
+	resp, err := http.Post(host+"/email/verify", "application/json", bytes.NewReader(evb))
+	if err != nil {
+		return errors.Wrap(err, "couldn't post email verification request")
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return errors.Errorf("Unexpected status code (%v), (%v)", resp.StatusCode, string(body))
+	}
+
 	return nil
 }
 
+// ResendVerification has Aurum mail a new verification link for the pending or unverified email address
+func ResendVerification(host string, tp *jwt.TokenPair) error {
+	req, err := http.NewRequest(http.MethodPost, host+"/email/verify/resend", nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = authenticatedRequest(req, tp)
+	return err
+}
+
+// IsTokenRevoked asks whether the token with the given id (its jti claim), or the token family
+// it belongs to, has been revoked. The family may be empty.
+func IsTokenRevoked(host string, id, family string) (bool, error) {
+	u := host + "/revoked/" + url.PathEscape(id)
+	if family != "" {
+		u += "?" + url.Values{"family": {family}}.Encode()
+	}
+
+	resp, err := http.Get(u)
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't get revocation status")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		return false, errors.Errorf("Unexpected status code (%v), (%v)", resp.StatusCode, string(body))
+	}
+
+	var status models.RevocationStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return false, errors.Wrap(err, "couldn't decode json body")
+	}
+
+	return status.Revoked, nil
+}
+
 func GetUser(host string, tp *jwt.TokenPair) (*models.User, error) {
 	req, err := http.NewRequest(http.MethodGet, host+"/user", nil)
 	if err != nil {
@@ -119,3 +269,84 @@ func UpdateUser(host string, tp *jwt.TokenPair, user *models.User) (ret *models.
 
 	return ret, errors.Wrap(json.NewDecoder(resp.Body).Decode(&ret), "json decoding response")
 }
+
+// GetUsers lists a page of users, which requires the token to belong to an admin
+func GetUsers(host string, tp *jwt.TokenPair, query models.UserQuery) (*models.UserPage, error) {
+	req, err := http.NewRequest(http.MethodGet, host+"/users?"+query.Values().Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var page models.UserPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// LookupUser retrieves any user by name, which requires the token to belong to an admin
+func LookupUser(host string, tp *jwt.TokenPair, user string) (*models.User, error) {
+	req, err := http.NewRequest(http.MethodGet, host+"/user/"+url.PathEscape(user), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var u models.User
+	if err := json.NewDecoder(resp.Body).Decode(&u); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// AdminUpdateUser resets the password and/or changes the email of the user named in user.Username,
+// which requires the token to belong to an admin
+func AdminUpdateUser(host string, tp *jwt.TokenPair, user *models.User) (ret *models.User, _ error) {
+	userb, err := json.Marshal(user)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling json")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, host+"/user/"+url.PathEscape(user.Username), bytes.NewReader(userb))
+	if err != nil {
+		return nil, errors.Wrap(err, "building admin update user request")
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, errors.Wrap(err, "admin update user")
+	}
+
+	return ret, errors.Wrap(json.NewDecoder(resp.Body).Decode(&ret), "json decoding response")
+}
+
+// RemoveUser deletes an account and its memberships, which requires the token to belong to an admin
+func RemoveUser(host string, tp *jwt.TokenPair, user string) (*models.RemovalSummary, error) {
+	req, err := http.NewRequest(http.MethodDelete, host+"/user/"+url.PathEscape(user), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := authenticatedRequest(req, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary models.RemovalSummary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
@@ -67,6 +67,24 @@ func TestLogin(t *testing.T) {
 	assert.Equal(t, &tp, rtp)
 }
 
+func TestLoginTooManyRequests(t *testing.T) {
+	retryAfter := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	_, err := Login(ts.URL, models.User{Username: "user", Password: "pass"})
+	assert.Equal(t, ErrLockedOut, err)
+
+	retryAfter = "6"
+	_, err = Login(ts.URL, models.User{Username: "user", Password: "pass"})
+	assert.Equal(t, ErrRateLimited, err)
+}
+
 func TestRefresh(t *testing.T) {
 	tp := jwt.TokenPair{
 		LoginToken:   "login",
@@ -74,7 +92,8 @@ func TestRefresh(t *testing.T) {
 	}
 
 	tp2 := jwt.TokenPair{
-		LoginToken: "login2",
+		LoginToken:   "login2",
+		RefreshToken: "refresh2",
 	}
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -95,8 +114,119 @@ func TestRefresh(t *testing.T) {
 	err := Refresh(ts.URL, &tp)
 	assert.NoError(t, err)
 
-	assert.Equal(t, tp2.LoginToken, tp.LoginToken)
-	assert.Equal(t, "refresh", tp.RefreshToken)
+	assert.Equal(t, tp2, tp)
+}
+
+func TestLogout(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/logout", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv jwt.TokenPair
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, tp, recv)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := Logout(ts.URL, &tp)
+	assert.NoError(t, err)
+}
+
+func TestForgotPassword(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/password/forgot", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv models.PasswordForgot
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, "user", recv.Username)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	err := ForgotPassword(ts.URL, "user")
+	assert.NoError(t, err)
+}
+
+func TestResetPassword(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/password/reset", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv models.PasswordReset
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, models.PasswordReset{Token: "token", Password: "pass"}, recv)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := ResetPassword(ts.URL, "token", "pass")
+	assert.NoError(t, err)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/email/verify", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var recv models.EmailVerification
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, "token", recv.Token)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	err := VerifyEmail(ts.URL, "token")
+	assert.NoError(t, err)
+}
+
+func TestResendVerification(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/email/verify/resend", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer "+tp.LoginToken, r.Header.Get("Authorization"))
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	err := ResendVerification(ts.URL, &tp)
+	assert.NoError(t, err)
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/revoked/some-jti", r.URL.Path)
+		assert.Equal(t, "some-family", r.URL.Query().Get("family"))
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		err := json.NewEncoder(w).Encode(&models.RevocationStatus{ID: "some-jti", Revoked: true})
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	revoked, err := IsTokenRevoked(ts.URL, "some-jti", "some-family")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
 }
 
 func TestGetUser(t *testing.T) {
@@ -291,3 +421,137 @@ func TestGetUserRefreshNeeded(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &u, user)
 }
+
+func TestGetUsers(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	query := models.UserQuery{
+		Search:     "bob",
+		Group:      "aurum",
+		Role:       models.RoleAdmin,
+		Sort:       models.SortEmail,
+		Descending: true,
+		Cursor:     "cursor",
+		Limit:      10,
+	}
+
+	expected := models.UserPage{
+		Users:      []models.User{{Username: "bob", Email: "bob@example.com"}},
+		NextCursor: "next",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		recv, err := models.ParseUserQuery(r.URL.Query())
+		assert.NoError(t, err)
+		assert.Equal(t, query, recv)
+
+		err = json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	page, err := GetUsers(ts.URL, &tp, query)
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, page)
+}
+
+func TestLookupUser(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	u := models.User{
+		Username: "bob",
+		Email:    "bob@example.com",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		err := json.NewEncoder(w).Encode(&u)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	user, err := LookupUser(ts.URL, &tp, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, &u, user)
+}
+
+func TestAdminUpdateUser(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	u := models.User{
+		Username: "bob",
+		Password: "pass",
+		Email:    "bob@example.com",
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		var recv models.User
+		err := json.NewDecoder(r.Body).Decode(&recv)
+		assert.NoError(t, err)
+		assert.Equal(t, u, recv)
+
+		err = json.NewEncoder(w).Encode(&models.User{Username: u.Username, Email: u.Email})
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	user, err := AdminUpdateUser(ts.URL, &tp, &u)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.User{Username: u.Username, Email: u.Email}, user)
+}
+
+func TestRemoveUser(t *testing.T) {
+	tp := jwt.TokenPair{
+		LoginToken:   "login",
+		RefreshToken: "refresh",
+	}
+
+	expected := models.RemovalSummary{
+		Users: []string{"bob"},
+		Memberships: []models.Membership{
+			{Username: "bob", GroupName: "aurum", Role: models.RoleUser},
+		},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/bob", r.URL.Path)
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		token := r.Header.Get("Authorization")
+		assert.Equal(t, "Bearer "+tp.LoginToken, token)
+
+		err := json.NewEncoder(w).Encode(&expected)
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	summary, err := RemoveUser(ts.URL, &tp, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, summary)
+}
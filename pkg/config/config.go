@@ -1,7 +1,11 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/finitum/aurum/pkg/jwt/ecc"
+	"github.com/finitum/aurum/pkg/models"
 	log "github.com/sirupsen/logrus"
 	"go.deanishe.net/env"
 )
@@ -21,9 +25,95 @@ type EnvConfig struct {
 	PublicKeyPath string `env:"PUBLIC_KEY_PATH"`
 	SecretKeyPath string `env:"SECRET_KEY_PATH"`
 
-	DgraphUrl string `env:"DGRAPH_URL"`
+	// Store selects the database backend, one of "dgraph", "postgres", "bolt" or "memory"
+	Store       string `env:"STORE"`
+	DgraphUrl   string `env:"DGRAPH_URL"`
+	PostgresUrl string `env:"POSTGRES_URL"`
+	BoltPath    string `env:"BOLT_PATH"`
+
+	// NoMigrate disables migrating the Dgraph schema at startup, run `aurum migrate` instead
+	NoMigrate bool `env:"NO_MIGRATE"`
 
 	AdminPassword string `env:"ADMIN_PASSWORD"`
+
+	// RevocationGCInterval is how often revocations of tokens, and sessions, which have expired are removed
+	RevocationGCInterval time.Duration `env:"REVOCATION_GC_INTERVAL"`
+
+	// Mailer selects how mail is delivered, either "smtp" or "log" to append it to MailLogPath
+	Mailer       string `env:"MAILER"`
+	MailFrom     string `env:"MAIL_FROM"`
+	MailLogPath  string `env:"MAIL_LOG_PATH"`
+	SMTPAddr     string `env:"SMTP_ADDRESS"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+
+	// PasswordResetURL is the page password reset mails link to, with the token in its query.
+	// Without it the mails contain just the token.
+	PasswordResetURL string `env:"PASSWORD_RESET_URL"`
+	// PasswordResetCooldown is how long after a password reset mail no other is sent to the same user
+	PasswordResetCooldown time.Duration `env:"PASSWORD_RESET_COOLDOWN"`
+
+	// EmailVerificationURL is the page email verification mails link to, with the token in its query.
+	// Without it the mails contain just the token.
+	EmailVerificationURL string `env:"EMAIL_VERIFICATION_URL"`
+
+	// LockoutAccountThreshold and LockoutAddressThreshold are the numbers of failed logins after
+	// which an account or a source address is locked, zero disables locking it
+	LockoutAccountThreshold int `env:"LOCKOUT_ACCOUNT_THRESHOLD"`
+	LockoutAddressThreshold int `env:"LOCKOUT_ADDRESS_THRESHOLD"`
+	// LockoutDuration is how long the first lock lasts, every further failure doubles it up to LockoutMaxDuration
+	LockoutDuration    time.Duration `env:"LOCKOUT_DURATION"`
+	LockoutMaxDuration time.Duration `env:"LOCKOUT_MAX_DURATION"`
+	// LockoutResetAfter is how long after the last failed login the failures are forgotten
+	LockoutResetAfter time.Duration `env:"LOCKOUT_RESET_AFTER"`
+
+	// PasswordHash is the algorithm new password hashes are made with, "argon2id" or "bcrypt".
+	// Hashes made with another algorithm or other parameters are upgraded when their user logs in.
+	PasswordHash string `env:"PASSWORD_HASH"`
+	// Argon2Memory (in KiB), Argon2Time and Argon2Parallelism are the parameters of argon2id
+	Argon2Memory      int `env:"ARGON2_MEMORY"`
+	Argon2Time        int `env:"ARGON2_TIME"`
+	Argon2Parallelism int `env:"ARGON2_PARALLELISM"`
+	BcryptCost        int `env:"BCRYPT_COST"`
+
+	// Pepper lists secrets mixed into every password hash, as <version>:<base64 encoded pepper> separated by
+	// commas or newlines. New hashes use the highest version, older versions can still be verified until they
+	// are removed. Without Pepper the peppers are read from PepperPath, which must exist unless it is the default.
+	Pepper     string `env:"PEPPER"`
+	PepperPath string `env:"PEPPER_PATH"`
+
+	// The password policy, which groups can make stricter for their members. Lengths are in characters, a
+	// maximum length of 0 means there is none. The minimum score is the zxcvbn score from 0 to 4. Banned words
+	// are separated by commas. The history is the number of previous passwords which can't be used again,
+	// and the maximum age the number of days after which a password has to be changed, 0 means never.
+	PasswordMinLength     int    `env:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength     int    `env:"PASSWORD_MAX_LENGTH"`
+	PasswordMinScore      int    `env:"PASSWORD_MIN_SCORE"`
+	PasswordRequireLower  bool   `env:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireUpper  bool   `env:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireDigit  bool   `env:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol bool   `env:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordBannedWords   string `env:"PASSWORD_BANNED_WORDS"`
+	PasswordHistory       int    `env:"PASSWORD_HISTORY"`
+	PasswordMaxAgeDays    int    `env:"PASSWORD_MAX_AGE_DAYS"`
+
+	// BreachedPasswordsPath is a list of passwords which appeared in data breaches, which can't be used.
+	// Convert the Pwned Passwords list "ordered by hash" to such a list with `aurum convert-breached`.
+	BreachedPasswordsPath string `env:"BREACHED_PASSWORDS_PATH"`
+
+	// The rate limits are the numbers of requests allowed per minute, zero disables a limit.
+	// RateLimitIP and RateLimitUser apply to all requests of a client address or user, the others
+	// per client address to POST /login, POST /signup, POST /refresh, POST /password/forgot and
+	// GET /group/{group}/{user}.
+	RateLimitIP      int `env:"RATE_LIMIT_IP"`
+	RateLimitUser    int `env:"RATE_LIMIT_USER"`
+	RateLimitLogin   int `env:"RATE_LIMIT_LOGIN"`
+	RateLimitSignUp  int `env:"RATE_LIMIT_SIGNUP"`
+	RateLimitRefresh int `env:"RATE_LIMIT_REFRESH"`
+	RateLimitForgot  int `env:"RATE_LIMIT_FORGOT"`
+	RateLimitAccess  int `env:"RATE_LIMIT_ACCESS"`
+	// RateLimitShared keeps the rate limits in the store, so they are shared by all replicas using it
+	RateLimitShared bool `env:"RATE_LIMIT_SHARED"`
 }
 
 type Config struct {
@@ -33,8 +123,50 @@ type Config struct {
 	PublicKey ecc.PublicKey
 	SecretKey ecc.SecretKey
 
-	DgraphUrl string
+	Store         string
+	DgraphUrl     string
+	PostgresUrl   string
+	BoltPath      string
+	NoMigrate     bool
 	AdminPassword string
+
+	RevocationGCInterval time.Duration
+
+	Mailer       string
+	MailFrom     string
+	MailLogPath  string
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	PasswordResetURL      string
+	PasswordResetCooldown time.Duration
+	EmailVerificationURL  string
+
+	LockoutAccountThreshold int
+	LockoutAddressThreshold int
+	LockoutDuration         time.Duration
+	LockoutMaxDuration      time.Duration
+	LockoutResetAfter       time.Duration
+
+	PasswordHash      string
+	Argon2Memory      int
+	Argon2Time        int
+	Argon2Parallelism int
+	BcryptCost        int
+	Peppers           map[int][]byte
+
+	PasswordPolicy        models.PasswordPolicy
+	BreachedPasswordsPath string
+
+	RateLimitIP      int
+	RateLimitUser    int
+	RateLimitLogin   int
+	RateLimitSignUp  int
+	RateLimitRefresh int
+	RateLimitForgot  int
+	RateLimitAccess  int
+	RateLimitShared  bool
 }
 
 func defaultEnvConfig() EnvConfig {
@@ -43,12 +175,50 @@ func defaultEnvConfig() EnvConfig {
 		BasePath:      "/",
 		PublicKeyPath: "./id_25519.pub",
 		SecretKeyPath: "./id_25519",
+		PepperPath:    defaultPepperPath,
 		SecretKey:     "",
 		PublicKey:     "",
 		NoKeyGen:      false,
 		NoKeyWrite:    false,
+		Store:         "dgraph",
 		DgraphUrl:     "localhost:9080",
+		PostgresUrl:   "postgres://localhost:5432/aurum?sslmode=disable",
+		BoltPath:      "./aurum.db",
+		NoMigrate:     false,
 		AdminPassword: "",
+
+		RevocationGCInterval: time.Hour,
+
+		Mailer:      "log",
+		MailFrom:    "aurum@localhost",
+		MailLogPath: "./mail.log",
+		SMTPAddr:    "localhost:25",
+
+		PasswordResetCooldown: 5 * time.Minute,
+
+		LockoutAccountThreshold: 5,
+		LockoutAddressThreshold: 20,
+		LockoutDuration:         time.Minute,
+		LockoutMaxDuration:      time.Hour,
+		LockoutResetAfter:       24 * time.Hour,
+
+		PasswordHash:      "argon2id",
+		Argon2Memory:      64 * 1024,
+		Argon2Time:        3,
+		Argon2Parallelism: 4,
+		BcryptCost:        10,
+
+		PasswordMinLength:   8,
+		PasswordMinScore:    3,
+		PasswordBannedWords: "aurum,finitum",
+
+		RateLimitIP:      600,
+		RateLimitUser:    300,
+		RateLimitLogin:   10,
+		RateLimitSignUp:  5,
+		RateLimitRefresh: 60,
+		RateLimitForgot:  5,
+		RateLimitAccess:  600,
 	}
 }
 
@@ -70,14 +240,61 @@ func GetConfig(e ...env.Env) *Config {
 		log.Fatal(err.Error())
 	}
 
+	peppers, err := loadPeppers(ec.Pepper, ec.PepperPath)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
 	return &Config{
 		WebAddr:   ec.WebAddr,
 		BasePath:  ec.BasePath,
 		PublicKey: pk,
 		SecretKey: sk,
 
-		DgraphUrl: ec.DgraphUrl,
+		Store:         ec.Store,
+		DgraphUrl:     ec.DgraphUrl,
+		PostgresUrl:   ec.PostgresUrl,
+		BoltPath:      ec.BoltPath,
+		NoMigrate:     ec.NoMigrate,
 		AdminPassword: ec.AdminPassword,
+
+		RevocationGCInterval: ec.RevocationGCInterval,
+
+		Mailer:       ec.Mailer,
+		MailFrom:     ec.MailFrom,
+		MailLogPath:  ec.MailLogPath,
+		SMTPAddr:     ec.SMTPAddr,
+		SMTPUsername: ec.SMTPUsername,
+		SMTPPassword: ec.SMTPPassword,
+
+		PasswordResetURL:      ec.PasswordResetURL,
+		PasswordResetCooldown: ec.PasswordResetCooldown,
+		EmailVerificationURL:  ec.EmailVerificationURL,
+
+		LockoutAccountThreshold: ec.LockoutAccountThreshold,
+		LockoutAddressThreshold: ec.LockoutAddressThreshold,
+		LockoutDuration:         ec.LockoutDuration,
+		LockoutMaxDuration:      ec.LockoutMaxDuration,
+		LockoutResetAfter:       ec.LockoutResetAfter,
+
+		PasswordHash:      ec.PasswordHash,
+		Argon2Memory:      ec.Argon2Memory,
+		Argon2Time:        ec.Argon2Time,
+		Argon2Parallelism: ec.Argon2Parallelism,
+		BcryptCost:        ec.BcryptCost,
+		Peppers:           peppers,
+
+		PasswordPolicy:        passwordPolicy(ec),
+		BreachedPasswordsPath: ec.BreachedPasswordsPath,
+
+		RateLimitIP:      ec.RateLimitIP,
+		RateLimitUser:    ec.RateLimitUser,
+		RateLimitLogin:   ec.RateLimitLogin,
+		RateLimitSignUp:  ec.RateLimitSignUp,
+		RateLimitRefresh: ec.RateLimitRefresh,
+		RateLimitForgot:  ec.RateLimitForgot,
+		RateLimitAccess:  ec.RateLimitAccess,
+		RateLimitShared:  ec.RateLimitShared,
 	}
 }
 
@@ -96,5 +313,34 @@ func EphemeralConfig() *Config {
 		BasePath:  ec.BasePath,
 		PublicKey: pk,
 		SecretKey: sk,
+
+		Store: "memory",
+
+		RevocationGCInterval: ec.RevocationGCInterval,
+
+		PasswordPolicy: passwordPolicy(&ec),
+	}
+}
+
+// passwordPolicy reads the password policy from the config
+func passwordPolicy(ec *EnvConfig) models.PasswordPolicy {
+	var banned []string
+	for _, word := range strings.Split(ec.PasswordBannedWords, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			banned = append(banned, word)
+		}
+	}
+
+	return models.PasswordPolicy{
+		MinLength:     ec.PasswordMinLength,
+		MaxLength:     ec.PasswordMaxLength,
+		MinScore:      ec.PasswordMinScore,
+		RequireLower:  ec.PasswordRequireLower,
+		RequireUpper:  ec.PasswordRequireUpper,
+		RequireDigit:  ec.PasswordRequireDigit,
+		RequireSymbol: ec.PasswordRequireSymbol,
+		BannedWords:   banned,
+		HistoryDepth:  ec.PasswordHistory,
+		MaxAgeDays:    ec.PasswordMaxAgeDays,
 	}
 }
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// minPepperLength is the minimum length in bytes of a pepper
+	minPepperLength = 16
+
+	// defaultPepperPath is where peppers are read from when PEPPER_PATH isn't set
+	defaultPepperPath = "./pepper"
+)
+
+// loadPeppers reads the password peppers from the environment, or else from the file at peppersPath.
+// Without either passwords aren't peppered. The file may only be missing when peppersPath is the default,
+// as a path which was configured on purpose but can't be found is a mistake which would silently stop
+// peppering new hashes.
+func loadPeppers(peppers, peppersPath string) (map[int][]byte, error) {
+	if peppers == "" {
+		b, err := ioutil.ReadFile(peppersPath)
+		if os.IsNotExist(err) && peppersPath == defaultPepperPath {
+			log.Warnf("Couldn't find peppers in environment or at %v, passwords won't be peppered", peppersPath)
+			return nil, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "couldn't read peppers")
+		}
+
+		peppers = string(b)
+	}
+
+	return parsePeppers(peppers)
+}
+
+// parsePeppers parses peppers separated by commas or newlines, as <version>:<base64 encoded pepper>
+func parsePeppers(s string) (map[int][]byte, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+
+	peppers := make(map[int][]byte)
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("peppers must look like <version>:<base64 encoded pepper>")
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || version <= 0 {
+			return nil, errors.Errorf("invalid pepper version %q", parts[0])
+		}
+
+		pepper, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pepper with version %d", version)
+		} else if len(pepper) < minPepperLength {
+			return nil, errors.Errorf("pepper with version %d is shorter than %d bytes", version, minPepperLength)
+		}
+
+		if _, ok := peppers[version]; ok {
+			return nil, errors.Errorf("duplicate pepper version %d", version)
+		}
+		peppers[version] = pepper
+	}
+
+	if len(peppers) == 0 {
+		return nil, nil
+	}
+
+	return peppers, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pepperOne = "MDEyMzQ1Njc4OWFiY2RlZg==" // 0123456789abcdef
+	pepperTwo = "ZmVkY2JhOTg3NjU0MzIxMA==" // fedcba9876543210
+)
+
+func TestParsePeppers(t *testing.T) {
+	peppers, err := parsePeppers("1:" + pepperOne + ", 2:" + pepperTwo + "\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int][]byte{
+		1: []byte("0123456789abcdef"),
+		2: []byte("fedcba9876543210"),
+	}, peppers)
+}
+
+func TestParsePeppersEmpty(t *testing.T) {
+	peppers, err := parsePeppers(" \n")
+	assert.NoError(t, err)
+	assert.Nil(t, peppers)
+}
+
+func TestParsePeppersInvalid(t *testing.T) {
+	for _, s := range []string{
+		pepperOne,
+		"0:" + pepperOne,
+		"x:" + pepperOne,
+		"1:not base64",
+		"1:c2hvcnQ=",
+		"1:" + pepperOne + ",1:" + pepperTwo,
+	} {
+		_, err := parsePeppers(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestLoadPeppers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aurum")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "pepper")
+
+	// Peppers are optional, but a configured file must exist
+	peppers, err := loadPeppers("", defaultPepperPath)
+	assert.NoError(t, err)
+	assert.Nil(t, peppers)
+
+	_, err = loadPeppers("", path)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("1:"+pepperOne+"\n"), 0600))
+
+	peppers, err = loadPeppers("", path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int][]byte{1: []byte("0123456789abcdef")}, peppers)
+
+	// The environment takes precedence
+	peppers, err = loadPeppers("2:"+pepperTwo, path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int][]byte{2: []byte("fedcba9876543210")}, peppers)
+}
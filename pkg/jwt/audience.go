@@ -0,0 +1,48 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+	"github.com/google/uuid"
+)
+
+// audienceClaims are the claims of a token meant for a single audience, which embed jwt.StandardClaims
+type audienceClaims interface {
+	jwt.Claims
+	VerifyAudience(cmp string, req bool) bool
+}
+
+// generateAudienceJWT signs claims as a token for the user, meant only for audience and valid for expiry.
+// standard are the standard claims embedded in claims, which are filled in.
+func generateAudienceJWT(claims jwt.Claims, standard *jwt.StandardClaims, audience, username string, expiry time.Duration, key ecc.SecretKey) (string, error) {
+	now := time.Now()
+
+	*standard = jwt.StandardClaims{
+		Audience:  audience,
+		Subject:   username,
+		ExpiresAt: now.Add(expiry).Unix(),
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		Id:        uuid.New().String(),
+	}
+
+	token := jwt.NewWithClaims(&ecc.SigningMethodEdDSA{}, claims)
+
+	return token.SignedString(key)
+}
+
+// verifyAudienceJWT verifies token and parses it into claims, and checks that it's meant for audience
+func verifyAudienceJWT(token string, claims audienceClaims, audience string, key ecc.PublicKey) error {
+	if err := parse(token, claims, key); err != nil {
+		return err
+	}
+
+	if !claims.VerifyAudience(audience, true) {
+		return fmt.Errorf("not a token for %v", audience)
+	}
+
+	return nil
+}
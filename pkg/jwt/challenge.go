@@ -0,0 +1,44 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+)
+
+// challengeAudience is the audience of challenge tokens. VerifyJWT rejects tokens for
+// this audience, so a challenge token can never be used in place of a login token.
+const challengeAudience = "aurum-challenge"
+
+// ChallengeExpiry is how long a second factor can be supplied after the password was
+const ChallengeExpiry = 5 * time.Minute
+
+// ErrChallengeToken is returned by VerifyJWT when it's given a challenge token
+var ErrChallengeToken = errors.New("challenge tokens can't be used for authentication")
+
+// ChallengeClaims are the claims of a challenge token, which is handed out instead of a token pair
+// when the password of a user was correct, but a second factor still has to be supplied.
+// The subject is the username.
+type ChallengeClaims struct {
+	jwt.StandardClaims
+}
+
+// GenerateChallengeJWT generates a challenge token for the user
+func GenerateChallengeJWT(username string, key ecc.SecretKey) (string, error) {
+	claims := &ChallengeClaims{}
+
+	return generateAudienceJWT(claims, &claims.StandardClaims, challengeAudience, username, ChallengeExpiry, key)
+}
+
+// VerifyChallengeJWT verifies a challenge token, and checks that it is one
+func VerifyChallengeJWT(token string, key ecc.PublicKey) (*ChallengeClaims, error) {
+	claims := &ChallengeClaims{}
+
+	if err := verifyAudienceJWT(token, claims, challengeAudience, key); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
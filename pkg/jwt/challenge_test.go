@@ -0,0 +1,36 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/finitum/aurum/pkg/config"
+	tassert "github.com/stretchr/testify/assert"
+)
+
+func TestChallengeToken(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GenerateChallengeJWT("User", cfg.SecretKey)
+	assert.Nil(err)
+
+	claims, err := VerifyChallengeJWT(token, cfg.PublicKey)
+	assert.Nil(err)
+	assert.Equal("User", claims.Subject)
+	assert.NotEmpty(claims.Id)
+
+	// Challenge tokens are no login tokens
+	_, err = VerifyJWT(token, cfg.PublicKey)
+	assert.Equal(ErrChallengeToken, err)
+}
+
+func TestLoginTokenIsNoChallenge(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GenerateJWT("User", false, cfg.SecretKey)
+	assert.Nil(err)
+
+	_, err = VerifyChallengeJWT(token, cfg.PublicKey)
+	assert.NotNil(err)
+}
@@ -12,6 +12,9 @@ import (
 type Claims struct {
 	Username string
 	Refresh  bool
+	// Family identifies all tokens handed out since a single login. Refreshing replaces
+	// the refresh token with a new one of the same family.
+	Family string
 	jwt.StandardClaims
 }
 
@@ -20,12 +23,23 @@ type TokenPair struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
+// RefreshTokenExpiry is the time at which a refresh token issued at the given time expires
+func RefreshTokenExpiry(issued time.Time) time.Time {
+	return issued.AddDate(0, 3, 0)
+}
+
+// GenerateJWT generates a token which starts a new token family
 func GenerateJWT(username string, refresh bool, key ecc.SecretKey) (string, error) {
+	return GenerateFamilyJWT(username, refresh, uuid.New().String(), key)
+}
+
+// GenerateFamilyJWT generates a token which belongs to the given token family
+func GenerateFamilyJWT(username string, refresh bool, family string, key ecc.SecretKey) (string, error) {
 	// expirationTime := time.Now().Add(time.Hour)
 	var expirationTime time.Time
 
 	if refresh {
-		expirationTime = time.Now().AddDate(0, 3, 0)
+		expirationTime = RefreshTokenExpiry(time.Now())
 	} else {
 		expirationTime = time.Now().Add(time.Minute * 15)
 	}
@@ -35,6 +49,7 @@ func GenerateJWT(username string, refresh bool, key ecc.SecretKey) (string, erro
 	claims := &Claims{
 		Username: username,
 		Refresh:  refresh,
+		Family:   family,
 		StandardClaims: jwt.StandardClaims{
 			// In JWT, the expiry time is expressed as unix seconds
 			ExpiresAt: expirationTime.Unix(),
@@ -49,13 +64,19 @@ func GenerateJWT(username string, refresh bool, key ecc.SecretKey) (string, erro
 	return token.SignedString(key)
 }
 
+// GenerateJWTPair generates a login and refresh token which start a new token family
 func GenerateJWTPair(user string, key ecc.SecretKey) (TokenPair, error) {
-	login, err := GenerateJWT(user, false, key)
+	return GenerateFamilyJWTPair(user, uuid.New().String(), key)
+}
+
+// GenerateFamilyJWTPair generates a login and refresh token which belong to the given token family
+func GenerateFamilyJWTPair(user, family string, key ecc.SecretKey) (TokenPair, error) {
+	login, err := GenerateFamilyJWT(user, false, family, key)
 	if err != nil {
 		return TokenPair{}, err
 	}
 
-	refresh, err := GenerateJWT(user, true, key)
+	refresh, err := GenerateFamilyJWT(user, true, family, key)
 	if err != nil {
 		return TokenPair{}, err
 	}
@@ -63,17 +84,35 @@ func GenerateJWTPair(user string, key ecc.SecretKey) (TokenPair, error) {
 	return TokenPair{login, refresh}, nil
 }
 
-func VerifyJWT(token string, key ecc.PublicKey) (*Claims, error) {
-	claims := &Claims{}
-
-	if _, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+// parse verifies the signature of token, and parses it into claims
+func parse(token string, claims jwt.Claims, key ecc.PublicKey) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*ecc.SigningMethodEdDSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return key, nil
-	}); err != nil {
+	})
+
+	return err
+}
+
+func VerifyJWT(token string, key ecc.PublicKey) (*Claims, error) {
+	claims := &Claims{}
+
+	if err := parse(token, claims, key); err != nil {
 		return nil, err
 	}
 
+	switch claims.Audience {
+	case challengeAudience:
+		return nil, ErrChallengeToken
+	case resetAudience:
+		return nil, ErrResetToken
+	case verificationAudience:
+		return nil, ErrVerificationToken
+	case passwordExpiredAudience:
+		return nil, ErrPasswordExpiredToken
+	}
+
 	return claims, nil
 }
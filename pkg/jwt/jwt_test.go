@@ -78,10 +78,33 @@ func TestTokenPair(t *testing.T) {
 	assert.NotNil(claims)
 	assert.Equal(claims.Refresh, false)
 
+	family := claims.Family
+	assert.NotEmpty(family)
+
 	claims, err = VerifyJWT(tp.RefreshToken, cfg.PublicKey)
 	assert.Nil(err)
 	assert.NotNil(claims)
 	assert.Equal(claims.Refresh, true)
+	assert.Equal(family, claims.Family)
+}
+
+func TestFamilyTokenPair(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	tp, err := GenerateFamilyJWTPair("User", "family", cfg.SecretKey)
+	assert.Nil(err)
+
+	login, err := VerifyJWT(tp.LoginToken, cfg.PublicKey)
+	assert.Nil(err)
+	assert.Equal("family", login.Family)
+
+	refresh, err := VerifyJWT(tp.RefreshToken, cfg.PublicKey)
+	assert.Nil(err)
+	assert.Equal("family", refresh.Family)
+
+	// Every token still has its own id
+	assert.NotEqual(login.Id, refresh.Id)
 }
 
 func TestExpiredToken(t *testing.T) {
@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+)
+
+// passwordExpiredAudience is the audience of password expired tokens. VerifyJWT rejects tokens for
+// this audience, so a password expired token can never be used in place of a login token.
+const passwordExpiredAudience = "aurum-password-expired"
+
+// PasswordExpiredExpiry is how long an expired password can be changed after the login
+const PasswordExpiredExpiry = 10 * time.Minute
+
+// ErrPasswordExpiredToken is returned by VerifyJWT when it's given a password expired token
+var ErrPasswordExpiredToken = errors.New("password expired tokens can't be used for authentication")
+
+// PasswordExpiredClaims are the claims of a password expired token, which is handed out instead of a token pair
+// when the password of a user was correct, but has expired. The subject is the username.
+type PasswordExpiredClaims struct {
+	// Fingerprint identifies the expired password, changing the password
+	// invalidates all password expired tokens issued before.
+	Fingerprint string
+	jwt.StandardClaims
+}
+
+// GeneratePasswordExpiredJWT generates a password expired token for the user
+func GeneratePasswordExpiredJWT(username, fingerprint string, key ecc.SecretKey) (string, error) {
+	claims := &PasswordExpiredClaims{Fingerprint: fingerprint}
+
+	return generateAudienceJWT(claims, &claims.StandardClaims, passwordExpiredAudience, username, PasswordExpiredExpiry, key)
+}
+
+// VerifyPasswordExpiredJWT verifies a password expired token, and checks that it is one
+func VerifyPasswordExpiredJWT(token string, key ecc.PublicKey) (*PasswordExpiredClaims, error) {
+	claims := &PasswordExpiredClaims{}
+
+	if err := verifyAudienceJWT(token, claims, passwordExpiredAudience, key); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
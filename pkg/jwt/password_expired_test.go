@@ -0,0 +1,40 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/finitum/aurum/pkg/config"
+	tassert "github.com/stretchr/testify/assert"
+)
+
+func TestPasswordExpiredToken(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GeneratePasswordExpiredJWT("User", "fingerprint", cfg.SecretKey)
+	assert.Nil(err)
+
+	claims, err := VerifyPasswordExpiredJWT(token, cfg.PublicKey)
+	assert.Nil(err)
+	assert.Equal("User", claims.Subject)
+	assert.Equal("fingerprint", claims.Fingerprint)
+	assert.NotEmpty(claims.Id)
+
+	// Password expired tokens are no login tokens, nor reset tokens
+	_, err = VerifyJWT(token, cfg.PublicKey)
+	assert.Equal(ErrPasswordExpiredToken, err)
+
+	_, err = VerifyResetJWT(token, cfg.PublicKey)
+	assert.NotNil(err)
+}
+
+func TestResetTokenIsNoPasswordExpiredToken(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GenerateResetJWT("User", "fingerprint", cfg.SecretKey)
+	assert.Nil(err)
+
+	_, err = VerifyPasswordExpiredJWT(token, cfg.PublicKey)
+	assert.NotNil(err)
+}
@@ -0,0 +1,45 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+)
+
+// resetAudience is the audience of password reset tokens. VerifyJWT rejects tokens for
+// this audience, so a reset token can never be used in place of a login token.
+const resetAudience = "aurum-password-reset"
+
+// ResetExpiry is how long a password reset token can be used after it was mailed
+const ResetExpiry = 30 * time.Minute
+
+// ErrResetToken is returned by VerifyJWT when it's given a password reset token
+var ErrResetToken = errors.New("password reset tokens can't be used for authentication")
+
+// ResetClaims are the claims of a password reset token. The subject is the username.
+type ResetClaims struct {
+	// Fingerprint identifies the password the user had when the token was issued,
+	// changing the password invalidates all reset tokens issued before.
+	Fingerprint string
+	jwt.StandardClaims
+}
+
+// GenerateResetJWT generates a password reset token for the user
+func GenerateResetJWT(username, fingerprint string, key ecc.SecretKey) (string, error) {
+	claims := &ResetClaims{Fingerprint: fingerprint}
+
+	return generateAudienceJWT(claims, &claims.StandardClaims, resetAudience, username, ResetExpiry, key)
+}
+
+// VerifyResetJWT verifies a password reset token, and checks that it is one
+func VerifyResetJWT(token string, key ecc.PublicKey) (*ResetClaims, error) {
+	claims := &ResetClaims{}
+
+	if err := verifyAudienceJWT(token, claims, resetAudience, key); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,40 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/finitum/aurum/pkg/config"
+	tassert "github.com/stretchr/testify/assert"
+)
+
+func TestResetToken(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GenerateResetJWT("User", "fingerprint", cfg.SecretKey)
+	assert.Nil(err)
+
+	claims, err := VerifyResetJWT(token, cfg.PublicKey)
+	assert.Nil(err)
+	assert.Equal("User", claims.Subject)
+	assert.Equal("fingerprint", claims.Fingerprint)
+	assert.NotEmpty(claims.Id)
+
+	// Reset tokens are no login tokens, nor challenges
+	_, err = VerifyJWT(token, cfg.PublicKey)
+	assert.Equal(ErrResetToken, err)
+
+	_, err = VerifyChallengeJWT(token, cfg.PublicKey)
+	assert.NotNil(err)
+}
+
+func TestLoginTokenIsNoResetToken(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GenerateJWT("User", false, cfg.SecretKey)
+	assert.Nil(err)
+
+	_, err = VerifyResetJWT(token, cfg.PublicKey)
+	assert.NotNil(err)
+}
@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+)
+
+// verificationAudience is the audience of email verification tokens
+const verificationAudience = "aurum-email-verification"
+
+// VerificationExpiry is how long an email verification link stays valid
+const VerificationExpiry = 24 * time.Hour
+
+// ErrVerificationToken is returned by VerifyJWT when it's given an email verification token
+var ErrVerificationToken = errors.New("email verification tokens can't be used for authentication")
+
+// VerificationClaims are the claims of an email verification token. The subject is the username.
+type VerificationClaims struct {
+	// Email is the address the token was mailed to, and thus proves ownership of
+	Email string
+	jwt.StandardClaims
+}
+
+// GenerateVerificationJWT generates a token proving the user received mail on the given address
+func GenerateVerificationJWT(username, email string, key ecc.SecretKey) (string, error) {
+	claims := &VerificationClaims{Email: email}
+
+	return generateAudienceJWT(claims, &claims.StandardClaims, verificationAudience, username, VerificationExpiry, key)
+}
+
+// VerifyVerificationJWT verifies an email verification token, and checks that it is one
+func VerifyVerificationJWT(token string, key ecc.PublicKey) (*VerificationClaims, error) {
+	claims := &VerificationClaims{}
+
+	if err := verifyAudienceJWT(token, claims, verificationAudience, key); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,34 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/finitum/aurum/pkg/config"
+	tassert "github.com/stretchr/testify/assert"
+)
+
+func TestVerificationToken(t *testing.T) {
+	assert := tassert.New(t)
+	cfg := config.EphemeralConfig()
+
+	token, err := GenerateVerificationJWT("User", "user@example.com", cfg.SecretKey)
+	assert.Nil(err)
+
+	claims, err := VerifyVerificationJWT(token, cfg.PublicKey)
+	assert.Nil(err)
+	assert.Equal("User", claims.Subject)
+	assert.Equal("user@example.com", claims.Email)
+
+	// Verification tokens can't be used for anything else
+	_, err = VerifyJWT(token, cfg.PublicKey)
+	assert.Equal(ErrVerificationToken, err)
+
+	_, err = VerifyResetJWT(token, cfg.PublicKey)
+	assert.NotNil(err)
+
+	reset, err := GenerateResetJWT("User", "fingerprint", cfg.SecretKey)
+	assert.Nil(err)
+
+	_, err = VerifyVerificationJWT(reset, cfg.PublicKey)
+	assert.NotNil(err)
+}
@@ -0,0 +1,6 @@
+package models
+
+// EmailVerification confirms an email address with the token from a verification mail
+type EmailVerification struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// LoginFailures counts the failed logins for an account or a source address
+type LoginFailures struct {
+	// Key identifies what the failures are counted for
+	Key         string    `json:"key"`
+	Count       int       `json:"count"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// Lockout is the state of an account or source address which failed to log in.
+// Exactly one of Username and Address is set.
+type Lockout struct {
+	Username string `json:"username,omitempty"`
+	Address  string `json:"address,omitempty"`
+
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	// LockedUntil is when logins are allowed again, it's the zero time when they are allowed now
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+}
@@ -1,14 +1,107 @@
 package models
 
+import "time"
+
 type Group struct {
 	Name              string `json:"name,omitempty"`
 	AllowRegistration bool   `json:"allow_registration,omitempty"`
+
+	DisplayName string `json:"display_name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Metadata holds arbitrary information about the group, like a homepage or owning team
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// RequireTwoFactor denies access to members which haven't enabled two-factor authentication
+	RequireTwoFactor bool `json:"require_two_factor,omitempty"`
+
+	// RequireVerifiedEmail only lets users with a verified email address join the group
+	RequireVerifiedEmail bool `json:"require_verified_email,omitempty"`
+
+	// PasswordPolicy is what the group demands of the passwords of its members on top of the policy of Aurum
+	PasswordPolicy *PasswordPolicy `json:"password_policy,omitempty"`
+}
+
+// GroupUpdate changes the settings of a group. Fields which are nil are left unchanged.
+type GroupUpdate struct {
+	AllowRegistration *bool   `json:"allow_registration,omitempty"`
+	DisplayName       *string `json:"display_name,omitempty"`
+	Description       *string `json:"description,omitempty"`
+	// Metadata sets the value of the given keys, keys with an empty value are removed
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	RequireTwoFactor     *bool `json:"require_two_factor,omitempty"`
+	RequireVerifiedEmail *bool `json:"require_verified_email,omitempty"`
+
+	// PasswordPolicy replaces the password policy of the group, the zero PasswordPolicy removes it
+	PasswordPolicy *PasswordPolicy `json:"password_policy,omitempty"`
+}
+
+// Apply makes the changes of the update to group
+func (u GroupUpdate) Apply(group *Group) {
+	if u.AllowRegistration != nil {
+		group.AllowRegistration = *u.AllowRegistration
+	}
+
+	if u.DisplayName != nil {
+		group.DisplayName = *u.DisplayName
+	}
+
+	if u.Description != nil {
+		group.Description = *u.Description
+	}
+
+	if u.RequireTwoFactor != nil {
+		group.RequireTwoFactor = *u.RequireTwoFactor
+	}
+
+	if u.RequireVerifiedEmail != nil {
+		group.RequireVerifiedEmail = *u.RequireVerifiedEmail
+	}
+
+	if u.PasswordPolicy != nil {
+		if u.PasswordPolicy.IsZero() {
+			group.PasswordPolicy = nil
+		} else {
+			policy := *u.PasswordPolicy
+			group.PasswordPolicy = &policy
+		}
+	}
+
+	if len(u.Metadata) == 0 {
+		return
+	}
+
+	metadata := make(map[string]string, len(group.Metadata)+len(u.Metadata))
+	for k, v := range group.Metadata {
+		metadata[k] = v
+	}
+
+	for k, v := range u.Metadata {
+		if v == "" {
+			delete(metadata, k)
+		} else {
+			metadata[k] = v
+		}
+	}
+
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	group.Metadata = metadata
 }
 
 type User struct {
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
 	Email    string `json:"email,omitempty"`
+
+	// EmailVerified is set once the user confirmed to own Email
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// PendingEmail is the address the user changed to, which replaces Email once confirmed
+	PendingEmail string `json:"pending_email,omitempty"`
+
+	// PasswordChangedAt is when the password was last set, it's the zero time when that isn't known
+	PasswordChangedAt time.Time `json:"password_changed_at,omitempty"`
 }
 
 type Role int
@@ -22,3 +115,15 @@ type GroupWithRole struct {
 	Group
 	Role Role `json:"role,omitempty"`
 }
+
+type UserWithRole struct {
+	User
+	Role Role `json:"role,omitempty"`
+}
+
+// Membership is the role a user has within a group
+type Membership struct {
+	Username  string `json:"username"`
+	GroupName string `json:"group_name"`
+	Role      Role   `json:"role"`
+}
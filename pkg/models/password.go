@@ -0,0 +1,48 @@
+package models
+
+// PasswordForgot requests a password reset mail for a user
+type PasswordForgot struct {
+	Username string `json:"username"`
+}
+
+// PasswordReset sets a new password with the token from a password reset mail
+type PasswordReset struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// PasswordChange replaces an expired password, with the token handed out by the login instead
+type PasswordChange struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// PasswordPolicy are the rules new passwords have to follow. The zero PasswordPolicy has no rules.
+type PasswordPolicy struct {
+	// MinLength and MaxLength are in characters, a MaxLength of 0 means there's no maximum
+	MinLength int `json:"min_length,omitempty"`
+	MaxLength int `json:"max_length,omitempty"`
+	// MinScore is the minimum zxcvbn score, from 0 (too guessable) to 4 (very unguessable)
+	MinScore int `json:"min_score,omitempty"`
+
+	// The character classes every password has to contain
+	RequireLower  bool `json:"require_lower,omitempty"`
+	RequireUpper  bool `json:"require_upper,omitempty"`
+	RequireDigit  bool `json:"require_digit,omitempty"`
+	RequireSymbol bool `json:"require_symbol,omitempty"`
+
+	// BannedWords may not appear in passwords, regardless of case
+	BannedWords []string `json:"banned_words,omitempty"`
+
+	// HistoryDepth is the number of most recent passwords, the current one included, which can't be used again
+	HistoryDepth int `json:"history_depth,omitempty"`
+	// MaxAgeDays is the number of days after which a password has to be changed, 0 means never
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+}
+
+// IsZero tells whether the policy has no rules
+func (p PasswordPolicy) IsZero() bool {
+	return p.MinLength == 0 && p.MaxLength == 0 && p.MinScore == 0 &&
+		!p.RequireLower && !p.RequireUpper && !p.RequireDigit && !p.RequireSymbol &&
+		len(p.BannedWords) == 0 && p.HistoryDepth == 0 && p.MaxAgeDays == 0
+}
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Match selects how a search string is matched
+type Match string
+
+const (
+	MatchContains Match = "contains"
+	MatchPrefix   Match = "prefix"
+)
+
+// UserSort is the field users are sorted on
+type UserSort string
+
+const (
+	SortUsername UserSort = "username"
+	SortEmail    UserSort = "email"
+)
+
+// UserQuery selects a page of users. The zero value selects all users sorted by username.
+type UserQuery struct {
+	// Search only selects users whose username or email matches it, ignoring case
+	Search string
+	// Match is how Search is matched, the default is MatchContains
+	Match Match
+
+	// Group only selects members of this group
+	Group string
+	// Role only selects members of Group with this role
+	Role Role
+
+	// Sort is the field to sort on, the default is SortUsername.
+	// Users are always sorted on username secondly.
+	Sort       UserSort
+	Descending bool
+
+	// Cursor continues the listing after the page it was returned with
+	Cursor string
+	// Limit is the maximum number of users in a page, 0 means no maximum
+	Limit int
+}
+
+// UserPage is a single page of users selected by a UserQuery
+type UserPage struct {
+	Users []User `json:"users"`
+	// NextCursor continues the listing after this page, it's empty for the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GroupQuery selects a page of groups, sorted by name. The zero value selects all groups.
+type GroupQuery struct {
+	// Search only selects groups whose name matches it, ignoring case
+	Search string
+	// Match is how Search is matched, the default is MatchContains
+	Match Match
+
+	// AllowRegistration only selects groups which users may join themselves
+	AllowRegistration bool
+
+	Descending bool
+
+	// Cursor continues the listing after the page it was returned with
+	Cursor string
+	// Limit is the maximum number of groups in a page, 0 means no maximum
+	Limit int
+}
+
+// GroupPage is a single page of groups selected by a GroupQuery
+type GroupPage struct {
+	Groups []Group `json:"groups"`
+	// NextCursor continues the listing after this page, it's empty for the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// MemberQuery selects a page of the members of a group, sorted by username.
+// The zero value selects all members.
+type MemberQuery struct {
+	// Search only selects members whose username or email matches it, ignoring case
+	Search string
+	// Match is how Search is matched, the default is MatchContains
+	Match Match
+
+	// Role only selects members with this role
+	Role Role
+
+	Descending bool
+
+	// Cursor continues the listing after the page it was returned with
+	Cursor string
+	// Limit is the maximum number of members in a page, 0 means no maximum
+	Limit int
+}
+
+// MemberPage is a single page of members selected by a MemberQuery
+type MemberPage struct {
+	Members []UserWithRole `json:"members"`
+	// NextCursor continues the listing after this page, it's empty for the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UserQuery is the query selecting the same users as q does in group
+func (q MemberQuery) UserQuery(group string) UserQuery {
+	return UserQuery{
+		Search:     q.Search,
+		Match:      q.Match,
+		Group:      group,
+		Role:       q.Role,
+		Descending: q.Descending,
+		Cursor:     q.Cursor,
+		Limit:      q.Limit,
+	}
+}
+
+// Values encodes the query as url query parameters
+func (q UserQuery) Values() url.Values {
+	v := url.Values{}
+	setSearch(v, q.Search, q.Match)
+	setString(v, "group", q.Group)
+	setRole(v, q.Role)
+	setString(v, "sort", string(q.Sort))
+	setPage(v, q.Descending, q.Cursor, q.Limit)
+
+	return v
+}
+
+// ParseUserQuery is the inverse of UserQuery.Values
+func ParseUserQuery(v url.Values) (UserQuery, error) {
+	q := UserQuery{
+		Search: v.Get("search"),
+		Match:  Match(v.Get("match")),
+		Group:  v.Get("group"),
+		Sort:   UserSort(v.Get("sort")),
+		Cursor: v.Get("cursor"),
+	}
+
+	var err error
+	if q.Role, err = parseRole(v); err != nil {
+		return UserQuery{}, err
+	}
+
+	q.Descending, q.Limit, err = parsePage(v)
+
+	return q, err
+}
+
+// Values encodes the query as url query parameters
+func (q GroupQuery) Values() url.Values {
+	v := url.Values{}
+	setSearch(v, q.Search, q.Match)
+	if q.AllowRegistration {
+		v.Set("allow_registration", "true")
+	}
+	setPage(v, q.Descending, q.Cursor, q.Limit)
+
+	return v
+}
+
+// ParseGroupQuery is the inverse of GroupQuery.Values
+func ParseGroupQuery(v url.Values) (GroupQuery, error) {
+	q := GroupQuery{
+		Search: v.Get("search"),
+		Match:  Match(v.Get("match")),
+		Cursor: v.Get("cursor"),
+	}
+
+	if ar := v.Get("allow_registration"); ar != "" {
+		var err error
+		if q.AllowRegistration, err = strconv.ParseBool(ar); err != nil {
+			return GroupQuery{}, errors.Wrap(err, "invalid allow_registration")
+		}
+	}
+
+	var err error
+	q.Descending, q.Limit, err = parsePage(v)
+
+	return q, err
+}
+
+// Values encodes the query as url query parameters
+func (q MemberQuery) Values() url.Values {
+	v := url.Values{}
+	setSearch(v, q.Search, q.Match)
+	setRole(v, q.Role)
+	setPage(v, q.Descending, q.Cursor, q.Limit)
+
+	return v
+}
+
+// ParseMemberQuery is the inverse of MemberQuery.Values
+func ParseMemberQuery(v url.Values) (MemberQuery, error) {
+	q := MemberQuery{
+		Search: v.Get("search"),
+		Match:  Match(v.Get("match")),
+		Cursor: v.Get("cursor"),
+	}
+
+	var err error
+	if q.Role, err = parseRole(v); err != nil {
+		return MemberQuery{}, err
+	}
+
+	q.Descending, q.Limit, err = parsePage(v)
+
+	return q, err
+}
+
+func setString(v url.Values, key, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
+
+func setSearch(v url.Values, search string, match Match) {
+	setString(v, "search", search)
+	setString(v, "match", string(match))
+}
+
+func setRole(v url.Values, role Role) {
+	if role != 0 {
+		v.Set("role", strconv.Itoa(int(role)))
+	}
+}
+
+func parseRole(v url.Values) (Role, error) {
+	role := v.Get("role")
+	if role == "" {
+		return 0, nil
+	}
+
+	r, err := strconv.Atoi(role)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid role")
+	}
+
+	return Role(r), nil
+}
+
+func setPage(v url.Values, descending bool, cursor string, limit int) {
+	if descending {
+		v.Set("order", "desc")
+	}
+	setString(v, "cursor", cursor)
+	if limit != 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
+}
+
+func parsePage(v url.Values) (descending bool, limit int, err error) {
+	switch v.Get("order") {
+	case "", "asc":
+	case "desc":
+		descending = true
+	default:
+		return false, 0, errors.Errorf("invalid order %q", v.Get("order"))
+	}
+
+	if l := v.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil {
+			return false, 0, errors.Wrap(err, "invalid limit")
+		}
+	}
+
+	return descending, limit, nil
+}
@@ -5,8 +5,34 @@ type AccessStatus struct {
 	Username      string
 	AllowedAccess bool
 	Role          Role
+	// TwoFactorRequired is set when access is denied because the group requires
+	// two-factor authentication, and the user hasn't enabled it
+	TwoFactorRequired bool `json:",omitempty"`
+}
+
+// LoginResponse is the response to a login. When the user has to supply a second factor no
+// tokens are handed out yet, instead the challenge has to be completed with a code.
+// Likewise, when the password expired it has to be changed with the PasswordExpired token first.
+type LoginResponse struct {
+	LoginToken      string `json:"login_token,omitempty"`
+	RefreshToken    string `json:"refresh_token,omitempty"`
+	Challenge       string `json:"challenge,omitempty"`
+	PasswordExpired string `json:"password_expired,omitempty"`
 }
 
 type PublicKeyResponse struct {
 	PublicKey string `json:"public_key"`
 }
+
+// RemovalSummary describes everything that was removed along with a user or group
+type RemovalSummary struct {
+	Users       []string     `json:"users,omitempty"`
+	Groups      []string     `json:"groups,omitempty"`
+	Memberships []Membership `json:"memberships,omitempty"`
+}
+
+// RevocationStatus tells whether the token with the given id (its jti claim) has been revoked
+type RevocationStatus struct {
+	ID      string `json:"jti"`
+	Revoked bool   `json:"revoked"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Session is a login of a user on some device. It lasts for as long as the refresh
+// tokens handed out at the login are refreshed, and is identified by their token family.
+type Session struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+
+	CreatedAt   time.Time `json:"created_at"`
+	LastRefresh time.Time `json:"last_refresh"`
+	// ExpiresAt is when the latest refresh token of the session expires
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// IP and UserAgent are those of the client at the last login or refresh
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Current marks the session the sessions were listed from
+	Current bool `json:"current,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// SecondFactor is the TOTP configuration of a user. It holds secrets, so it's never handed out.
+type SecondFactor struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+
+	// Confirmed is set once the user proved to have set up the secret by supplying a code.
+	// Until then the second factor isn't required to log in.
+	Confirmed bool `json:"confirmed"`
+
+	// LastStep is the time step of the last code which was accepted, codes can't be used twice
+	LastStep int64 `json:"last_step"`
+
+	// RecoveryCodes are the hashes of the recovery codes which haven't been used yet
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// TOTPEnrollment is handed out when setting up TOTP. The URI is what authenticator apps
+// are set up with, usually shown as a QR code.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPCode is a code from an authenticator app, or a recovery code
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes can each be used once in place of a TOTP code, for when the authenticator is lost
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// ChallengeResponse completes a login for which a second factor is required
+type ChallengeResponse struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
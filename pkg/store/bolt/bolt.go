@@ -0,0 +1,154 @@
+// Package bolt implements an AurumStore on top of bbolt, an embedded key-value database
+// which persists everything to a single local file. It is meant for small deployments
+// which don't want to run a separate database.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	usersBucket  = []byte("users")
+	groupsBucket = []byte("groups")
+
+	// membershipsBucket maps user\x00group to the role the user has in the group
+	membershipsBucket = []byte("memberships")
+	// membersBucket is the reverse index of membershipsBucket, it maps group\x00user to nothing
+	membersBucket = []byte("members")
+
+	// revokedBucket maps the ids of revoked tokens to the unix time they expire
+	revokedBucket = []byte("revoked")
+
+	// sessionsBucket maps user\x00id to the session
+	sessionsBucket = []byte("sessions")
+
+	// secondFactorsBucket maps a username to the second factor of the user
+	secondFactorsBucket = []byte("second_factors")
+
+	// passwordHistoryBucket maps a username to the hashes of the previous passwords of the user
+	passwordHistoryBucket = []byte("password_history")
+
+	// loginFailuresBucket maps a key to the failed logins counted for it
+	loginFailuresBucket = []byte("login_failures")
+
+	// rateLimitsBucket maps a key to the time its token bucket is full again
+	rateLimitsBucket = []byte("rate_limits")
+)
+
+var errInvalidName = errors.New("names may not contain null bytes")
+
+var buckets = [][]byte{
+	usersBucket,
+	groupsBucket,
+	membershipsBucket,
+	membersBucket,
+	revokedBucket,
+	sessionsBucket,
+	secondFactorsBucket,
+	passwordHistoryBucket,
+	loginFailuresBucket,
+	rateLimitsBucket,
+}
+
+type Bolt struct {
+	db *bbolt.DB
+
+	// tx is the running transaction when this store was handed out by WithTx
+	tx *bbolt.Tx
+}
+
+// New opens (or creates) the database file at path.
+func New(path string) (*Bolt, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database")
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "creating buckets")
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// Close closes the underlying database file.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// WithTx runs fn inside a single read-write bbolt transaction.
+func (b *Bolt) WithTx(_ context.Context, fn func(tx store.AurumStore) error) error {
+	if b.tx != nil {
+		return fn(b)
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return fn(&Bolt{db: b.db, tx: tx})
+	})
+}
+
+// view runs fn in a read-only transaction, or in the running transaction if there is one.
+func (b *Bolt) view(fn func(tx *bbolt.Tx) error) error {
+	if b.tx != nil {
+		return fn(b.tx)
+	}
+
+	return b.db.View(fn)
+}
+
+// update runs fn in a read-write transaction, or in the running transaction if there is one.
+func (b *Bolt) update(fn func(tx *bbolt.Tx) error) error {
+	if b.tx != nil {
+		return fn(b.tx)
+	}
+
+	return b.db.Update(fn)
+}
+
+// compositeKey joins two names into a single key. Names can't contain a null byte,
+// so this is unambiguous.
+func compositeKey(a, b string) []byte {
+	return []byte(a + "\x00" + b)
+}
+
+// splitKey is the inverse of compositeKey
+func splitKey(key []byte) (string, string) {
+	parts := bytes.SplitN(key, []byte{0}, 2)
+	if len(parts) != 2 {
+		return string(key), ""
+	}
+	return string(parts[0]), string(parts[1])
+}
+
+func get(tx *bbolt.Tx, bucket []byte, key []byte, v interface{}) (bool, error) {
+	data := tx.Bucket(bucket).Get(key)
+	if data == nil {
+		return false, nil
+	}
+
+	return true, errors.Wrap(json.Unmarshal(data, v), "json unmarshal")
+}
+
+func put(tx *bbolt.Tx, bucket []byte, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
+	}
+
+	return tx.Bucket(bucket).Put(key, data)
+}
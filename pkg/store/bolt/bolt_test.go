@@ -0,0 +1,59 @@
+package bolt
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDB(t *testing.T) (*Bolt, string) {
+	dir, err := ioutil.TempDir("", "aurum-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "aurum.db")
+	b, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b, path
+}
+
+func TestBolt(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.AurumStore {
+		b, _ := tempDB(t)
+		t.Cleanup(func() { _ = b.Close() })
+		return b
+	})
+}
+
+func TestBolt_Persistence(t *testing.T) {
+	ctx := context.Background()
+	b, path := tempDB(t)
+
+	assert.NoError(t, b.CreateUser(ctx, models.User{Username: "bob"}))
+	assert.NoError(t, b.CreateGroup(ctx, models.Group{Name: "group"}))
+	assert.NoError(t, b.AddGroupToUser(ctx, "bob", "group", models.RoleAdmin))
+	assert.NoError(t, b.Close())
+
+	b, err := New(path)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	_, err = b.GetUser(ctx, "bob")
+	assert.NoError(t, err)
+
+	role, err := b.GetGroupRole(ctx, "group", "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+}
@@ -0,0 +1,260 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) CreateGroup(_ context.Context, group models.Group) error {
+	if strings.ContainsRune(group.Name, 0) {
+		return errInvalidName
+	}
+
+	return b.update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(groupsBucket).Get([]byte(group.Name)) != nil {
+			return store.ErrExists
+		}
+
+		return put(tx, groupsBucket, []byte(group.Name), &group)
+	})
+}
+
+func (b *Bolt) SetGroup(_ context.Context, group models.Group) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(groupsBucket).Get([]byte(group.Name)) == nil {
+			return store.ErrNotExists
+		}
+
+		return put(tx, groupsBucket, []byte(group.Name), &group)
+	})
+}
+
+func (b *Bolt) RemoveGroup(_ context.Context, group string) (models.RemovalSummary, error) {
+	summary := models.RemovalSummary{Groups: []string{group}}
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		groups := tx.Bucket(groupsBucket)
+		if groups.Get([]byte(group)) == nil {
+			return store.ErrNotExists
+		}
+
+		if err := groups.Delete([]byte(group)); err != nil {
+			return err
+		}
+
+		// Remove all memberships of this group
+		members := tx.Bucket(membersBucket)
+		memberships := tx.Bucket(membershipsBucket)
+
+		prefix := compositeKey(group, "")
+		var keys [][]byte
+
+		c := members.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, k)
+		}
+
+		for _, k := range keys {
+			_, user := splitKey(k)
+
+			var role models.Role
+			if _, err := get(tx, membershipsBucket, compositeKey(user, group), &role); err != nil {
+				return err
+			}
+			summary.Memberships = append(summary.Memberships, models.Membership{
+				Username:  user,
+				GroupName: group,
+				Role:      role,
+			})
+
+			if err := memberships.Delete(compositeKey(user, group)); err != nil {
+				return err
+			}
+			if err := members.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.RemovalSummary{}, err
+	}
+
+	return summary, nil
+}
+
+func (b *Bolt) GetGroup(_ context.Context, group string) (*models.Group, error) {
+	var g models.Group
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, groupsBucket, []byte(group), &g)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+func (b *Bolt) GetGroups(_ context.Context, query models.GroupQuery) (models.GroupPage, error) {
+	var groups []models.Group
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		return tx.Bucket(groupsBucket).ForEach(func(_, v []byte) error {
+			var g models.Group
+			if err := json.Unmarshal(v, &g); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			groups = append(groups, g)
+			return nil
+		})
+	})
+	if err != nil {
+		return models.GroupPage{}, err
+	}
+
+	return store.PageGroups(groups, query)
+}
+
+func (b *Bolt) GetGroupMembers(_ context.Context, group string, query models.MemberQuery) (models.MemberPage, error) {
+	var users []models.User
+	roles := make(map[string]models.Role)
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		if tx.Bucket(groupsBucket).Get([]byte(group)) == nil {
+			return store.ErrNotExists
+		}
+
+		prefix := compositeKey(group, "")
+		c := tx.Bucket(membersBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			_, name := splitKey(k)
+
+			var role models.Role
+			if _, err := get(tx, membershipsBucket, compositeKey(name, group), &role); err != nil {
+				return err
+			}
+			roles[name] = role
+
+			var u models.User
+			if _, err := get(tx, usersBucket, []byte(name), &u); err != nil {
+				return err
+			}
+			users = append(users, u)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.MemberPage{}, err
+	}
+
+	return store.PageMembers(users, group, query, func(user string) (models.Role, bool) {
+		role, ok := roles[user]
+		return role, ok
+	})
+}
+
+func (b *Bolt) GetGroupsForUser(_ context.Context, user string) ([]models.GroupWithRole, error) {
+	var groups []models.GroupWithRole
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		prefix := compositeKey(user, "")
+
+		c := tx.Bucket(membershipsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			_, name := splitKey(k)
+
+			var gr models.GroupWithRole
+			if err := json.Unmarshal(v, &gr.Role); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			if _, err := get(tx, groupsBucket, []byte(name), &gr.Group); err != nil {
+				return err
+			}
+
+			groups = append(groups, gr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, store.ErrNotExists
+	}
+
+	return groups, nil
+}
+
+func (b *Bolt) AddGroupToUser(_ context.Context, user string, group string, role models.Role) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(usersBucket).Get([]byte(user)) == nil {
+			return store.ErrNotExists
+		}
+		if tx.Bucket(groupsBucket).Get([]byte(group)) == nil {
+			return store.ErrNotExists
+		}
+
+		if err := put(tx, membershipsBucket, compositeKey(user, group), role); err != nil {
+			return err
+		}
+
+		return tx.Bucket(membersBucket).Put(compositeKey(group, user), []byte{})
+	})
+}
+
+func (b *Bolt) RemoveGroupFromUser(_ context.Context, group string, user string) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		memberships := tx.Bucket(membershipsBucket)
+
+		key := compositeKey(user, group)
+		if memberships.Get(key) == nil {
+			return store.ErrNotExists
+		}
+
+		if err := memberships.Delete(key); err != nil {
+			return err
+		}
+
+		return tx.Bucket(membersBucket).Delete(compositeKey(group, user))
+	})
+}
+
+func (b *Bolt) GetGroupRole(_ context.Context, group string, user string) (models.Role, error) {
+	var role models.Role
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, membershipsBucket, compositeKey(user, group), &role)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+		return nil
+	})
+
+	return role, err
+}
+
+func (b *Bolt) SetGroupRole(ctx context.Context, group string, user string, role models.Role) error {
+	return b.AddGroupToUser(ctx, user, group, role)
+}
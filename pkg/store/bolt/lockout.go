@@ -0,0 +1,117 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) AddLoginFailure(_ context.Context, key string, at, since time.Time) (models.LoginFailures, error) {
+	var failures models.LoginFailures
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, loginFailuresBucket, []byte(key), &failures)
+		if err != nil {
+			return err
+		}
+
+		if !ok || failures.LastFailure.Before(since) {
+			failures = models.LoginFailures{Key: key}
+		}
+
+		failures.Count++
+		failures.LastFailure = at
+
+		return put(tx, loginFailuresBucket, []byte(key), failures)
+	})
+
+	return failures, err
+}
+
+func (b *Bolt) GetLoginFailures(_ context.Context, key string) (models.LoginFailures, error) {
+	var failures models.LoginFailures
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, loginFailuresBucket, []byte(key), &failures)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+
+		return nil
+	})
+
+	return failures, err
+}
+
+func (b *Bolt) ListLoginFailures(_ context.Context, since time.Time) ([]models.LoginFailures, error) {
+	list := []models.LoginFailures{}
+
+	// Keys are iterated in byte order, so the list is ordered by key
+	err := b.view(func(tx *bbolt.Tx) error {
+		return tx.Bucket(loginFailuresBucket).ForEach(func(_, v []byte) error {
+			var failures models.LoginFailures
+			if err := json.Unmarshal(v, &failures); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			if !failures.LastFailure.Before(since) {
+				list = append(list, failures)
+			}
+			return nil
+		})
+	})
+
+	return list, err
+}
+
+func (b *Bolt) RemoveLoginFailures(_ context.Context, key string) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(loginFailuresBucket)
+		if bucket.Get([]byte(key)) == nil {
+			return store.ErrNotExists
+		}
+
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func (b *Bolt) RemoveExpiredLoginFailures(_ context.Context, before time.Time) (int, error) {
+	var removed int
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(loginFailuresBucket)
+
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var failures models.LoginFailures
+			if err := json.Unmarshal(v, &failures); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			if failures.LastFailure.Before(before) {
+				expired = append(expired, k)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		removed = len(expired)
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,28 @@
+package bolt
+
+import (
+	"context"
+
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) GetPasswordHistory(_ context.Context, username string) ([]string, error) {
+	var hashes []string
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		_, err := get(tx, passwordHistoryBucket, []byte(username), &hashes)
+		return err
+	})
+
+	return hashes, err
+}
+
+func (b *Bolt) SetPasswordHistory(_ context.Context, username string, hashes []string) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		if len(hashes) == 0 {
+			return tx.Bucket(passwordHistoryBucket).Delete([]byte(username))
+		}
+
+		return put(tx, passwordHistoryBucket, []byte(username), hashes)
+	})
+}
@@ -0,0 +1,64 @@
+package bolt
+
+import (
+	"context"
+	"time"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) TakeRateLimitToken(_ context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	var retry time.Duration
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		var tat time.Time
+		if _, err := get(tx, rateLimitsBucket, []byte(key), &tat); err != nil {
+			return err
+		}
+
+		tat, retry = store.TakeToken(tat, now, burst, interval)
+		if retry > 0 {
+			return nil
+		}
+
+		return put(tx, rateLimitsBucket, []byte(key), tat)
+	})
+
+	return retry, err
+}
+
+func (b *Bolt) RemoveExpiredRateLimits(_ context.Context, now time.Time) (int, error) {
+	var removed int
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(rateLimitsBucket)
+
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var tat time.Time
+			if err := tat.UnmarshalJSON(v); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			if !tat.After(now) {
+				expired = append(expired, k)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		removed = len(expired)
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,43 @@
+package bolt
+
+import (
+	"context"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) GetSecondFactor(_ context.Context, username string) (models.SecondFactor, error) {
+	var factor models.SecondFactor
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, secondFactorsBucket, []byte(username), &factor)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+
+		return nil
+	})
+
+	return factor, err
+}
+
+func (b *Bolt) SetSecondFactor(_ context.Context, factor models.SecondFactor) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		return put(tx, secondFactorsBucket, []byte(factor.Username), factor)
+	})
+}
+
+func (b *Bolt) RemoveSecondFactor(_ context.Context, username string) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(secondFactorsBucket)
+		if bucket.Get([]byte(username)) == nil {
+			return store.ErrNotExists
+		}
+
+		return bucket.Delete([]byte(username))
+	})
+}
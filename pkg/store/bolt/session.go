@@ -0,0 +1,127 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) CreateSession(_ context.Context, session models.Session) error {
+	if strings.ContainsRune(session.ID, 0) {
+		return errInvalidName
+	}
+
+	return b.update(func(tx *bbolt.Tx) error {
+		key := compositeKey(session.Username, session.ID)
+		if tx.Bucket(sessionsBucket).Get(key) != nil {
+			return store.ErrExists
+		}
+
+		return put(tx, sessionsBucket, key, session)
+	})
+}
+
+func (b *Bolt) GetSession(_ context.Context, username, id string) (models.Session, error) {
+	var session models.Session
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, sessionsBucket, compositeKey(username, id), &session)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+
+		return nil
+	})
+
+	return session, err
+}
+
+func (b *Bolt) GetSessions(_ context.Context, username string) ([]models.Session, error) {
+	sessions := make([]models.Session, 0)
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		prefix := compositeKey(username, "")
+
+		c := tx.Bucket(sessionsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var session models.Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			sessions = append(sessions, session)
+		}
+
+		return nil
+	})
+
+	store.SortSessions(sessions)
+	return sessions, err
+}
+
+func (b *Bolt) SetSession(_ context.Context, session models.Session) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		key := compositeKey(session.Username, session.ID)
+		if tx.Bucket(sessionsBucket).Get(key) == nil {
+			return store.ErrNotExists
+		}
+
+		return put(tx, sessionsBucket, key, session)
+	})
+}
+
+func (b *Bolt) RemoveSession(_ context.Context, username, id string) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+
+		key := compositeKey(username, id)
+		if bucket.Get(key) == nil {
+			return store.ErrNotExists
+		}
+
+		return bucket.Delete(key)
+	})
+}
+
+func (b *Bolt) RemoveExpiredSessions(_ context.Context, now time.Time) (int, error) {
+	var removed int
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var session models.Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			if session.ExpiresAt.Before(now) {
+				expired = append(expired, k)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		removed = len(expired)
+		return nil
+	})
+
+	return removed, err
+}
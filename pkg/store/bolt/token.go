@@ -0,0 +1,68 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) RevokeToken(_ context.Context, id string, expiresAt time.Time) (bool, error) {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(expiresAt.Unix()))
+
+	var revoked bool
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(revokedBucket)
+		if bucket.Get([]byte(id)) != nil {
+			return nil
+		}
+
+		revoked = true
+		return bucket.Put([]byte(id), v)
+	})
+
+	return revoked, err
+}
+
+func (b *Bolt) IsTokenRevoked(_ context.Context, id string) (bool, error) {
+	var revoked bool
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		revoked = tx.Bucket(revokedBucket).Get([]byte(id)) != nil
+		return nil
+	})
+
+	return revoked, err
+}
+
+func (b *Bolt) RemoveExpiredRevocations(_ context.Context, now time.Time) (int, error) {
+	var removed int
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(revokedBucket)
+
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			if int64(binary.BigEndian.Uint64(v)) < now.Unix() {
+				expired = append(expired, k)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		removed = len(expired)
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,251 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+func (b *Bolt) CreateUser(_ context.Context, user models.User) error {
+	if strings.ContainsRune(user.Username, 0) {
+		return errInvalidName
+	}
+
+	return b.update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(usersBucket).Get([]byte(user.Username)) != nil {
+			return store.ErrExists
+		}
+
+		return put(tx, usersBucket, []byte(user.Username), &user)
+	})
+}
+
+func (b *Bolt) RemoveUser(_ context.Context, user string) (models.RemovalSummary, error) {
+	summary := models.RemovalSummary{Users: []string{user}}
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		if users.Get([]byte(user)) == nil {
+			return store.ErrNotExists
+		}
+
+		if err := users.Delete([]byte(user)); err != nil {
+			return err
+		}
+
+		// Remove all memberships of this user
+		members := tx.Bucket(membersBucket)
+		memberships := tx.Bucket(membershipsBucket)
+
+		prefix := compositeKey(user, "")
+		var keys [][]byte
+
+		c := memberships.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			_, group := splitKey(k)
+
+			var role models.Role
+			if err := json.Unmarshal(v, &role); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+			summary.Memberships = append(summary.Memberships, models.Membership{
+				Username:  user,
+				GroupName: group,
+				Role:      role,
+			})
+
+			keys = append(keys, k)
+		}
+
+		for _, k := range keys {
+			_, group := splitKey(k)
+			if err := members.Delete(compositeKey(group, user)); err != nil {
+				return err
+			}
+			if err := memberships.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		// Along with everything else that belongs to the user
+		sessions := tx.Bucket(sessionsBucket)
+
+		keys = nil
+		c = sessions.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, k)
+		}
+
+		for _, k := range keys {
+			if err := sessions.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Bucket(secondFactorsBucket).Delete([]byte(user)); err != nil {
+			return err
+		}
+
+		return tx.Bucket(passwordHistoryBucket).Delete([]byte(user))
+	})
+	if err != nil {
+		return models.RemovalSummary{}, err
+	}
+
+	return summary, nil
+}
+
+func (b *Bolt) GetUser(_ context.Context, user string) (models.User, error) {
+	var u models.User
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, usersBucket, []byte(user), &u)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+		return nil
+	})
+
+	return u, err
+}
+
+func (b *Bolt) GetUsers(_ context.Context, query models.UserQuery) (models.UserPage, error) {
+	var users []models.User
+	roles := make(map[string]models.Role)
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(usersBucket).ForEach(func(_, v []byte) error {
+			var u models.User
+			if err := json.Unmarshal(v, &u); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			users = append(users, u)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if query.Group == "" {
+			return nil
+		}
+
+		// Collect the roles of the members of the group to filter on
+		prefix := compositeKey(query.Group, "")
+		c := tx.Bucket(membersBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			_, user := splitKey(k)
+
+			var role models.Role
+			if _, err := get(tx, membershipsBucket, compositeKey(user, query.Group), &role); err != nil {
+				return err
+			}
+			roles[user] = role
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.UserPage{}, err
+	}
+
+	return store.PageUsers(users, query, func(user string) (models.Role, bool) {
+		role, ok := roles[user]
+		return role, ok
+	})
+}
+
+func (b *Bolt) SetUser(_ context.Context, user models.User) (models.User, error) {
+	var curr models.User
+
+	err := b.update(func(tx *bbolt.Tx) error {
+		ok, err := get(tx, usersBucket, []byte(user.Username), &curr)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+
+		if user.Password != "" {
+			curr.Password = user.Password
+		}
+
+		if user.Email != "" {
+			curr.Email = user.Email
+		}
+
+		if !user.PasswordChangedAt.IsZero() {
+			curr.PasswordChangedAt = user.PasswordChangedAt
+		}
+
+		return put(tx, usersBucket, []byte(user.Username), &curr)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return curr, nil
+}
+
+func (b *Bolt) SetUserEmail(_ context.Context, username, email, pending string, verified bool) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		var curr models.User
+
+		ok, err := get(tx, usersBucket, []byte(username), &curr)
+		if err != nil {
+			return err
+		} else if !ok {
+			return store.ErrNotExists
+		}
+
+		curr.Email = email
+		curr.PendingEmail = pending
+		curr.EmailVerified = verified
+
+		return put(tx, usersBucket, []byte(username), &curr)
+	})
+}
+
+func (b *Bolt) CountUsers(_ context.Context) (int, error) {
+	var n int
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		// Bucket stats don't include uncommitted changes, so count by hand
+		// to get the right answer inside transactions as well.
+		c := tx.Bucket(usersBucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+		return nil
+	})
+
+	return n, err
+}
+
+func (b *Bolt) CountPasswordsWithPrefix(_ context.Context, prefix string) (int, error) {
+	var n int
+
+	err := b.view(func(tx *bbolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(_, v []byte) error {
+			var u models.User
+			if err := json.Unmarshal(v, &u); err != nil {
+				return errors.Wrap(err, "json unmarshal")
+			}
+
+			if strings.HasPrefix(u.Password, prefix) {
+				n++
+			}
+			return nil
+		})
+	})
+
+	return n, err
+}
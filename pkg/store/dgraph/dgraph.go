@@ -2,54 +2,217 @@ package dgraph
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/dgraph-io/dgo/v200"
 	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/finitum/aurum/pkg/store"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 )
 
+const (
+	// maxRetries is how often a transaction is retried when it conflicted with a concurrent transaction
+	maxRetries = 10
+
+	// retryBackoff is the longest wait before the first retry, it doubles with every retry up to maxRetryBackoff
+	retryBackoff    = 5 * time.Millisecond
+	maxRetryBackoff = 500 * time.Millisecond
+)
+
 type DGraph struct {
 	*dgo.Dgraph
+
+	// txn is the running transaction when this store was handed out by WithTx
+	txn *dgo.Txn
 }
 
 func (dg DGraph) ClearAllImSure(ctx context.Context) error {
 	return dg.Alter(ctx, &api.Operation{DropOp: api.Operation_ALL})
 }
 
+// New connects to Dgraph at address and migrates the database to the latest schema version.
 func New(ctx context.Context, address string) (*DGraph, error) {
+	dg, err := dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dg.Migrate(ctx); err != nil {
+		return nil, errors.Wrap(err, "migrating database")
+	}
+
+	return dg, nil
+}
+
+// Connect connects to Dgraph at address without migrating the database,
+// which therefore already has to be at the latest schema version.
+func Connect(ctx context.Context, address string) (*DGraph, error) {
+	dg, err := dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := dg.SchemaVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying schema version")
+	}
+
+	if version > LatestSchemaVersion() {
+		return nil, errors.Errorf("database schema version %d is newer than supported version %d", version, LatestSchemaVersion())
+	} else if version < LatestSchemaVersion() {
+		return nil, errors.Errorf("database schema version %d is outdated, migrate it to version %d first", version, LatestSchemaVersion())
+	}
+
+	return dg, nil
+}
+
+func dial(address string) (*DGraph, error) {
 	d, err := grpc.Dial(address, grpc.WithInsecure())
 	if err != nil {
 		return nil, err
 	}
 
-	dg := dgo.NewDgraphClient(api.NewDgraphClient(d))
+	return &DGraph{Dgraph: dgo.NewDgraphClient(api.NewDgraphClient(d))}, nil
+}
+
+// WithTx runs fn inside a single Dgraph transaction, which is committed once fn returns.
+// Dgraph transactions are optimistic, so they are aborted if another transaction modified
+// the same data in the meantime. Then fn runs again in a new transaction, which sees the
+// changes of the other one.
+func (dg DGraph) WithTx(ctx context.Context, fn func(tx store.AurumStore) error) error {
+	if dg.inTx() {
+		return fn(dg)
+	}
+
+	return retryAborted(ctx, maxRetries, func() error {
+		txn := dg.NewTxn()
+		defer txn.Discard(ctx)
+
+		if err := fn(DGraph{Dgraph: dg.Dgraph, txn: txn}); err != nil {
+			return err
+		}
 
-	if err := dg.Alter(ctx, &api.Operation{
-		Schema: `
-			type User {
-				username
-				password
-				email
-				groups
-			}
+		return errors.Wrap(txn.Commit(ctx), "commit")
+	})
+}
+
+// retryAborted runs fn again for as long as it's aborted because of a conflicting concurrent transaction.
+// In between it waits a random, growing backoff, so the conflicting transactions don't collide again.
+// It gives up after limit retries, or when ctx is done if limit is 0, returning the last error.
+func retryAborted(ctx context.Context, limit int, fn func() error) error {
+	backoff := retryBackoff
 
-			type Group {
-				name
-				allow_registration
-			}
+	for i := 0; ; i++ {
+		err := fn()
+		if errors.Cause(err) != dgo.ErrAborted || (limit > 0 && i >= limit) {
+			return err
+		}
 
-			username: string @index(hash) .
-			password: string .
-			email: string .
-			groups: [uid] .
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(rand.Int63n(int64(backoff))) + 1):
+		}
+
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+func (dg DGraph) inTx() bool {
+	return dg.txn != nil
+}
+
+// newTxn returns the running transaction if there is one, or starts a new one.
+// Mutations should only be committed right away when not part of a running transaction.
+func (dg DGraph) newTxn() *dgo.Txn {
+	if dg.inTx() {
+		return dg.txn
+	}
+
+	return dg.NewTxn()
+}
+
+// newReadOnlyTxn returns the running transaction if there is one, so reads see its
+// uncommitted changes, or starts a new read-only transaction.
+func (dg DGraph) newReadOnlyTxn() *dgo.Txn {
+	if dg.inTx() {
+		return dg.txn
+	}
+
+	return dg.NewReadOnlyTxn()
+}
+
+// newBestEffortTxn is like newReadOnlyTxn, but a new transaction is best effort.
+func (dg DGraph) newBestEffortTxn() *dgo.Txn {
+	if dg.inTx() {
+		return dg.txn
+	}
+
+	return dg.NewReadOnlyTxn().BestEffort()
+}
+
+// discard discards txn, unless it is the running transaction which is discarded by WithTx.
+func (dg DGraph) discard(ctx context.Context, txn *dgo.Txn) {
+	if txn != dg.txn {
+		_ = txn.Discard(ctx)
+	}
+}
+
+// upsert executes an upsert block. Outside of a running transaction it's committed right away,
+// and retried when it was aborted because of a conflicting concurrent transaction.
+func (dg DGraph) upsert(ctx context.Context, req *api.Request) (*api.Response, error) {
+	if dg.inTx() {
+		return dg.txn.Do(ctx, req)
+	}
+
+	req.CommitNow = true
+
+	var resp *api.Response
+	err := retryAborted(ctx, maxRetries, func() error {
+		var err error
+		resp, err = dg.NewTxn().Do(ctx, req)
+		return err
+	})
+
+	return resp, err
+}
+
+// newNode is the blank node used for nodes created by createUnique
+const newNode = "_:new"
+
+// createUnique creates node, but only if no other node has the same value for predicate.
+// The uid of node must be newNode. The predicate needs the @upsert directive, so Dgraph
+// aborts one of two concurrent transactions creating the same value, after which the
+// retry finds the existing node.
+func (dg DGraph) createUnique(ctx context.Context, predicate, value string, node interface{}) error {
+	js, err := json.Marshal(node)
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
+	}
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: fmt.Sprintf(`query q($value: string) { existing as var(func: eq(%s, $value)) }`, predicate),
+		Vars:  map[string]string{"$value": value},
+		Mutations: []*api.Mutation{{
+			Cond:    `@if(eq(len(existing), 0))`,
+			SetJson: js,
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "upsert")
+	}
 
-			name: string @index(hash) .
-			allow_registration: bool .
-		`,
-	}); err != nil {
-		return nil, errors.Wrap(err, "applying schema")
+	// The mutation was skipped when a node with this value already existed,
+	// otherwise the uid of the blank node is returned
+	if _, ok := resp.Uids["new"]; !ok {
+		return store.ErrExists
 	}
 
-	return &DGraph{dg}, nil
+	return nil
 }
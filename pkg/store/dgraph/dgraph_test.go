@@ -0,0 +1,139 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testURL() string {
+	url := os.Getenv("DGRAPH_URL")
+	if url == "" {
+		url = "localhost:9080"
+	}
+
+	return url
+}
+
+// testDB connects to the dgraph instance at DGRAPH_URL and empties it
+func testDB(t *testing.T) *DGraph {
+	if testing.Short() {
+		t.Skip("Skipping dgraph integration test")
+	}
+
+	url := testURL()
+	ctx := context.Background()
+
+	dg, err := New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping everything also drops the schema and its version, so migrate again
+	if err := dg.ClearAllImSure(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dg.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return dg
+}
+
+func TestDGraph(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.AurumStore {
+		return testDB(t)
+	})
+}
+
+func TestDGraph_ConcurrentSignUp(t *testing.T) {
+	dg := testDB(t)
+	ctx := context.Background()
+
+	const n = 100
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- dg.WithTx(ctx, func(tx store.AurumStore) error {
+				return tx.CreateUser(ctx, models.User{Username: "bob"})
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// The others are retried after the winner committed, and find the user it created
+	var succeeded int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.Equal(t, store.ErrExists, err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	// Count the nodes directly, as a duplicate would make getUser fail
+	resp, err := dg.NewReadOnlyTxn().Query(ctx, `{ q(func: eq(username, "bob")) { count(uid) } }`)
+	require.NoError(t, err)
+
+	var r struct {
+		Q []struct {
+			Count int `json:"count"`
+		} `json:"q"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Json, &r))
+	require.Len(t, r.Q, 1)
+	assert.Equal(t, 1, r.Q[0].Count)
+}
+
+func TestDGraph_Migrate(t *testing.T) {
+	dg := testDB(t)
+	ctx := context.Background()
+
+	version, err := dg.SchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), version)
+
+	// Migrating an up to date database is a no-op
+	assert.NoError(t, dg.Migrate(ctx))
+
+	version, err = dg.SchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), version)
+
+	// A database from a newer version of Aurum is refused
+	require.NoError(t, dg.setSchemaVersion(ctx, LatestSchemaVersion()+1))
+	assert.Error(t, dg.Migrate(ctx))
+}
+
+func TestDGraph_Connect(t *testing.T) {
+	dg := testDB(t)
+	ctx := context.Background()
+	url := testURL()
+
+	_, err := Connect(ctx, url)
+	assert.NoError(t, err)
+
+	require.NoError(t, dg.setSchemaVersion(ctx, LatestSchemaVersion()-1))
+	_, err = Connect(ctx, url)
+	assert.Error(t, err)
+
+	require.NoError(t, dg.setSchemaVersion(ctx, LatestSchemaVersion()+1))
+	_, err = Connect(ctx, url)
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package dgraph
 import (
 	"context"
 	"encoding/json"
+	"sort"
 
 	"github.com/dgraph-io/dgo/v200"
 	"github.com/dgraph-io/dgo/v200/protos/api"
@@ -16,8 +17,7 @@ func (dg DGraph) getGroup(ctx context.Context, txn *dgo.Txn, group string) (*Gro
 		query q($aname: string) {
 		  q(func:eq(name, $aname)) {
 			uid
-			allow_registration
-			name
+			` + groupPredicates + `
 		  }
 		}
 	`
@@ -38,7 +38,7 @@ func (dg DGraph) getGroup(ctx context.Context, txn *dgo.Txn, group string) (*Gro
 	}
 
 	if len(r.Q) == 0 {
-		return nil, errors.Errorf("group %s wasn't found", group)
+		return nil, store.ErrNotExists
 	} else if len(r.Q) != 1 {
 		return nil, errors.Errorf("expected unique (one) group with name %s, but found %d", group, len(r.Q))
 	}
@@ -47,122 +47,205 @@ func (dg DGraph) getGroup(ctx context.Context, txn *dgo.Txn, group string) (*Gro
 }
 
 func (dg DGraph) GetGroup(ctx context.Context, groupName string) (*models.Group, error) {
-	txn := dg.NewReadOnlyTxn().BestEffort()
+	txn := dg.newBestEffortTxn()
 	group, err := dg.getGroup(ctx, txn, groupName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &group.Group, nil
+	g, err := group.Model()
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
 }
 
-func (dg DGraph) GetGroups(ctx context.Context) ([]models.Group, error) {
-	query := `
+func (dg DGraph) GetGroups(ctx context.Context, query models.GroupQuery) (models.GroupPage, error) {
+	q := `
 		{
 			q(func: type(Group)) {
-				name
-				allow_registration
+				` + groupPredicates + `
 			}
 		}
 	`
 
-	txn := dg.NewReadOnlyTxn().BestEffort()
-	resp, err := txn.Query(ctx, query)
+	txn := dg.newBestEffortTxn()
+	resp, err := txn.Query(ctx, q)
 	if err != nil {
-		return nil, errors.Wrap(err, "query")
+		return models.GroupPage{}, errors.Wrap(err, "query")
 	}
 
 	var r struct {
-		Q []models.Group `json:"q"`
+		Q []Group `json:"q"`
 	}
 
 	err = json.Unmarshal(resp.Json, &r)
 	if err != nil {
-		return nil, errors.Wrap(err, "json unmarshal")
+		return models.GroupPage{}, errors.Wrap(err, "json unmarshal")
 	}
 
-	return r.Q, nil
-}
-
-func (dg DGraph) CreateGroup(ctx context.Context, group models.Group) error {
-	// start a new transaction
-	txn := dg.NewTxn()
-	defer txn.Discard(ctx)
+	groups := make([]models.Group, 0, len(r.Q))
+	for _, g := range r.Q {
+		group, err := g.Model()
+		if err != nil {
+			return models.GroupPage{}, err
+		}
+		groups = append(groups, group)
+	}
 
-	// query the database for the number of groups that exist with either the same group id
-	// or the same group name
-	query := `
-		query q($aname: string) {
-		  q(func:eq(name, $aname)) {
-			count(uid)
-		  }
-		}`
+	return store.PageGroups(groups, query)
+}
 
-	variables := map[string]string{
-		"$aname": group.Name,
+func (dg DGraph) GetGroupMembers(ctx context.Context, groupName string, query models.MemberQuery) (models.MemberPage, error) {
+	// Searching and paginating happens in store.PageMembers
+	q := `
+query q($gname: string) {
+	group(func: eq(name, $gname)) {
+		~groups @facets(role) {
+			username
+			email
+		}
 	}
+}`
 
-	resp, err := txn.QueryWithVars(ctx, query, variables)
+	txn := dg.newBestEffortTxn()
+	resp, err := txn.QueryWithVars(ctx, q, map[string]string{"$gname": groupName})
 	if err != nil {
-		return errors.Wrap(err, "query")
+		return models.MemberPage{}, errors.Wrap(err, "query")
 	}
 
 	var r struct {
-		Q []struct {
-			Count int `json:"count"`
-		} `json:"q"`
+		Group []struct {
+			Members []struct {
+				models.User
+				Role models.Role `json:"~groups|role"`
+			} `json:"~groups"`
+		} `json:"group"`
 	}
 
-	err = json.Unmarshal(resp.Json, &r)
-	if err != nil {
-		return errors.Wrap(err, "json unmarshal")
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return models.MemberPage{}, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Group) == 0 {
+		return models.MemberPage{}, store.ErrNotExists
+	} else if len(r.Group) != 1 {
+		return models.MemberPage{}, errors.Errorf("expected unique (one) group with name %s, but found %d", groupName, len(r.Group))
 	}
 
-	// If there exists 1 or more groups with this username, fail
-	if len(r.Q) != 1 || r.Q[0].Count > 0 {
-		return store.ErrExists
+	members := r.Group[0].Members
+	users := make([]models.User, 0, len(members))
+	roles := make(map[string]models.Role, len(members))
+	for _, m := range members {
+		users = append(users, m.User)
+		roles[m.Username] = m.Role
 	}
 
-	// Add the new group to the database
+	return store.PageMembers(users, groupName, query, func(user string) (models.Role, bool) {
+		role, ok := roles[user]
+		return role, ok
+	})
+}
+
+func (dg DGraph) CreateGroup(ctx context.Context, group models.Group) error {
 	dGroup := NewDGraphGroup(group)
+	dGroup.Uid = newNode
 
-	js, err := json.Marshal(dGroup)
+	return dg.createUnique(ctx, "name", group.Name, dGroup)
+}
+
+func (dg DGraph) SetGroup(ctx context.Context, group models.Group) error {
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
+
+	curr, err := dg.getGroup(ctx, txn, group.Name)
 	if err != nil {
 		return err
 	}
 
-	mu := &api.Mutation{
-		CommitNow: true,
-		SetJson:   js,
+	// Group omits empty values, so resetting a setting wouldn't be stored
+	js, err := json.Marshal(map[string]interface{}{
+		"uid":                    curr.Uid,
+		"allow_registration":     group.AllowRegistration,
+		"display_name":           group.DisplayName,
+		"description":            group.Description,
+		"metadata":               encodeMetadata(group.Metadata),
+		"require_two_factor":     group.RequireTwoFactor,
+		"require_verified_email": group.RequireVerifiedEmail,
+		"password_policy":        encodePolicy(group.PasswordPolicy),
+	})
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
 	}
 
-	_, err = txn.Mutate(ctx, mu)
+	_, err = txn.Mutate(ctx, &api.Mutation{
+		SetJson:   js,
+		CommitNow: !dg.inTx(),
+	})
 
 	return errors.Wrap(err, "mutate")
 }
 
-func (dg DGraph) RemoveGroup(ctx context.Context, groupName string) error {
-	txn := dg.NewTxn()
+func (dg DGraph) RemoveGroup(ctx context.Context, groupName string) (models.RemovalSummary, error) {
+	// Users point at their groups, so besides the group node itself the
+	// edges of all members towards it have to be deleted as well.
+	query := `
+query q($gname: string) {
+	group(func: eq(name, $gname)) {
+		g as uid
+		~groups @facets(role) {
+			m as uid
+			username
+		}
+	}
+}`
 
-	group, err := dg.getGroup(ctx, txn, groupName)
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: query,
+		Vars:  map[string]string{"$gname": groupName},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(eq(len(g), 1))`,
+			DelNquads: []byte("uid(m) <groups> uid(g) .\nuid(g) * * ."),
+		}},
+	})
 	if err != nil {
-		return errors.Wrap(err, "get user (internal)")
+		return models.RemovalSummary{}, errors.Wrap(err, "upsert")
 	}
 
-	d := map[string]string{"uid": group.Uid}
-	js, err := json.Marshal(d)
-	if err != nil {
-		return errors.Wrap(err, "json marshal")
+	var r struct {
+		Group []struct {
+			Members []struct {
+				Username string      `json:"username"`
+				Role     models.Role `json:"~groups|role"`
+			} `json:"~groups"`
+		} `json:"group"`
 	}
 
-	mu := &api.Mutation{
-		CommitNow:  true,
-		DeleteJson: js,
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return models.RemovalSummary{}, errors.Wrap(err, "json unmarshal")
 	}
 
-	_, err = txn.Mutate(ctx, mu)
+	if len(r.Group) == 0 {
+		return models.RemovalSummary{}, store.ErrNotExists
+	} else if len(r.Group) != 1 {
+		return models.RemovalSummary{}, errors.Errorf("expected unique (one) group with name %s, but found %d", groupName, len(r.Group))
+	}
 
-	return errors.Wrap(err, "delete")
+	summary := models.RemovalSummary{Groups: []string{groupName}}
+	for _, m := range r.Group[0].Members {
+		summary.Memberships = append(summary.Memberships, models.Membership{
+			Username:  m.Username,
+			GroupName: groupName,
+			Role:      m.Role,
+		})
+	}
+
+	sort.Slice(summary.Memberships, func(i, j int) bool {
+		return summary.Memberships[i].Username < summary.Memberships[j].Username
+	})
+
+	return summary, nil
 }
 
 func (dg DGraph) GetGroupsForUser(ctx context.Context, user string) ([]models.GroupWithRole, error) {
@@ -170,36 +253,40 @@ func (dg DGraph) GetGroupsForUser(ctx context.Context, user string) ([]models.Gr
 query q($uname: string) {
   q(func: type(User)) @filter(eq(username, $uname)) {
 	username
-   	groups @facets(role:role) {
-      name
-	  allow_registration
-  	} 
+   	groups @facets(role) @filter(has(name)) {
+      ` + groupPredicates + `
+  	}
   }
 }`
 
 	variables := map[string]string{
 		"$uname": user,
 	}
-	txn := dg.NewReadOnlyTxn().BestEffort()
+	txn := dg.newBestEffortTxn()
 	resp, err := txn.QueryWithVars(ctx, query, variables)
 	if err != nil {
 		return nil, errors.Wrap(err, "query")
 	}
 
 	var r struct {
-		Q []struct {
-			Groups []models.GroupWithRole `json:"groups"`
-		} `json:"q"`
+		Q []User `json:"q"`
 	}
 
 	err = json.Unmarshal(resp.Json, &r)
 	if err != nil {
 		return nil, errors.Wrap(err, "json unmarshal")
-	} else if len(r.Q) != 1 {
-		return nil, errors.Wrap(err, "how the hell did this happen???")
-	} else if len(r.Q[0].Groups) == 0 {
+	} else if len(r.Q) != 1 || len(r.Q[0].Groups) == 0 {
 		return nil, store.ErrNotExists
 	}
 
-	return r.Q[0].Groups, nil
+	groups := make([]models.GroupWithRole, 0, len(r.Q[0].Groups))
+	for _, g := range r.Q[0].Groups {
+		group, err := g.Model()
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, models.GroupWithRole{Group: group, Role: g.Role})
+	}
+
+	return groups, nil
 }
@@ -0,0 +1,234 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/dgo/v200"
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// LoginFailures is the node counting the failed logins for a key
+type LoginFailures struct {
+	Key         string    `json:"failure_key"`
+	Count       int       `json:"failure_count"`
+	LastFailure time.Time `json:"last_failure"`
+
+	DType []string `json:"dgraph.type,omitempty"`
+	Uid   string   `json:"uid,omitempty"`
+}
+
+// loginFailuresPredicates are the predicates of login failures to query
+const loginFailuresPredicates = `
+	failure_key
+	failure_count
+	last_failure
+`
+
+// Model converts the failures back into models.LoginFailures
+func (f LoginFailures) Model() models.LoginFailures {
+	return models.LoginFailures{
+		Key:         f.Key,
+		Count:       f.Count,
+		LastFailure: f.LastFailure.UTC(),
+	}
+}
+
+func (dg DGraph) getLoginFailures(ctx context.Context, txn *dgo.Txn, key string) (*LoginFailures, error) {
+	query := `
+		query q($key: string) {
+		  q(func: eq(failure_key, $key)) {
+			uid
+			` + loginFailuresPredicates + `
+		  }
+		}
+	`
+
+	resp, err := txn.QueryWithVars(ctx, query, map[string]string{"$key": key})
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []LoginFailures `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return nil, store.ErrNotExists
+	} else if len(r.Q) != 1 {
+		return nil, errors.Errorf("expected unique (one) login failures for %s, but found %d", key, len(r.Q))
+	}
+
+	return &r.Q[0], nil
+}
+
+func (dg DGraph) AddLoginFailure(ctx context.Context, key string, at, since time.Time) (models.LoginFailures, error) {
+	// Within a running transaction the whole transaction is retried instead
+	if dg.inTx() {
+		return dg.addLoginFailure(ctx, key, at, since)
+	}
+
+	// Concurrent failures for the same key conflict, the retry counts on top of the other one.
+	// Every failure has to be counted, so it's retried until the caller gives up.
+	var failures models.LoginFailures
+	err := retryAborted(ctx, 0, func() error {
+		var err error
+		failures, err = dg.addLoginFailure(ctx, key, at, since)
+		return err
+	})
+
+	return failures, err
+}
+
+func (dg DGraph) addLoginFailure(ctx context.Context, key string, at, since time.Time) (models.LoginFailures, error) {
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
+
+	node := LoginFailures{
+		Key:   key,
+		DType: []string{"LoginFailures"},
+		Uid:   newNode,
+	}
+
+	curr, err := dg.getLoginFailures(ctx, txn, key)
+	if err == nil {
+		node.Uid = curr.Uid
+		if !curr.LastFailure.Before(since) {
+			node.Count = curr.Count
+		}
+	} else if err != store.ErrNotExists {
+		return models.LoginFailures{}, err
+	}
+
+	node.Count++
+	node.LastFailure = at.UTC()
+
+	js, err := json.Marshal(node)
+	if err != nil {
+		return models.LoginFailures{}, errors.Wrap(err, "json marshal")
+	}
+
+	if _, err := txn.Mutate(ctx, &api.Mutation{
+		SetJson:   js,
+		CommitNow: !dg.inTx(),
+	}); err != nil {
+		return models.LoginFailures{}, errors.Wrap(err, "mutate")
+	}
+
+	return node.Model(), nil
+}
+
+func (dg DGraph) GetLoginFailures(ctx context.Context, key string) (models.LoginFailures, error) {
+	failures, err := dg.getLoginFailures(ctx, dg.newBestEffortTxn(), key)
+	if err != nil {
+		return models.LoginFailures{}, err
+	}
+
+	return failures.Model(), nil
+}
+
+func (dg DGraph) ListLoginFailures(ctx context.Context, since time.Time) ([]models.LoginFailures, error) {
+	query := `
+		query q($since: string) {
+		  q(func: type(LoginFailures), orderasc: failure_key) @filter(ge(last_failure, $since)) {
+			` + loginFailuresPredicates + `
+		  }
+		}
+	`
+
+	resp, err := dg.newBestEffortTxn().QueryWithVars(ctx, query, map[string]string{
+		"$since": since.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []LoginFailures `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	list := make([]models.LoginFailures, 0, len(r.Q))
+	for _, f := range r.Q {
+		list = append(list, f.Model())
+	}
+
+	return list, nil
+}
+
+func (dg DGraph) RemoveLoginFailures(ctx context.Context, key string) error {
+	query := `
+query q($key: string) {
+	q(func: eq(failure_key, $key)) {
+		f as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: query,
+		Vars:  map[string]string{"$key": key},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(gt(len(f), 0))`,
+			DelNquads: []byte("uid(f) * * ."),
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []LoginFailures `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return store.ErrNotExists
+	}
+
+	return nil
+}
+
+func (dg DGraph) RemoveExpiredLoginFailures(ctx context.Context, before time.Time) (int, error) {
+	q := `
+query q($before: string) {
+	q(func: type(LoginFailures)) @filter(lt(last_failure, $before)) {
+		f as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: q,
+		Vars:  map[string]string{"$before": before.UTC().Format(time.RFC3339Nano)},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(gt(len(f), 0))`,
+			DelNquads: []byte("uid(f) * * ."),
+		}},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []LoginFailures `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return 0, errors.Wrap(err, "json unmarshal")
+	}
+
+	return len(r.Q), nil
+}
@@ -0,0 +1,315 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/pkg/errors"
+)
+
+// migration is a single step in the evolution of the schema and the data in it. Steps must be
+// idempotent, as a step runs again when recording the new version failed after it completed.
+type migration struct {
+	description string
+	run         func(ctx context.Context, dg DGraph) error
+}
+
+// migrations contains every version of the database schema. Entries may never be
+// changed or removed once released, changes must be made by appending a new migration.
+var migrations = []migration{
+	{
+		description: "users, groups and memberships",
+		run: alterSchema(`
+			type User {
+				username
+				password
+				email
+				groups
+			}
+
+			type Group {
+				name
+				allow_registration
+			}
+
+			username: string @index(hash) .
+			password: string .
+			email: string .
+			groups: [uid] .
+
+			name: string @index(hash) .
+			allow_registration: bool .
+		`),
+	},
+	{
+		description: "unique usernames and group names, reverse edges for group members",
+		run: alterSchema(`
+			username: string @index(hash) @upsert .
+			name: string @index(hash) @upsert .
+			groups: [uid] @reverse .
+		`),
+	},
+	{
+		description: "group settings",
+		run: alterSchema(`
+			type Group {
+				name
+				allow_registration
+				display_name
+				description
+				metadata
+			}
+
+			display_name: string .
+			description: string .
+			metadata: string .
+		`),
+	},
+	{
+		description: "revoked tokens",
+		run: alterSchema(`
+			type RevokedToken {
+				jti
+				expires_at
+			}
+
+			jti: string @index(hash) @upsert .
+			expires_at: datetime @index(hour) .
+		`),
+	},
+	{
+		description: "sessions",
+		run: alterSchema(`
+			type Session {
+				session_key
+				session_username
+				session_id
+				created_at
+				last_refresh
+				expires_at
+				ip
+				user_agent
+			}
+
+			session_key: string @index(hash) @upsert .
+			session_username: string @index(hash) .
+			session_id: string .
+			created_at: datetime .
+			last_refresh: datetime .
+			ip: string .
+			user_agent: string .
+		`),
+	},
+	{
+		description: "two-factor authentication",
+		run: alterSchema(`
+			type Group {
+				name
+				allow_registration
+				display_name
+				description
+				metadata
+				require_two_factor
+			}
+
+			type SecondFactor {
+				second_factor_username
+				totp_secret
+				totp_confirmed
+				totp_last_step
+				recovery_codes
+			}
+
+			require_two_factor: bool .
+
+			second_factor_username: string @index(hash) @upsert .
+			totp_secret: string .
+			totp_confirmed: bool .
+			totp_last_step: int .
+			recovery_codes: [string] .
+		`),
+	},
+	{
+		description: "email verification",
+		run: alterSchema(`
+			type User {
+				username
+				password
+				email
+				email_verified
+				pending_email
+				groups
+			}
+
+			type Group {
+				name
+				allow_registration
+				display_name
+				description
+				metadata
+				require_two_factor
+				require_verified_email
+			}
+
+			email_verified: bool .
+			pending_email: string .
+
+			require_verified_email: bool .
+		`),
+	},
+	{
+		description: "failed logins",
+		run: alterSchema(`
+			type LoginFailures {
+				failure_key
+				failure_count
+				last_failure
+			}
+
+			failure_key: string @index(exact) @upsert .
+			failure_count: int .
+			last_failure: datetime @index(hour) .
+		`),
+	},
+	{
+		description: "rate limits",
+		run: alterSchema(`
+			type RateLimit {
+				rate_limit_key
+				rate_limit_tat
+			}
+
+			rate_limit_key: string @index(hash) @upsert .
+			rate_limit_tat: datetime @index(hour) .
+		`),
+	},
+	{
+		description: "password policies",
+		run: alterSchema(`
+			type Group {
+				name
+				allow_registration
+				display_name
+				description
+				metadata
+				require_two_factor
+				require_verified_email
+				password_policy
+			}
+
+			password_policy: string .
+		`),
+	},
+	{
+		description: "password history and expiry",
+		run: alterSchema(`
+			type User {
+				username
+				password
+				email
+				email_verified
+				pending_email
+				password_changed_at
+				groups
+			}
+
+			type PasswordHistory {
+				password_history_username
+				password_hashes
+			}
+
+			password_changed_at: datetime .
+
+			password_history_username: string @index(hash) @upsert .
+			password_hashes: string .
+		`),
+	},
+}
+
+// alterSchema creates a migration which applies schema. Applying the same schema twice is a no-op.
+func alterSchema(schema string) func(ctx context.Context, dg DGraph) error {
+	return func(ctx context.Context, dg DGraph) error {
+		return dg.Alter(ctx, &api.Operation{Schema: schema})
+	}
+}
+
+// LatestSchemaVersion is the schema version the database is at after running all migrations.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// SchemaVersion returns the schema version recorded in the database, which
+// is 0 when no migration has been run yet.
+func (dg DGraph) SchemaVersion(ctx context.Context) (int, error) {
+	resp, err := dg.NewReadOnlyTxn().Query(ctx, `{ q(func: has(schema_version)) { schema_version } }`)
+	if err != nil {
+		return 0, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []struct {
+			Version int `json:"schema_version"`
+		} `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return 0, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return 0, nil
+	} else if len(r.Q) != 1 {
+		return 0, errors.Errorf("expected one schema version, but found %d", len(r.Q))
+	}
+
+	return r.Q[0].Version, nil
+}
+
+// Migrate runs all migrations newer than the schema version of the database in order, recording
+// the new version after each of them. It refuses to touch a database which is newer than this binary.
+func (dg DGraph) Migrate(ctx context.Context) error {
+	if err := dg.Alter(ctx, &api.Operation{Schema: `schema_version: int .`}); err != nil {
+		return errors.Wrap(err, "applying schema version schema")
+	}
+
+	version, err := dg.SchemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "querying schema version")
+	}
+
+	if version > LatestSchemaVersion() {
+		return errors.Errorf("database schema version %d is newer than supported version %d", version, LatestSchemaVersion())
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if err := migrations[i].run(ctx, dg); err != nil {
+			return errors.Wrapf(err, "applying migration %d (%s)", i+1, migrations[i].description)
+		}
+
+		if err := dg.setSchemaVersion(ctx, i+1); err != nil {
+			return errors.Wrapf(err, "recording migration %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (dg DGraph) setSchemaVersion(ctx context.Context, version int) error {
+	_, err := dg.upsert(ctx, &api.Request{
+		Query: `{ v as var(func: has(schema_version)) }`,
+		Mutations: []*api.Mutation{
+			{
+				Cond:      `@if(eq(len(v), 0))`,
+				SetNquads: []byte(fmt.Sprintf(`_:version <schema_version> "%d" .`, version)),
+			},
+			{
+				Cond:      `@if(gt(len(v), 0))`,
+				SetNquads: []byte(fmt.Sprintf(`uid(v) <schema_version> "%d" .`, version)),
+			},
+		},
+	})
+
+	return err
+}
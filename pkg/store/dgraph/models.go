@@ -1,6 +1,11 @@
 package dgraph
 
-import "github.com/finitum/aurum/pkg/models"
+import (
+	"encoding/json"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/pkg/errors"
+)
 
 type User struct {
 	models.User
@@ -14,16 +19,86 @@ type User struct {
 type Group struct {
 	models.Group
 
+	// Metadata is stored as a JSON object in a string, as Dgraph has no map type.
+	// It hides the Metadata of models.Group from encoding/json.
+	Metadata string `json:"metadata,omitempty"`
+	// PasswordPolicy is stored as a JSON object in a string as well
+	PasswordPolicy string `json:"password_policy,omitempty"`
+
 	Role models.Role `json:"groups|role,omitempty"`
 
 	DType []string `json:"dgraph.type,omitempty"`
 	Uid   string   `json:"uid,omitempty"`
 }
 
+// groupPredicates are the predicates of a group to query
+const groupPredicates = `
+	name
+	allow_registration
+	display_name
+	description
+	metadata
+	require_two_factor
+	require_verified_email
+	password_policy
+`
+
 func NewDGraphUser(user models.User) *User {
 	return &User{User: user, DType: []string{"User"}}
 }
 
 func NewDGraphGroup(group models.Group) *Group {
-	return &Group{Group: group, DType: []string{"Group"}}
+	return &Group{
+		Group:          group,
+		Metadata:       encodeMetadata(group.Metadata),
+		PasswordPolicy: encodePolicy(group.PasswordPolicy),
+		DType:          []string{"Group"},
+	}
+}
+
+// Model converts the group back into a models.Group
+func (g Group) Model() (models.Group, error) {
+	group := g.Group
+	group.Metadata = nil
+
+	if g.Metadata != "" {
+		if err := json.Unmarshal([]byte(g.Metadata), &group.Metadata); err != nil {
+			return models.Group{}, errors.Wrap(err, "json unmarshal metadata")
+		}
+	}
+
+	if len(group.Metadata) == 0 {
+		group.Metadata = nil
+	}
+
+	group.PasswordPolicy = nil
+	if g.PasswordPolicy != "" {
+		if err := json.Unmarshal([]byte(g.PasswordPolicy), &group.PasswordPolicy); err != nil {
+			return models.Group{}, errors.Wrap(err, "json unmarshal password policy")
+		}
+	}
+
+	return group, nil
+}
+
+// encodeMetadata encodes metadata for the metadata predicate
+func encodeMetadata(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+
+	// Marshalling a map of strings can't fail
+	js, _ := json.Marshal(metadata)
+	return string(js)
+}
+
+// encodePolicy encodes a password policy for the password_policy predicate
+func encodePolicy(policy *models.PasswordPolicy) string {
+	if policy == nil {
+		return ""
+	}
+
+	// Marshalling a struct of numbers, bools and strings can't fail
+	js, _ := json.Marshal(policy)
+	return string(js)
 }
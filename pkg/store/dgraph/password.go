@@ -0,0 +1,95 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/pkg/errors"
+)
+
+// PasswordHistory is the node storing the previous passwords of a user. Dgraph lists
+// are unordered, so the hashes are stored as a JSON array in a string.
+type PasswordHistory struct {
+	Username string `json:"password_history_username"`
+	Hashes   string `json:"password_hashes"`
+
+	DType []string `json:"dgraph.type,omitempty"`
+	Uid   string   `json:"uid,omitempty"`
+}
+
+func (dg DGraph) GetPasswordHistory(ctx context.Context, username string) ([]string, error) {
+	query := `
+		query q($uname: string) {
+		  q(func: eq(password_history_username, $uname)) {
+			password_history_username
+			password_hashes
+		  }
+		}
+	`
+
+	resp, err := dg.newBestEffortTxn().QueryWithVars(ctx, query, map[string]string{"$uname": username})
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []PasswordHistory `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return nil, nil
+	} else if len(r.Q) != 1 {
+		return nil, errors.Errorf("expected unique (one) password history of %s, but found %d", username, len(r.Q))
+	}
+
+	var hashes []string
+	if err := json.Unmarshal([]byte(r.Q[0].Hashes), &hashes); err != nil {
+		return nil, errors.Wrap(err, "json unmarshal hashes")
+	}
+
+	if len(hashes) == 0 {
+		hashes = nil
+	}
+
+	return hashes, nil
+}
+
+func (dg DGraph) SetPasswordHistory(ctx context.Context, username string, hashes []string) error {
+	mutations := []*api.Mutation{{
+		Cond:      `@if(gt(len(h), 0))`,
+		DelNquads: []byte("uid(h) * * ."),
+	}}
+
+	// The existing node is replaced by a new one within the same upsert, or just removed
+	if len(hashes) > 0 {
+		encoded, err := json.Marshal(hashes)
+		if err != nil {
+			return errors.Wrap(err, "json marshal hashes")
+		}
+
+		js, err := json.Marshal(PasswordHistory{
+			Username: username,
+			Hashes:   string(encoded),
+			DType:    []string{"PasswordHistory"},
+			Uid:      "_:history",
+		})
+		if err != nil {
+			return errors.Wrap(err, "json marshal")
+		}
+
+		mutations = append(mutations, &api.Mutation{SetJson: js})
+	}
+
+	_, err := dg.upsert(ctx, &api.Request{
+		Query:     `query q($uname: string) { h as var(func: eq(password_history_username, $uname)) }`,
+		Vars:      map[string]string{"$uname": username},
+		Mutations: mutations,
+	})
+
+	return errors.Wrap(err, "upsert")
+}
@@ -0,0 +1,138 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/dgo/v200"
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// RateLimit is the node storing the token bucket of a key
+type RateLimit struct {
+	Key string    `json:"rate_limit_key"`
+	Tat time.Time `json:"rate_limit_tat"`
+
+	DType []string `json:"dgraph.type,omitempty"`
+	Uid   string   `json:"uid,omitempty"`
+}
+
+func (dg DGraph) TakeRateLimitToken(ctx context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	// Within a running transaction the whole transaction is retried instead
+	if dg.inTx() {
+		return dg.takeRateLimitToken(ctx, key, burst, interval, now)
+	}
+
+	// Concurrent requests for the same key conflict, the retry takes the next token
+	var retry time.Duration
+	err := retryAborted(ctx, 0, func() error {
+		var err error
+		retry, err = dg.takeRateLimitToken(ctx, key, burst, interval, now)
+		return err
+	})
+
+	// A bucket which is too contended to take a token from is as good as empty, letting
+	// the request through would defeat the limit when it matters most
+	if errors.Cause(err) == dgo.ErrAborted {
+		return interval, nil
+	}
+
+	return retry, err
+}
+
+func (dg DGraph) takeRateLimitToken(ctx context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
+
+	query := `
+		query q($key: string) {
+		  q(func: eq(rate_limit_key, $key)) {
+			uid
+			rate_limit_key
+			rate_limit_tat
+		  }
+		}
+	`
+
+	resp, err := txn.QueryWithVars(ctx, query, map[string]string{"$key": key})
+	if err != nil {
+		return 0, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []RateLimit `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return 0, errors.Wrap(err, "json unmarshal")
+	}
+
+	node := RateLimit{
+		Key:   key,
+		DType: []string{"RateLimit"},
+		Uid:   newNode,
+	}
+
+	if len(r.Q) == 1 {
+		node.Uid = r.Q[0].Uid
+		node.Tat = r.Q[0].Tat
+	} else if len(r.Q) != 0 {
+		return 0, errors.Errorf("expected unique (one) rate limit for %s, but found %d", key, len(r.Q))
+	}
+
+	var retry time.Duration
+	node.Tat, retry = store.TakeToken(node.Tat, now, burst, interval)
+	if retry > 0 {
+		return retry, nil
+	}
+
+	node.Tat = node.Tat.UTC()
+
+	js, err := json.Marshal(node)
+	if err != nil {
+		return 0, errors.Wrap(err, "json marshal")
+	}
+
+	if _, err := txn.Mutate(ctx, &api.Mutation{
+		SetJson:   js,
+		CommitNow: !dg.inTx(),
+	}); err != nil {
+		return 0, errors.Wrap(err, "mutate")
+	}
+
+	return 0, nil
+}
+
+func (dg DGraph) RemoveExpiredRateLimits(ctx context.Context, now time.Time) (int, error) {
+	q := `
+query q($now: string) {
+	q(func: type(RateLimit)) @filter(le(rate_limit_tat, $now)) {
+		r as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: q,
+		Vars:  map[string]string{"$now": now.UTC().Format(time.RFC3339Nano)},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(gt(len(r), 0))`,
+			DelNquads: []byte("uid(r) * * ."),
+		}},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []RateLimit `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return 0, errors.Wrap(err, "json unmarshal")
+	}
+
+	return len(r.Q), nil
+}
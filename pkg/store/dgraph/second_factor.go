@@ -0,0 +1,139 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// SecondFactor is the node storing the second factor of a user. Its predicates are
+// prefixed where they would otherwise collide with those of users.
+type SecondFactor struct {
+	Username      string   `json:"second_factor_username"`
+	Secret        string   `json:"totp_secret"`
+	Confirmed     bool     `json:"totp_confirmed"`
+	LastStep      int64    `json:"totp_last_step"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+
+	DType []string `json:"dgraph.type,omitempty"`
+	Uid   string   `json:"uid,omitempty"`
+}
+
+// secondFactorPredicates are the predicates of a second factor to query
+const secondFactorPredicates = `
+	second_factor_username
+	totp_secret
+	totp_confirmed
+	totp_last_step
+	recovery_codes
+`
+
+func (dg DGraph) GetSecondFactor(ctx context.Context, username string) (models.SecondFactor, error) {
+	query := `
+		query q($uname: string) {
+		  q(func: eq(second_factor_username, $uname)) {
+			` + secondFactorPredicates + `
+		  }
+		}
+	`
+
+	resp, err := dg.newBestEffortTxn().QueryWithVars(ctx, query, map[string]string{"$uname": username})
+	if err != nil {
+		return models.SecondFactor{}, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []SecondFactor `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return models.SecondFactor{}, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return models.SecondFactor{}, store.ErrNotExists
+	} else if len(r.Q) != 1 {
+		return models.SecondFactor{}, errors.Errorf("expected unique (one) second factor of %s, but found %d", username, len(r.Q))
+	}
+
+	f := r.Q[0]
+	return models.SecondFactor{
+		Username:      f.Username,
+		Secret:        f.Secret,
+		Confirmed:     f.Confirmed,
+		LastStep:      f.LastStep,
+		RecoveryCodes: f.RecoveryCodes,
+	}, nil
+}
+
+func (dg DGraph) SetSecondFactor(ctx context.Context, factor models.SecondFactor) error {
+	// Setting a list predicate adds to it, so rather than updating the existing
+	// node it's replaced by a new one within the same upsert.
+	js, err := json.Marshal(SecondFactor{
+		Username:      factor.Username,
+		Secret:        factor.Secret,
+		Confirmed:     factor.Confirmed,
+		LastStep:      factor.LastStep,
+		RecoveryCodes: factor.RecoveryCodes,
+		DType:         []string{"SecondFactor"},
+		Uid:           "_:factor",
+	})
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
+	}
+
+	_, err = dg.upsert(ctx, &api.Request{
+		Query: `query q($uname: string) { f as var(func: eq(second_factor_username, $uname)) }`,
+		Vars:  map[string]string{"$uname": factor.Username},
+		Mutations: []*api.Mutation{
+			{
+				Cond:      `@if(gt(len(f), 0))`,
+				DelNquads: []byte("uid(f) * * ."),
+			},
+			{
+				SetJson: js,
+			},
+		},
+	})
+
+	return errors.Wrap(err, "upsert")
+}
+
+func (dg DGraph) RemoveSecondFactor(ctx context.Context, username string) error {
+	query := `
+query q($uname: string) {
+	q(func: eq(second_factor_username, $uname)) {
+		f as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: query,
+		Vars:  map[string]string{"$uname": username},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(gt(len(f), 0))`,
+			DelNquads: []byte("uid(f) * * ."),
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []SecondFactor `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return store.ErrNotExists
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/dgo/v200"
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// Session is the node storing a session. Its predicates are prefixed where they
+// would otherwise collide with those of users.
+type Session struct {
+	// Key identifies the session, as session ids are only unique per user
+	Key      string `json:"session_key"`
+	Username string `json:"session_username"`
+	ID       string `json:"session_id"`
+
+	CreatedAt   time.Time `json:"created_at"`
+	LastRefresh time.Time `json:"last_refresh"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+
+	DType []string `json:"dgraph.type,omitempty"`
+	Uid   string   `json:"uid,omitempty"`
+}
+
+// sessionPredicates are the predicates of a session to query
+const sessionPredicates = `
+	session_key
+	session_username
+	session_id
+	created_at
+	last_refresh
+	expires_at
+	ip
+	user_agent
+`
+
+// sessionKey joins the username and id of a session into its key
+func sessionKey(username, id string) string {
+	// Marshalling a slice of strings can't fail
+	js, _ := json.Marshal([]string{username, id})
+	return string(js)
+}
+
+func newDGraphSession(session models.Session) *Session {
+	return &Session{
+		Key:         sessionKey(session.Username, session.ID),
+		Username:    session.Username,
+		ID:          session.ID,
+		CreatedAt:   session.CreatedAt.UTC(),
+		LastRefresh: session.LastRefresh.UTC(),
+		ExpiresAt:   session.ExpiresAt.UTC(),
+		IP:          session.IP,
+		UserAgent:   session.UserAgent,
+		DType:       []string{"Session"},
+	}
+}
+
+// Model converts the session back into a models.Session
+func (s Session) Model() models.Session {
+	return models.Session{
+		ID:          s.ID,
+		Username:    s.Username,
+		CreatedAt:   s.CreatedAt.UTC(),
+		LastRefresh: s.LastRefresh.UTC(),
+		ExpiresAt:   s.ExpiresAt.UTC(),
+		IP:          s.IP,
+		UserAgent:   s.UserAgent,
+	}
+}
+
+func (dg DGraph) getSession(ctx context.Context, txn *dgo.Txn, username, id string) (*Session, error) {
+	query := `
+		query q($key: string) {
+		  q(func: eq(session_key, $key)) {
+			uid
+			` + sessionPredicates + `
+		  }
+		}
+	`
+
+	resp, err := txn.QueryWithVars(ctx, query, map[string]string{"$key": sessionKey(username, id)})
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []Session `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return nil, store.ErrNotExists
+	} else if len(r.Q) != 1 {
+		return nil, errors.Errorf("expected unique (one) session %s of %s, but found %d", id, username, len(r.Q))
+	}
+
+	return &r.Q[0], nil
+}
+
+func (dg DGraph) CreateSession(ctx context.Context, session models.Session) error {
+	node := newDGraphSession(session)
+	node.Uid = newNode
+
+	return dg.createUnique(ctx, "session_key", node.Key, node)
+}
+
+func (dg DGraph) GetSession(ctx context.Context, username, id string) (models.Session, error) {
+	session, err := dg.getSession(ctx, dg.newBestEffortTxn(), username, id)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	return session.Model(), nil
+}
+
+func (dg DGraph) GetSessions(ctx context.Context, username string) ([]models.Session, error) {
+	query := `
+		query q($uname: string) {
+		  q(func: eq(session_username, $uname)) {
+			` + sessionPredicates + `
+		  }
+		}
+	`
+
+	resp, err := dg.newBestEffortTxn().QueryWithVars(ctx, query, map[string]string{"$uname": username})
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []Session `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	sessions := make([]models.Session, 0, len(r.Q))
+	for _, s := range r.Q {
+		sessions = append(sessions, s.Model())
+	}
+
+	store.SortSessions(sessions)
+	return sessions, nil
+}
+
+func (dg DGraph) SetSession(ctx context.Context, session models.Session) error {
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
+
+	curr, err := dg.getSession(ctx, txn, session.Username, session.ID)
+	if err != nil {
+		return err
+	}
+
+	node := newDGraphSession(session)
+	node.Uid = curr.Uid
+
+	js, err := json.Marshal(node)
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
+	}
+
+	_, err = txn.Mutate(ctx, &api.Mutation{
+		SetJson:   js,
+		CommitNow: !dg.inTx(),
+	})
+
+	return errors.Wrap(err, "mutate")
+}
+
+func (dg DGraph) RemoveSession(ctx context.Context, username, id string) error {
+	query := `
+query q($key: string) {
+	q(func: eq(session_key, $key)) {
+		s as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: query,
+		Vars:  map[string]string{"$key": sessionKey(username, id)},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(eq(len(s), 1))`,
+			DelNquads: []byte("uid(s) * * ."),
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []Session `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.Q) == 0 {
+		return store.ErrNotExists
+	} else if len(r.Q) != 1 {
+		return errors.Errorf("expected unique (one) session %s of %s, but found %d", id, username, len(r.Q))
+	}
+
+	return nil
+}
+
+func (dg DGraph) RemoveExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	q := `
+query q($now: string) {
+	q(func: type(Session)) @filter(lt(expires_at, $now)) {
+		s as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: q,
+		Vars:  map[string]string{"$now": now.UTC().Format(time.RFC3339)},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(gt(len(s), 0))`,
+			DelNquads: []byte("uid(s) * * ."),
+		}},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []Session `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return 0, errors.Wrap(err, "json unmarshal")
+	}
+
+	return len(r.Q), nil
+}
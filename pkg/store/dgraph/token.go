@@ -0,0 +1,90 @@
+package dgraph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/dgo/v200/protos/api"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// RevokedToken is the node recording the revocation of a token
+type RevokedToken struct {
+	ID        string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	DType []string `json:"dgraph.type,omitempty"`
+	Uid   string   `json:"uid,omitempty"`
+}
+
+func (dg DGraph) RevokeToken(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	err := dg.createUnique(ctx, "jti", id, &RevokedToken{
+		ID:        id,
+		ExpiresAt: expiresAt.UTC(),
+		DType:     []string{"RevokedToken"},
+		Uid:       newNode,
+	})
+	if err == store.ErrExists {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (dg DGraph) IsTokenRevoked(ctx context.Context, id string) (bool, error) {
+	q := `
+query q($jti: string) {
+	q(func: eq(jti, $jti)) {
+		uid
+	}
+}`
+
+	txn := dg.newBestEffortTxn()
+	resp, err := txn.QueryWithVars(ctx, q, map[string]string{"$jti": id})
+	if err != nil {
+		return false, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []RevokedToken `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return false, errors.Wrap(err, "json unmarshal")
+	}
+
+	return len(r.Q) > 0, nil
+}
+
+func (dg DGraph) RemoveExpiredRevocations(ctx context.Context, now time.Time) (int, error) {
+	q := `
+query q($now: string) {
+	q(func: type(RevokedToken)) @filter(lt(expires_at, $now)) {
+		r as uid
+	}
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: q,
+		Vars:  map[string]string{"$now": now.UTC().Format(time.RFC3339)},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(gt(len(r), 0))`,
+			DelNquads: []byte("uid(r) * * ."),
+		}},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		Q []RevokedToken `json:"q"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return 0, errors.Wrap(err, "json unmarshal")
+	}
+
+	return len(r.Q), nil
+}
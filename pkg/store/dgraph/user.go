@@ -3,6 +3,8 @@ package dgraph
 import (
 	"context"
 	"encoding/json"
+	"sort"
+	"strings"
 
 	"github.com/dgraph-io/dgo/v200"
 	"github.com/dgraph-io/dgo/v200/protos/api"
@@ -19,6 +21,9 @@ query q($uname: string) {
 		username
 		password
 		email
+		email_verified
+		pending_email
+		password_changed_at
 	}
 }`
 
@@ -39,7 +44,7 @@ query q($uname: string) {
 	}
 
 	if len(r.Q) == 0 {
-		return User{}, errors.Errorf("user %s wasn't found", user)
+		return User{}, store.ErrNotExists
 	} else if len(r.Q) != 1 {
 		return User{}, errors.Errorf("expected one unique user %s, but found %d", user, len(r.Q))
 	}
@@ -82,7 +87,7 @@ query q($uname: string, $gname: string) {
 }
 
 func (dg DGraph) GetUser(ctx context.Context, username string) (models.User, error) {
-	txn := dg.NewReadOnlyTxn()
+	txn := dg.newReadOnlyTxn()
 
 	user, err := dg.getUser(ctx, txn, username)
 	if err != nil {
@@ -92,93 +97,63 @@ func (dg DGraph) GetUser(ctx context.Context, username string) (models.User, err
 	return user.User, nil
 }
 
-func (dg DGraph) GetUsers(ctx context.Context) ([]models.User, error) {
-	query := `
-		{
-			q(func: type(User)) {
-				username
-				password
-				email
-			}
+func (dg DGraph) GetUsers(ctx context.Context, query models.UserQuery) (models.UserPage, error) {
+	// Searching and paginating happens in store.PageUsers, which needs all
+	// users and the roles of those in the group to filter on.
+	q := `
+query q($gname: string) {
+	q(func: type(User)) {
+		username
+		email
+		email_verified
+		pending_email
+		password_changed_at
+		groups @facets(role) @filter(eq(name, $gname)) {
+			name
 		}
-	`
+	}
+}`
 
-	txn := dg.NewReadOnlyTxn().BestEffort()
-	resp, err := txn.Query(ctx, query)
+	txn := dg.newBestEffortTxn()
+	resp, err := txn.QueryWithVars(ctx, q, map[string]string{"$gname": query.Group})
 	if err != nil {
-		return nil, errors.Wrap(err, "query")
+		return models.UserPage{}, errors.Wrap(err, "query")
 	}
 
 	var r struct {
-		Q []models.User `json:"q"`
+		Q []User `json:"q"`
 	}
 
 	err = json.Unmarshal(resp.Json, &r)
 	if err != nil {
-		return nil, errors.Wrap(err, "json unmarshal")
-	}
-
-	return r.Q, nil
-}
-
-func (dg DGraph) CreateUser(ctx context.Context, user models.User) error {
-	// start a new transaction
-	txn := dg.NewTxn()
-	defer txn.Discard(ctx)
-
-	// query the database for the number of users that exist with either the same user id
-	// or the same username
-	query := `
-		query q($uname: string) {
-		  Q(func: eq(username, $uname)) {
-			count(uid)
-		  }
-		}
-	`
-	variables := map[string]string{
-		"$uname": user.Username,
-	}
-
-	resp, err := txn.QueryWithVars(ctx, query, variables)
-	if err != nil {
-		return errors.Wrap(err, "query")
+		return models.UserPage{}, errors.Wrap(err, "json unmarshal")
 	}
 
-	var r struct {
-		Q []struct {
-			Count int `json:"count"`
+	users := make([]models.User, 0, len(r.Q))
+	roles := make(map[string]models.Role)
+	for _, u := range r.Q {
+		users = append(users, u.User)
+		if len(u.Groups) == 1 {
+			roles[u.Username] = u.Groups[0].Role
 		}
 	}
 
-	err = json.Unmarshal(resp.Json, &r)
-	if err != nil {
-		return errors.Wrap(err, "json unmarshal")
-	}
-
-	// If there exists 1 or more users with this username, fail
-	if len(r.Q) != 1 || r.Q[0].Count > 0 {
-		return store.ErrExists
-	}
+	return store.PageUsers(users, query, func(user string) (models.Role, bool) {
+		role, ok := roles[user]
+		return role, ok
+	})
+}
 
-	// Add the new user to the database
+func (dg DGraph) CreateUser(ctx context.Context, user models.User) error {
 	dUser := NewDGraphUser(user)
+	dUser.Uid = newNode
 
-	js, err := json.Marshal(dUser)
-	if err != nil {
-		return err
-	}
-
-	mu := &api.Mutation{
-		CommitNow: true,
-		SetJson:   js,
-	}
-
-	_, err = txn.Mutate(ctx, mu)
-	return errors.Wrap(err, "mutate")
+	return dg.createUnique(ctx, "username", user.Username, dUser)
 }
 
 func (dg DGraph) SetUser(ctx context.Context, user models.User) (models.User, error) {
-	txn := dg.NewTxn()
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
 
 	currUser, err := dg.getUser(ctx, txn, user.Username)
 	if err != nil {
@@ -193,6 +168,10 @@ func (dg DGraph) SetUser(ctx context.Context, user models.User) (models.User, er
 		currUser.Email = user.Email
 	}
 
+	if !user.PasswordChangedAt.IsZero() {
+		currUser.PasswordChangedAt = user.PasswordChangedAt
+	}
+
 	js, err := json.Marshal(&currUser)
 	if err != nil {
 		return models.User{}, err
@@ -200,7 +179,7 @@ func (dg DGraph) SetUser(ctx context.Context, user models.User) (models.User, er
 
 	_, err = txn.Mutate(ctx, &api.Mutation{
 		SetJson:   js,
-		CommitNow: true,
+		CommitNow: !dg.inTx(),
 	})
 	if err != nil {
 		return models.User{}, err
@@ -209,31 +188,103 @@ func (dg DGraph) SetUser(ctx context.Context, user models.User) (models.User, er
 	return currUser.User, nil
 }
 
-func (dg DGraph) RemoveUser(ctx context.Context, username string) error {
-	txn := dg.NewTxn()
+func (dg DGraph) SetUserEmail(ctx context.Context, username, email, pending string, verified bool) error {
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
 
-	user, err := dg.getUser(ctx, txn, username)
+	curr, err := dg.getUser(ctx, txn, username)
 	if err != nil {
-		return errors.Wrap(err, "get user (internal)")
+		return err
 	}
 
-	d := map[string]string{"uid": user.Uid}
-	js, err := json.Marshal(d)
+	// User omits empty values, so clearing a field wouldn't be stored
+	js, err := json.Marshal(map[string]interface{}{
+		"uid":            curr.Uid,
+		"email":          email,
+		"pending_email":  pending,
+		"email_verified": verified,
+	})
 	if err != nil {
 		return errors.Wrap(err, "json marshal")
 	}
 
-	mu := &api.Mutation{
-		CommitNow:  true,
-		DeleteJson: js,
+	_, err = txn.Mutate(ctx, &api.Mutation{
+		SetJson:   js,
+		CommitNow: !dg.inTx(),
+	})
+
+	return errors.Wrap(err, "mutate")
+}
+
+func (dg DGraph) RemoveUser(ctx context.Context, username string) (models.RemovalSummary, error) {
+	// Deleting all predicates of the user also deletes its edges to groups. The nodes which
+	// belong to the user by name are deleted along with it.
+	query := `
+query q($uname: string) {
+	user(func: eq(username, $uname)) {
+		u as uid
+		groups @facets(role) @filter(has(name)) {
+			name
+		}
+	}
+	s as var(func: eq(session_username, $uname))
+	f as var(func: eq(second_factor_username, $uname))
+	h as var(func: eq(password_history_username, $uname))
+}`
+
+	resp, err := dg.upsert(ctx, &api.Request{
+		Query: query,
+		Vars:  map[string]string{"$uname": username},
+		Mutations: []*api.Mutation{{
+			Cond:      `@if(eq(len(u), 1))`,
+			DelNquads: []byte("uid(u) * * ."),
+		}, {
+			Cond:      `@if(eq(len(u), 1) AND gt(len(s), 0))`,
+			DelNquads: []byte("uid(s) * * ."),
+		}, {
+			Cond:      `@if(eq(len(u), 1) AND gt(len(f), 0))`,
+			DelNquads: []byte("uid(f) * * ."),
+		}, {
+			Cond:      `@if(eq(len(u), 1) AND gt(len(h), 0))`,
+			DelNquads: []byte("uid(h) * * ."),
+		}},
+	})
+	if err != nil {
+		return models.RemovalSummary{}, errors.Wrap(err, "upsert")
+	}
+
+	var r struct {
+		User []User `json:"user"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &r); err != nil {
+		return models.RemovalSummary{}, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(r.User) == 0 {
+		return models.RemovalSummary{}, store.ErrNotExists
+	} else if len(r.User) != 1 {
+		return models.RemovalSummary{}, errors.Errorf("expected one unique user %s, but found %d", username, len(r.User))
 	}
 
-	_, err = txn.Mutate(ctx, mu)
-	return errors.Wrap(err, "delete")
+	summary := models.RemovalSummary{Users: []string{username}}
+	for _, g := range r.User[0].Groups {
+		summary.Memberships = append(summary.Memberships, models.Membership{
+			Username:  username,
+			GroupName: g.Name,
+			Role:      g.Role,
+		})
+	}
+
+	sort.Slice(summary.Memberships, func(i, j int) bool {
+		return summary.Memberships[i].GroupName < summary.Memberships[j].GroupName
+	})
+
+	return summary, nil
 }
 
 func (dg DGraph) GetGroupRole(ctx context.Context, group string, user string) (models.Role, error) {
-	txn := dg.NewReadOnlyTxn().BestEffort()
+	txn := dg.newBestEffortTxn()
 
 	u, err := dg.getUserWithGroups(ctx, txn, user, group)
 	if err != nil {
@@ -245,8 +296,8 @@ func (dg DGraph) GetGroupRole(ctx context.Context, group string, user string) (m
 
 func (dg DGraph) AddGroupToUser(ctx context.Context, user string, group string, role models.Role) error {
 	// start a new transaction
-	txn := dg.NewTxn()
-	defer txn.Discard(ctx)
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
 
 	q := `
 query q($uname: string, $gname: string) {
@@ -279,7 +330,7 @@ query q($uname: string, $gname: string) {
 	}
 
 	if len(r.User) != 1 || len(r.Group) != 1 {
-		return errors.New("Couldn't find user or group")
+		return store.ErrNotExists
 	}
 
 	r.Group[0].Role = role
@@ -287,11 +338,11 @@ query q($uname: string, $gname: string) {
 
 	js, err := json.Marshal(&r.User[0])
 	if err != nil {
-		return nil
+		return err
 	}
 
 	mu := &api.Mutation{
-		CommitNow: true,
+		CommitNow: !dg.inTx(),
 		SetJson:   js,
 	}
 
@@ -308,10 +359,13 @@ func (dg DGraph) SetGroupRole(ctx context.Context, group string, user string, ro
 
 func (dg DGraph) RemoveGroupFromUser(ctx context.Context, group string, user string) error {
 	// start a new transaction
-	txn := dg.NewTxn()
-	defer txn.Discard(ctx)
+	txn := dg.newTxn()
+	defer dg.discard(ctx, txn)
 
 	u, err := dg.getUserWithGroups(ctx, txn, user, group)
+	if err != nil {
+		return err
+	}
 
 	js, err := json.Marshal(&u)
 	if err != nil {
@@ -320,7 +374,7 @@ func (dg DGraph) RemoveGroupFromUser(ctx context.Context, group string, user str
 
 	_, err = txn.Mutate(ctx, &api.Mutation{
 		DeleteJson: js,
-		CommitNow:  true,
+		CommitNow:  !dg.inTx(),
 	})
 
 	return errors.Wrap(err, "mutate")
@@ -335,8 +389,8 @@ func (dg DGraph) CountUsers(ctx context.Context) (int, error) {
 }
 	`
 
-	txn := dg.NewReadOnlyTxn().BestEffort()
-	defer txn.Discard(ctx)
+	txn := dg.newBestEffortTxn()
+	defer dg.discard(ctx, txn)
 
 	resp, err := txn.Query(ctx, query)
 	if err != nil {
@@ -360,3 +414,42 @@ func (dg DGraph) CountUsers(ctx context.Context) (int, error) {
 
 	return r.Q[0].Count, nil
 }
+
+func (dg DGraph) CountPasswordsWithPrefix(ctx context.Context, prefix string) (int, error) {
+	// The password isn't indexed, so the prefixes are compared here
+	query := `
+{
+	Q(func: type(User)) {
+		password
+	}
+}
+	`
+
+	txn := dg.newBestEffortTxn()
+	defer dg.discard(ctx, txn)
+
+	resp, err := txn.Query(ctx, query)
+	if err != nil {
+		return -1, errors.Wrap(err, "query")
+	}
+
+	var r struct {
+		Q []struct {
+			Password string `json:"password"`
+		}
+	}
+
+	err = json.Unmarshal(resp.Json, &r)
+	if err != nil {
+		return -1, errors.Wrap(err, "json unmarshal")
+	}
+
+	var n int
+	for _, u := range r.Q {
+		if strings.HasPrefix(u.Password, prefix) {
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+func (m *Memory) CreateGroup(_ context.Context, group models.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[group.Name]; ok {
+		return store.ErrExists
+	}
+
+	m.groups[group.Name] = copyGroup(group)
+	return nil
+}
+
+func (m *Memory) SetGroup(_ context.Context, group models.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[group.Name]; !ok {
+		return store.ErrNotExists
+	}
+
+	m.groups[group.Name] = copyGroup(group)
+	return nil
+}
+
+// copyGroup copies the metadata and password policy of group, so the caller can't change the stored
+// group through them. Stored groups are never changed in place, which allows clone to share them.
+func copyGroup(group models.Group) models.Group {
+	if group.PasswordPolicy != nil {
+		policy := *group.PasswordPolicy
+		policy.BannedWords = append([]string(nil), policy.BannedWords...)
+		group.PasswordPolicy = &policy
+	}
+
+	if len(group.Metadata) == 0 {
+		group.Metadata = nil
+		return group
+	}
+
+	metadata := make(map[string]string, len(group.Metadata))
+	for k, v := range group.Metadata {
+		metadata[k] = v
+	}
+	group.Metadata = metadata
+
+	return group
+}
+
+func (m *Memory) RemoveGroup(_ context.Context, group string) (models.RemovalSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[group]; !ok {
+		return models.RemovalSummary{}, store.ErrNotExists
+	}
+
+	summary := models.RemovalSummary{Groups: []string{group}}
+
+	delete(m.groups, group)
+	for user, groups := range m.roles {
+		if role, ok := groups[group]; ok {
+			summary.Memberships = append(summary.Memberships, models.Membership{
+				Username:  user,
+				GroupName: group,
+				Role:      role,
+			})
+			delete(groups, group)
+		}
+	}
+
+	sort.Slice(summary.Memberships, func(i, j int) bool {
+		return summary.Memberships[i].Username < summary.Memberships[j].Username
+	})
+
+	return summary, nil
+}
+
+func (m *Memory) GetGroup(_ context.Context, group string) (*models.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	g, ok := m.groups[group]
+	if !ok {
+		return nil, store.ErrNotExists
+	}
+
+	return &g, nil
+}
+
+func (m *Memory) GetGroups(_ context.Context, query models.GroupQuery) (models.GroupPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make([]models.Group, 0, len(m.groups))
+	for _, g := range m.groups {
+		groups = append(groups, g)
+	}
+
+	return store.PageGroups(groups, query)
+}
+
+func (m *Memory) GetGroupMembers(_ context.Context, group string, query models.MemberQuery) (models.MemberPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.groups[group]; !ok {
+		return models.MemberPage{}, store.ErrNotExists
+	}
+
+	var users []models.User
+	for name, roles := range m.roles {
+		if _, ok := roles[group]; ok {
+			users = append(users, m.users[name])
+		}
+	}
+
+	return store.PageMembers(users, group, query, func(user string) (models.Role, bool) {
+		role, ok := m.roles[user][group]
+		return role, ok
+	})
+}
+
+func (m *Memory) GetGroupsForUser(_ context.Context, user string) ([]models.GroupWithRole, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	roles := m.roles[user]
+	if len(roles) == 0 {
+		return nil, store.ErrNotExists
+	}
+
+	groups := make([]models.GroupWithRole, 0, len(roles))
+	for name, role := range roles {
+		groups = append(groups, models.GroupWithRole{
+			Group: m.groups[name],
+			Role:  role,
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, nil
+}
+
+func (m *Memory) AddGroupToUser(_ context.Context, user string, group string, role models.Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user]; !ok {
+		return store.ErrNotExists
+	}
+	if _, ok := m.groups[group]; !ok {
+		return store.ErrNotExists
+	}
+
+	roles, ok := m.roles[user]
+	if !ok {
+		roles = make(map[string]models.Role)
+		m.roles[user] = roles
+	}
+	roles[group] = role
+
+	return nil
+}
+
+func (m *Memory) RemoveGroupFromUser(_ context.Context, group string, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.roles[user][group]; !ok {
+		return store.ErrNotExists
+	}
+
+	delete(m.roles[user], group)
+	return nil
+}
+
+func (m *Memory) GetGroupRole(_ context.Context, group string, user string) (models.Role, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	role, ok := m.roles[user][group]
+	if !ok {
+		return 0, store.ErrNotExists
+	}
+
+	return role, nil
+}
+
+func (m *Memory) SetGroupRole(ctx context.Context, group string, user string, role models.Role) error {
+	return m.AddGroupToUser(ctx, user, group, role)
+}
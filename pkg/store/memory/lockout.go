@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+func (m *Memory) AddLoginFailure(_ context.Context, key string, at, since time.Time) (models.LoginFailures, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failures, ok := m.loginFailures[key]
+	if !ok || failures.LastFailure.Before(since) {
+		failures = models.LoginFailures{Key: key}
+	}
+
+	failures.Count++
+	failures.LastFailure = at
+
+	m.loginFailures[key] = failures
+	return failures, nil
+}
+
+func (m *Memory) GetLoginFailures(_ context.Context, key string) (models.LoginFailures, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	failures, ok := m.loginFailures[key]
+	if !ok {
+		return models.LoginFailures{}, store.ErrNotExists
+	}
+
+	return failures, nil
+}
+
+func (m *Memory) ListLoginFailures(_ context.Context, since time.Time) ([]models.LoginFailures, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]models.LoginFailures, 0, len(m.loginFailures))
+	for _, failures := range m.loginFailures {
+		if !failures.LastFailure.Before(since) {
+			list = append(list, failures)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+
+	return list, nil
+}
+
+func (m *Memory) RemoveLoginFailures(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.loginFailures[key]; !ok {
+		return store.ErrNotExists
+	}
+
+	delete(m.loginFailures, key)
+	return nil
+}
+
+func (m *Memory) RemoveExpiredLoginFailures(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int
+	for key, failures := range m.loginFailures {
+		if failures.LastFailure.Before(before) {
+			delete(m.loginFailures, key)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
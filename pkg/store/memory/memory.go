@@ -0,0 +1,129 @@
+// Package memory implements an AurumStore which keeps all its data in memory.
+// Nothing is persisted, which makes it useful for tests, demos and embedding Aurum
+// without the need for an external database.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+type Memory struct {
+	mu sync.RWMutex
+
+	users  map[string]models.User
+	groups map[string]models.Group
+
+	// roles maps a username to the groups that user is in, and the role it has within them
+	roles map[string]map[string]models.Role
+
+	// revoked maps the ids of revoked tokens to the time they expire
+	revoked map[string]time.Time
+
+	// sessions maps a username to the sessions of that user by their id
+	sessions map[string]map[string]models.Session
+
+	// secondFactors maps a username to the second factor of that user
+	secondFactors map[string]models.SecondFactor
+
+	// passwordHistory maps a username to the hashes of the previous passwords of that user
+	passwordHistory map[string][]string
+
+	// loginFailures maps a key to the failed logins counted for it
+	loginFailures map[string]models.LoginFailures
+
+	// rateLimits maps a key to the time its token bucket is full again
+	rateLimits map[string]time.Time
+}
+
+func New() *Memory {
+	return &Memory{
+		users:           make(map[string]models.User),
+		groups:          make(map[string]models.Group),
+		roles:           make(map[string]map[string]models.Role),
+		revoked:         make(map[string]time.Time),
+		sessions:        make(map[string]map[string]models.Session),
+		secondFactors:   make(map[string]models.SecondFactor),
+		passwordHistory: make(map[string][]string),
+		loginFailures:   make(map[string]models.LoginFailures),
+		rateLimits:      make(map[string]time.Time),
+	}
+}
+
+// WithTx runs fn against a copy of the store, while holding the lock on the original for
+// the whole transaction. The copy replaces the contents of the original only if fn succeeds.
+func (m *Memory) WithTx(_ context.Context, fn func(tx store.AurumStore) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := m.clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	m.users = tx.users
+	m.groups = tx.groups
+	m.roles = tx.roles
+	m.revoked = tx.revoked
+	m.sessions = tx.sessions
+	m.secondFactors = tx.secondFactors
+	m.passwordHistory = tx.passwordHistory
+	m.loginFailures = tx.loginFailures
+	m.rateLimits = tx.rateLimits
+
+	return nil
+}
+
+// clone makes a deep copy of the store. The caller must hold the lock.
+func (m *Memory) clone() *Memory {
+	c := New()
+
+	for k, v := range m.users {
+		c.users[k] = v
+	}
+
+	for k, v := range m.groups {
+		c.groups[k] = v
+	}
+
+	for id, expiresAt := range m.revoked {
+		c.revoked[id] = expiresAt
+	}
+
+	for user, sessions := range m.sessions {
+		c.sessions[user] = make(map[string]models.Session, len(sessions))
+		for id, session := range sessions {
+			c.sessions[user][id] = session
+		}
+	}
+
+	for user, factor := range m.secondFactors {
+		c.secondFactors[user] = factor
+	}
+
+	// The history slices are never modified, only replaced, so they can be shared
+	for user, hashes := range m.passwordHistory {
+		c.passwordHistory[user] = hashes
+	}
+
+	for key, failures := range m.loginFailures {
+		c.loginFailures[key] = failures
+	}
+
+	for key, tat := range m.rateLimits {
+		c.rateLimits[key] = tat
+	}
+
+	for user, groups := range m.roles {
+		c.roles[user] = make(map[string]models.Role, len(groups))
+		for group, role := range groups {
+			c.roles[user][group] = role
+		}
+	}
+
+	return c
+}
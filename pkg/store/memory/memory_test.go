@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/storetest"
+)
+
+func TestMemory(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.AurumStore {
+		return New()
+	})
+}
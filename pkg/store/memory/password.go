@@ -0,0 +1,28 @@
+package memory
+
+import "context"
+
+func (m *Memory) GetPasswordHistory(_ context.Context, username string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hashes := m.passwordHistory[username]
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	return append([]string(nil), hashes...), nil
+}
+
+func (m *Memory) SetPasswordHistory(_ context.Context, username string, hashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(hashes) == 0 {
+		delete(m.passwordHistory, username)
+		return nil
+	}
+
+	m.passwordHistory[username] = append([]string(nil), hashes...)
+	return nil
+}
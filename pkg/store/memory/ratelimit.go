@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/finitum/aurum/pkg/store"
+)
+
+func (m *Memory) TakeRateLimitToken(_ context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tat, retry := store.TakeToken(m.rateLimits[key], now, burst, interval)
+	m.rateLimits[key] = tat
+
+	return retry, nil
+}
+
+func (m *Memory) RemoveExpiredRateLimits(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int
+	for key, tat := range m.rateLimits {
+		if !tat.After(now) {
+			delete(m.rateLimits, key)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+// copyFactor copies the recovery codes of factor, so the stored factor doesn't share them with the caller
+func copyFactor(factor models.SecondFactor) models.SecondFactor {
+	if factor.RecoveryCodes != nil {
+		factor.RecoveryCodes = append([]string(nil), factor.RecoveryCodes...)
+	}
+
+	return factor
+}
+
+func (m *Memory) GetSecondFactor(_ context.Context, username string) (models.SecondFactor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	factor, ok := m.secondFactors[username]
+	if !ok {
+		return models.SecondFactor{}, store.ErrNotExists
+	}
+
+	return copyFactor(factor), nil
+}
+
+func (m *Memory) SetSecondFactor(_ context.Context, factor models.SecondFactor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.secondFactors[factor.Username] = copyFactor(factor)
+	return nil
+}
+
+func (m *Memory) RemoveSecondFactor(_ context.Context, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.secondFactors[username]; !ok {
+		return store.ErrNotExists
+	}
+
+	delete(m.secondFactors, username)
+	return nil
+}
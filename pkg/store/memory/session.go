@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+func (m *Memory) CreateSession(_ context.Context, session models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions, ok := m.sessions[session.Username]
+	if !ok {
+		sessions = make(map[string]models.Session)
+		m.sessions[session.Username] = sessions
+	}
+
+	if _, ok := sessions[session.ID]; ok {
+		return store.ErrExists
+	}
+
+	sessions[session.ID] = session
+	return nil
+}
+
+func (m *Memory) GetSession(_ context.Context, username, id string) (models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[username][id]
+	if !ok {
+		return models.Session{}, store.ErrNotExists
+	}
+
+	return session, nil
+}
+
+func (m *Memory) GetSessions(_ context.Context, username string) ([]models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]models.Session, 0, len(m.sessions[username]))
+	for _, session := range m.sessions[username] {
+		sessions = append(sessions, session)
+	}
+
+	store.SortSessions(sessions)
+	return sessions, nil
+}
+
+func (m *Memory) SetSession(_ context.Context, session models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[session.Username][session.ID]; !ok {
+		return store.ErrNotExists
+	}
+
+	m.sessions[session.Username][session.ID] = session
+	return nil
+}
+
+func (m *Memory) RemoveSession(_ context.Context, username, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[username][id]; !ok {
+		return store.ErrNotExists
+	}
+
+	delete(m.sessions[username], id)
+	if len(m.sessions[username]) == 0 {
+		delete(m.sessions, username)
+	}
+
+	return nil
+}
+
+func (m *Memory) RemoveExpiredSessions(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int
+	for username, sessions := range m.sessions {
+		for id, session := range sessions {
+			if session.ExpiresAt.Before(now) {
+				delete(sessions, id)
+				removed++
+			}
+		}
+
+		if len(sessions) == 0 {
+			delete(m.sessions, username)
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+func (m *Memory) RevokeToken(_ context.Context, id string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.revoked[id]; ok {
+		return false, nil
+	}
+
+	m.revoked[id] = expiresAt
+	return true, nil
+}
+
+func (m *Memory) IsTokenRevoked(_ context.Context, id string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.revoked[id]
+	return ok, nil
+}
+
+func (m *Memory) RemoveExpiredRevocations(_ context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int
+	for id, expiresAt := range m.revoked {
+		if expiresAt.Before(now) {
+			delete(m.revoked, id)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+func (m *Memory) CreateUser(_ context.Context, user models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.Username]; ok {
+		return store.ErrExists
+	}
+
+	m.users[user.Username] = user
+	return nil
+}
+
+func (m *Memory) RemoveUser(_ context.Context, user string) (models.RemovalSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user]; !ok {
+		return models.RemovalSummary{}, store.ErrNotExists
+	}
+
+	summary := models.RemovalSummary{Users: []string{user}}
+	for group, role := range m.roles[user] {
+		summary.Memberships = append(summary.Memberships, models.Membership{
+			Username:  user,
+			GroupName: group,
+			Role:      role,
+		})
+	}
+
+	sort.Slice(summary.Memberships, func(i, j int) bool {
+		return summary.Memberships[i].GroupName < summary.Memberships[j].GroupName
+	})
+
+	delete(m.users, user)
+	delete(m.roles, user)
+	delete(m.sessions, user)
+	delete(m.secondFactors, user)
+	delete(m.passwordHistory, user)
+
+	return summary, nil
+}
+
+func (m *Memory) GetUser(_ context.Context, user string) (models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[user]
+	if !ok {
+		return models.User{}, store.ErrNotExists
+	}
+
+	return u, nil
+}
+
+func (m *Memory) GetUsers(_ context.Context, query models.UserQuery) (models.UserPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]models.User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u)
+	}
+
+	return store.PageUsers(users, query, func(user string) (models.Role, bool) {
+		role, ok := m.roles[user][query.Group]
+		return role, ok
+	})
+}
+
+func (m *Memory) SetUser(_ context.Context, user models.User) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	curr, ok := m.users[user.Username]
+	if !ok {
+		return models.User{}, store.ErrNotExists
+	}
+
+	if user.Password != "" {
+		curr.Password = user.Password
+	}
+
+	if user.Email != "" {
+		curr.Email = user.Email
+	}
+
+	if !user.PasswordChangedAt.IsZero() {
+		curr.PasswordChangedAt = user.PasswordChangedAt
+	}
+
+	m.users[user.Username] = curr
+	return curr, nil
+}
+
+func (m *Memory) SetUserEmail(_ context.Context, username, email, pending string, verified bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	curr, ok := m.users[username]
+	if !ok {
+		return store.ErrNotExists
+	}
+
+	curr.Email = email
+	curr.PendingEmail = pending
+	curr.EmailVerified = verified
+
+	m.users[username] = curr
+	return nil
+}
+
+func (m *Memory) CountUsers(_ context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.users), nil
+}
+
+func (m *Memory) CountPasswordsWithPrefix(_ context.Context, prefix string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var n int
+	for _, u := range m.users {
+		if strings.HasPrefix(u.Password, prefix) {
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -7,8 +7,10 @@ package mock_store
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/finitum/aurum/pkg/models"
+	store "github.com/finitum/aurum/pkg/store"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -49,6 +51,36 @@ func (mr *MockAurumStoreMockRecorder) AddGroupToUser(arg0, arg1, arg2, arg3 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupToUser", reflect.TypeOf((*MockAurumStore)(nil).AddGroupToUser), arg0, arg1, arg2, arg3)
 }
 
+// AddLoginFailure mocks base method
+func (m *MockAurumStore) AddLoginFailure(arg0 context.Context, arg1 string, arg2, arg3 time.Time) (models.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure
+func (mr *MockAurumStoreMockRecorder) AddLoginFailure(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockAurumStore)(nil).AddLoginFailure), arg0, arg1, arg2, arg3)
+}
+
+// CountPasswordsWithPrefix mocks base method
+func (m *MockAurumStore) CountPasswordsWithPrefix(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPasswordsWithPrefix", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPasswordsWithPrefix indicates an expected call of CountPasswordsWithPrefix
+func (mr *MockAurumStoreMockRecorder) CountPasswordsWithPrefix(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPasswordsWithPrefix", reflect.TypeOf((*MockAurumStore)(nil).CountPasswordsWithPrefix), arg0, arg1)
+}
+
 // CountUsers mocks base method
 func (m *MockAurumStore) CountUsers(arg0 context.Context) (int, error) {
 	m.ctrl.T.Helper()
@@ -78,6 +110,20 @@ func (mr *MockAurumStoreMockRecorder) CreateGroup(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockAurumStore)(nil).CreateGroup), arg0, arg1)
 }
 
+// CreateSession mocks base method
+func (m *MockAurumStore) CreateSession(arg0 context.Context, arg1 models.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession
+func (mr *MockAurumStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockAurumStore)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateUser mocks base method
 func (m *MockAurumStore) CreateUser(arg0 context.Context, arg1 models.User) error {
 	m.ctrl.T.Helper()
@@ -107,6 +153,21 @@ func (mr *MockAurumStoreMockRecorder) GetGroup(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockAurumStore)(nil).GetGroup), arg0, arg1)
 }
 
+// GetGroupMembers mocks base method
+func (m *MockAurumStore) GetGroupMembers(arg0 context.Context, arg1 string, arg2 models.MemberQuery) (models.MemberPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMembers", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.MemberPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupMembers indicates an expected call of GetGroupMembers
+func (mr *MockAurumStoreMockRecorder) GetGroupMembers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMembers", reflect.TypeOf((*MockAurumStore)(nil).GetGroupMembers), arg0, arg1, arg2)
+}
+
 // GetGroupRole mocks base method
 func (m *MockAurumStore) GetGroupRole(arg0 context.Context, arg1, arg2 string) (models.Role, error) {
 	m.ctrl.T.Helper()
@@ -123,18 +184,18 @@ func (mr *MockAurumStoreMockRecorder) GetGroupRole(arg0, arg1, arg2 interface{})
 }
 
 // GetGroups mocks base method
-func (m *MockAurumStore) GetGroups(arg0 context.Context) ([]models.Group, error) {
+func (m *MockAurumStore) GetGroups(arg0 context.Context, arg1 models.GroupQuery) (models.GroupPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetGroups", arg0)
-	ret0, _ := ret[0].([]models.Group)
+	ret := m.ctrl.Call(m, "GetGroups", arg0, arg1)
+	ret0, _ := ret[0].(models.GroupPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetGroups indicates an expected call of GetGroups
-func (mr *MockAurumStoreMockRecorder) GetGroups(arg0 interface{}) *gomock.Call {
+func (mr *MockAurumStoreMockRecorder) GetGroups(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockAurumStore)(nil).GetGroups), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockAurumStore)(nil).GetGroups), arg0, arg1)
 }
 
 // GetGroupsForUser mocks base method
@@ -152,6 +213,81 @@ func (mr *MockAurumStoreMockRecorder) GetGroupsForUser(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupsForUser", reflect.TypeOf((*MockAurumStore)(nil).GetGroupsForUser), arg0, arg1)
 }
 
+// GetLoginFailures mocks base method
+func (m *MockAurumStore) GetLoginFailures(arg0 context.Context, arg1 string) (models.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(models.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginFailures indicates an expected call of GetLoginFailures
+func (mr *MockAurumStoreMockRecorder) GetLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginFailures", reflect.TypeOf((*MockAurumStore)(nil).GetLoginFailures), arg0, arg1)
+}
+
+// GetPasswordHistory mocks base method
+func (m *MockAurumStore) GetPasswordHistory(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordHistory", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordHistory indicates an expected call of GetPasswordHistory
+func (mr *MockAurumStoreMockRecorder) GetPasswordHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordHistory", reflect.TypeOf((*MockAurumStore)(nil).GetPasswordHistory), arg0, arg1)
+}
+
+// GetSecondFactor mocks base method
+func (m *MockAurumStore) GetSecondFactor(arg0 context.Context, arg1 string) (models.SecondFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecondFactor", arg0, arg1)
+	ret0, _ := ret[0].(models.SecondFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecondFactor indicates an expected call of GetSecondFactor
+func (mr *MockAurumStoreMockRecorder) GetSecondFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecondFactor", reflect.TypeOf((*MockAurumStore)(nil).GetSecondFactor), arg0, arg1)
+}
+
+// GetSession mocks base method
+func (m *MockAurumStore) GetSession(arg0 context.Context, arg1, arg2 string) (models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession
+func (mr *MockAurumStoreMockRecorder) GetSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockAurumStore)(nil).GetSession), arg0, arg1, arg2)
+}
+
+// GetSessions mocks base method
+func (m *MockAurumStore) GetSessions(arg0 context.Context, arg1 string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", arg0, arg1)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions
+func (mr *MockAurumStoreMockRecorder) GetSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockAurumStore)(nil).GetSessions), arg0, arg1)
+}
+
 // GetUser mocks base method
 func (m *MockAurumStore) GetUser(arg0 context.Context, arg1 string) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -168,26 +304,117 @@ func (mr *MockAurumStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Ca
 }
 
 // GetUsers mocks base method
-func (m *MockAurumStore) GetUsers(arg0 context.Context) ([]models.User, error) {
+func (m *MockAurumStore) GetUsers(arg0 context.Context, arg1 models.UserQuery) (models.UserPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", arg0)
-	ret0, _ := ret[0].([]models.User)
+	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1)
+	ret0, _ := ret[0].(models.UserPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers
-func (mr *MockAurumStoreMockRecorder) GetUsers(arg0 interface{}) *gomock.Call {
+func (mr *MockAurumStoreMockRecorder) GetUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAurumStore)(nil).GetUsers), arg0, arg1)
+}
+
+// IsTokenRevoked mocks base method
+func (m *MockAurumStore) IsTokenRevoked(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked
+func (mr *MockAurumStoreMockRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAurumStore)(nil).GetUsers), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockAurumStore)(nil).IsTokenRevoked), arg0, arg1)
+}
+
+// ListLoginFailures mocks base method
+func (m *MockAurumStore) ListLoginFailures(arg0 context.Context, arg1 time.Time) ([]models.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].([]models.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginFailures indicates an expected call of ListLoginFailures
+func (mr *MockAurumStoreMockRecorder) ListLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginFailures", reflect.TypeOf((*MockAurumStore)(nil).ListLoginFailures), arg0, arg1)
+}
+
+// RemoveExpiredLoginFailures mocks base method
+func (m *MockAurumStore) RemoveExpiredLoginFailures(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveExpiredLoginFailures indicates an expected call of RemoveExpiredLoginFailures
+func (mr *MockAurumStoreMockRecorder) RemoveExpiredLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredLoginFailures", reflect.TypeOf((*MockAurumStore)(nil).RemoveExpiredLoginFailures), arg0, arg1)
+}
+
+// RemoveExpiredRateLimits mocks base method
+func (m *MockAurumStore) RemoveExpiredRateLimits(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredRateLimits", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveExpiredRateLimits indicates an expected call of RemoveExpiredRateLimits
+func (mr *MockAurumStoreMockRecorder) RemoveExpiredRateLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredRateLimits", reflect.TypeOf((*MockAurumStore)(nil).RemoveExpiredRateLimits), arg0, arg1)
+}
+
+// RemoveExpiredRevocations mocks base method
+func (m *MockAurumStore) RemoveExpiredRevocations(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredRevocations", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveExpiredRevocations indicates an expected call of RemoveExpiredRevocations
+func (mr *MockAurumStoreMockRecorder) RemoveExpiredRevocations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredRevocations", reflect.TypeOf((*MockAurumStore)(nil).RemoveExpiredRevocations), arg0, arg1)
+}
+
+// RemoveExpiredSessions mocks base method
+func (m *MockAurumStore) RemoveExpiredSessions(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredSessions", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveExpiredSessions indicates an expected call of RemoveExpiredSessions
+func (mr *MockAurumStoreMockRecorder) RemoveExpiredSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredSessions", reflect.TypeOf((*MockAurumStore)(nil).RemoveExpiredSessions), arg0, arg1)
 }
 
 // RemoveGroup mocks base method
-func (m *MockAurumStore) RemoveGroup(arg0 context.Context, arg1 string) error {
+func (m *MockAurumStore) RemoveGroup(arg0 context.Context, arg1 string) (models.RemovalSummary, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RemoveGroup", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(models.RemovalSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // RemoveGroup indicates an expected call of RemoveGroup
@@ -210,20 +437,92 @@ func (mr *MockAurumStoreMockRecorder) RemoveGroupFromUser(arg0, arg1, arg2 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupFromUser", reflect.TypeOf((*MockAurumStore)(nil).RemoveGroupFromUser), arg0, arg1, arg2)
 }
 
-// RemoveUser mocks base method
-func (m *MockAurumStore) RemoveUser(arg0 context.Context, arg1 string) error {
+// RemoveLoginFailures mocks base method
+func (m *MockAurumStore) RemoveLoginFailures(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "RemoveLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveLoginFailures indicates an expected call of RemoveLoginFailures
+func (mr *MockAurumStoreMockRecorder) RemoveLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveLoginFailures", reflect.TypeOf((*MockAurumStore)(nil).RemoveLoginFailures), arg0, arg1)
+}
+
+// RemoveSecondFactor mocks base method
+func (m *MockAurumStore) RemoveSecondFactor(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSecondFactor", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// RemoveSecondFactor indicates an expected call of RemoveSecondFactor
+func (mr *MockAurumStoreMockRecorder) RemoveSecondFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSecondFactor", reflect.TypeOf((*MockAurumStore)(nil).RemoveSecondFactor), arg0, arg1)
+}
+
+// RemoveSession mocks base method
+func (m *MockAurumStore) RemoveSession(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveSession indicates an expected call of RemoveSession
+func (mr *MockAurumStoreMockRecorder) RemoveSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSession", reflect.TypeOf((*MockAurumStore)(nil).RemoveSession), arg0, arg1, arg2)
+}
+
+// RemoveUser mocks base method
+func (m *MockAurumStore) RemoveUser(arg0 context.Context, arg1 string) (models.RemovalSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUser", arg0, arg1)
+	ret0, _ := ret[0].(models.RemovalSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // RemoveUser indicates an expected call of RemoveUser
 func (mr *MockAurumStoreMockRecorder) RemoveUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockAurumStore)(nil).RemoveUser), arg0, arg1)
 }
 
+// RevokeToken mocks base method
+func (m *MockAurumStore) RevokeToken(arg0 context.Context, arg1 string, arg2 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeToken indicates an expected call of RevokeToken
+func (mr *MockAurumStoreMockRecorder) RevokeToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockAurumStore)(nil).RevokeToken), arg0, arg1, arg2)
+}
+
+// SetGroup mocks base method
+func (m *MockAurumStore) SetGroup(arg0 context.Context, arg1 models.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetGroup", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGroup indicates an expected call of SetGroup
+func (mr *MockAurumStoreMockRecorder) SetGroup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGroup", reflect.TypeOf((*MockAurumStore)(nil).SetGroup), arg0, arg1)
+}
+
 // SetGroupRole mocks base method
 func (m *MockAurumStore) SetGroupRole(arg0 context.Context, arg1, arg2 string, arg3 models.Role) error {
 	m.ctrl.T.Helper()
@@ -238,6 +537,48 @@ func (mr *MockAurumStoreMockRecorder) SetGroupRole(arg0, arg1, arg2, arg3 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGroupRole", reflect.TypeOf((*MockAurumStore)(nil).SetGroupRole), arg0, arg1, arg2, arg3)
 }
 
+// SetPasswordHistory mocks base method
+func (m *MockAurumStore) SetPasswordHistory(arg0 context.Context, arg1 string, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPasswordHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPasswordHistory indicates an expected call of SetPasswordHistory
+func (mr *MockAurumStoreMockRecorder) SetPasswordHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPasswordHistory", reflect.TypeOf((*MockAurumStore)(nil).SetPasswordHistory), arg0, arg1, arg2)
+}
+
+// SetSecondFactor mocks base method
+func (m *MockAurumStore) SetSecondFactor(arg0 context.Context, arg1 models.SecondFactor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecondFactor", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecondFactor indicates an expected call of SetSecondFactor
+func (mr *MockAurumStoreMockRecorder) SetSecondFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecondFactor", reflect.TypeOf((*MockAurumStore)(nil).SetSecondFactor), arg0, arg1)
+}
+
+// SetSession mocks base method
+func (m *MockAurumStore) SetSession(arg0 context.Context, arg1 models.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSession indicates an expected call of SetSession
+func (mr *MockAurumStoreMockRecorder) SetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSession", reflect.TypeOf((*MockAurumStore)(nil).SetSession), arg0, arg1)
+}
+
 // SetUser mocks base method
 func (m *MockAurumStore) SetUser(arg0 context.Context, arg1 models.User) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -252,3 +593,46 @@ func (mr *MockAurumStoreMockRecorder) SetUser(arg0, arg1 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUser", reflect.TypeOf((*MockAurumStore)(nil).SetUser), arg0, arg1)
 }
+
+// SetUserEmail mocks base method
+func (m *MockAurumStore) SetUserEmail(arg0 context.Context, arg1, arg2, arg3 string, arg4 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserEmail", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserEmail indicates an expected call of SetUserEmail
+func (mr *MockAurumStoreMockRecorder) SetUserEmail(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserEmail", reflect.TypeOf((*MockAurumStore)(nil).SetUserEmail), arg0, arg1, arg2, arg3, arg4)
+}
+
+// TakeRateLimitToken mocks base method
+func (m *MockAurumStore) TakeRateLimitToken(arg0 context.Context, arg1 string, arg2 int, arg3 time.Duration, arg4 time.Time) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeRateLimitToken", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeRateLimitToken indicates an expected call of TakeRateLimitToken
+func (mr *MockAurumStoreMockRecorder) TakeRateLimitToken(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeRateLimitToken", reflect.TypeOf((*MockAurumStore)(nil).TakeRateLimitToken), arg0, arg1, arg2, arg3, arg4)
+}
+
+// WithTx mocks base method
+func (m *MockAurumStore) WithTx(arg0 context.Context, arg1 func(store.AurumStore) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx
+func (mr *MockAurumStoreMockRecorder) WithTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockAurumStore)(nil).WithTx), arg0, arg1)
+}
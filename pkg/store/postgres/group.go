@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// groupColumns are the columns of a group read by scanGroup, in order
+const groupColumns = `name, allow_registration, display_name, description, metadata, require_two_factor, require_verified_email, password_policy`
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGroup scans the groupColumns into group, followed by any extra columns
+func scanGroup(s scanner, group *models.Group, extra ...interface{}) error {
+	var metadata, policy []byte
+
+	dest := append([]interface{}{&group.Name, &group.AllowRegistration, &group.DisplayName, &group.Description, &metadata, &group.RequireTwoFactor, &group.RequireVerifiedEmail, &policy}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return err
+	}
+
+	group.PasswordPolicy = nil
+	if policy != nil {
+		if err := json.Unmarshal(policy, &group.PasswordPolicy); err != nil {
+			return errors.Wrap(err, "json unmarshal password policy")
+		}
+	}
+
+	group.Metadata = nil
+	if err := json.Unmarshal(metadata, &group.Metadata); err != nil {
+		return errors.Wrap(err, "json unmarshal metadata")
+	}
+
+	if len(group.Metadata) == 0 {
+		group.Metadata = nil
+	}
+
+	return nil
+}
+
+// encodeMetadata encodes metadata for the metadata column, which is never null
+func encodeMetadata(metadata map[string]string) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+
+	js, err := json.Marshal(metadata)
+	return string(js), errors.Wrap(err, "json marshal metadata")
+}
+
+// encodePolicy encodes a password policy for the password_policy column, which is null without one
+func encodePolicy(policy *models.PasswordPolicy) (interface{}, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	js, err := json.Marshal(policy)
+	return string(js), errors.Wrap(err, "json marshal password policy")
+}
+
+func (pg *Postgres) CreateGroup(ctx context.Context, group models.Group) error {
+	metadata, err := encodeMetadata(group.Metadata)
+	if err != nil {
+		return err
+	}
+
+	policy, err := encodePolicy(group.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+
+	_, err = pg.conn().ExecContext(ctx,
+		`INSERT INTO groups (`+groupColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		group.Name, group.AllowRegistration, group.DisplayName, group.Description, metadata,
+		group.RequireTwoFactor, group.RequireVerifiedEmail, policy,
+	)
+	if isUniqueViolation(err) {
+		return store.ErrExists
+	}
+
+	return errors.Wrap(err, "insert")
+}
+
+func (pg *Postgres) SetGroup(ctx context.Context, group models.Group) error {
+	metadata, err := encodeMetadata(group.Metadata)
+	if err != nil {
+		return err
+	}
+
+	policy, err := encodePolicy(group.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+
+	res, err := pg.conn().ExecContext(ctx, `
+		UPDATE groups
+		SET allow_registration = $2, display_name = $3, description = $4, metadata = $5,
+		    require_two_factor = $6, require_verified_email = $7, password_policy = $8
+		WHERE name = $1`,
+		group.Name, group.AllowRegistration, group.DisplayName, group.Description, metadata,
+		group.RequireTwoFactor, group.RequireVerifiedEmail, policy,
+	)
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) RemoveGroup(ctx context.Context, group string) (models.RemovalSummary, error) {
+	// Memberships are removed by the cascading foreign key, but the select
+	// still sees them as all parts of the statement share a single snapshot.
+	rows, err := pg.conn().QueryContext(ctx, `
+		WITH removed AS (DELETE FROM groups WHERE name = $1 RETURNING id)
+		SELECT u.username, m.role
+		FROM removed r
+		LEFT JOIN memberships m ON m.group_id = r.id
+		LEFT JOIN users u ON u.id = m.user_id
+		ORDER BY u.username`,
+		group,
+	)
+	if err != nil {
+		return models.RemovalSummary{}, errors.Wrap(err, "delete")
+	}
+
+	memberships, err := scanRemovedMemberships(rows, func(name string, role models.Role) models.Membership {
+		return models.Membership{Username: name, GroupName: group, Role: role}
+	})
+	if err != nil {
+		return models.RemovalSummary{}, err
+	}
+
+	return models.RemovalSummary{Groups: []string{group}, Memberships: memberships}, nil
+}
+
+func (pg *Postgres) GetGroup(ctx context.Context, group string) (*models.Group, error) {
+	var g models.Group
+
+	err := scanGroup(pg.conn().QueryRowContext(ctx,
+		`SELECT `+groupColumns+` FROM groups WHERE name = $1`, group,
+	), &g)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotExists
+	} else if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	return &g, nil
+}
+
+func (pg *Postgres) GetGroups(ctx context.Context, query models.GroupQuery) (models.GroupPage, error) {
+	if err := store.ValidateGroupQuery(query); err != nil {
+		return models.GroupPage{}, err
+	}
+
+	var q queryBuilder
+
+	if query.Search != "" {
+		q.where("lower(name) LIKE %s", likePattern(query.Search, query.Match))
+	}
+
+	if query.AllowRegistration {
+		q.where("allow_registration = %s", true)
+	}
+
+	stmt, err := q.page(`SELECT `+groupColumns+` FROM groups`, "name", "name", query.Cursor, query.Descending, query.Limit)
+	if err != nil {
+		return models.GroupPage{}, err
+	}
+
+	rows, err := pg.conn().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return models.GroupPage{}, errors.Wrap(err, "query")
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		var g models.Group
+		if err := scanGroup(rows, &g); err != nil {
+			return models.GroupPage{}, errors.Wrap(err, "scan")
+		}
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.GroupPage{}, errors.Wrap(err, "rows")
+	}
+
+	page := models.GroupPage{Groups: groups}
+	if query.Limit > 0 && len(groups) > query.Limit {
+		page.Groups = groups[:query.Limit]
+
+		last := page.Groups[query.Limit-1]
+		page.NextCursor = store.Cursor{Key: last.Name, Name: last.Name}.Encode()
+	}
+
+	return page, nil
+}
+
+func (pg *Postgres) GetGroupMembers(ctx context.Context, group string, query models.MemberQuery) (models.MemberPage, error) {
+	if err := store.ValidateUserQuery(query.UserQuery(group)); err != nil {
+		return models.MemberPage{}, err
+	}
+
+	// An empty page can't tell apart a group without (matching) members from no group at all
+	if _, err := pg.GetGroup(ctx, group); err != nil {
+		return models.MemberPage{}, err
+	}
+
+	var q queryBuilder
+	stmt := `SELECT u.username, u.email, m.role FROM users u JOIN memberships m ON m.user_id = u.id JOIN groups g ON g.id = m.group_id`
+	q.where("g.name = %s", group)
+
+	if query.Role != 0 {
+		q.where("m.role = %s", query.Role)
+	}
+
+	if query.Search != "" {
+		pattern := likePattern(query.Search, query.Match)
+		q.where("(lower(u.username) LIKE %s OR lower(u.email) LIKE %[1]s)", pattern)
+	}
+
+	stmt, err := q.page(stmt, "u.username", "u.username", query.Cursor, query.Descending, query.Limit)
+	if err != nil {
+		return models.MemberPage{}, err
+	}
+
+	rows, err := pg.conn().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return models.MemberPage{}, errors.Wrap(err, "query")
+	}
+	defer rows.Close()
+
+	members := []models.UserWithRole{}
+	for rows.Next() {
+		var m models.UserWithRole
+		if err := rows.Scan(&m.Username, &m.Email, &m.Role); err != nil {
+			return models.MemberPage{}, errors.Wrap(err, "scan")
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.MemberPage{}, errors.Wrap(err, "rows")
+	}
+
+	page := models.MemberPage{Members: members}
+	if query.Limit > 0 && len(members) > query.Limit {
+		page.Members = members[:query.Limit]
+
+		last := page.Members[query.Limit-1]
+		page.NextCursor = store.Cursor{Key: last.Username, Name: last.Username}.Encode()
+	}
+
+	return page, nil
+}
+
+func (pg *Postgres) GetGroupsForUser(ctx context.Context, user string) ([]models.GroupWithRole, error) {
+	rows, err := pg.conn().QueryContext(ctx, `
+		SELECT g.name, g.allow_registration, g.display_name, g.description, g.metadata, g.require_two_factor, g.require_verified_email, g.password_policy, m.role
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		JOIN groups g ON g.id = m.group_id
+		WHERE u.username = $1
+		ORDER BY g.name`, user)
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+	defer rows.Close()
+
+	var groups []models.GroupWithRole
+	for rows.Next() {
+		var g models.GroupWithRole
+		if err := scanGroup(rows, &g.Group, &g.Role); err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows")
+	}
+
+	if len(groups) == 0 {
+		return nil, store.ErrNotExists
+	}
+
+	return groups, nil
+}
+
+func (pg *Postgres) AddGroupToUser(ctx context.Context, user string, group string, role models.Role) error {
+	res, err := pg.conn().ExecContext(ctx, `
+		INSERT INTO memberships (user_id, group_id, role)
+		SELECT u.id, g.id, $3
+		FROM users u, groups g
+		WHERE u.username = $1 AND g.name = $2
+		ON CONFLICT (user_id, group_id) DO UPDATE SET role = EXCLUDED.role`,
+		user, group, role,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) RemoveGroupFromUser(ctx context.Context, group string, user string) error {
+	res, err := pg.conn().ExecContext(ctx, `
+		DELETE FROM memberships m
+		USING users u, groups g
+		WHERE m.user_id = u.id AND m.group_id = g.id
+		  AND u.username = $1 AND g.name = $2`,
+		user, group,
+	)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) GetGroupRole(ctx context.Context, group string, user string) (models.Role, error) {
+	var role models.Role
+
+	err := pg.conn().QueryRowContext(ctx, `
+		SELECT m.role
+		FROM memberships m
+		JOIN users u ON u.id = m.user_id
+		JOIN groups g ON g.id = m.group_id
+		WHERE u.username = $1 AND g.name = $2`,
+		user, group,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return 0, store.ErrNotExists
+	} else if err != nil {
+		return 0, errors.Wrap(err, "query")
+	}
+
+	return role, nil
+}
+
+func (pg *Postgres) SetGroupRole(ctx context.Context, group string, user string, role models.Role) error {
+	return pg.AddGroupToUser(ctx, user, group, role)
+}
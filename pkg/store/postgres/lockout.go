@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// scanLoginFailures scans the key, count and last_failure columns into failures
+func scanLoginFailures(s scanner, failures *models.LoginFailures) error {
+	if err := s.Scan(&failures.Key, &failures.Count, &failures.LastFailure); err != nil {
+		return err
+	}
+
+	failures.LastFailure = failures.LastFailure.UTC()
+	return nil
+}
+
+func (pg *Postgres) AddLoginFailure(ctx context.Context, key string, at, since time.Time) (models.LoginFailures, error) {
+	var failures models.LoginFailures
+
+	// The increment happens within the upsert, so concurrent failures can't overwrite each other
+	err := scanLoginFailures(pg.conn().QueryRowContext(ctx, `
+		INSERT INTO login_failures (key, count, last_failure) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET count = CASE WHEN login_failures.last_failure < $3 THEN 1 ELSE login_failures.count + 1 END,
+		    last_failure = EXCLUDED.last_failure
+		RETURNING key, count, last_failure`,
+		key, at, since,
+	), &failures)
+
+	return failures, errors.Wrap(err, "upsert")
+}
+
+func (pg *Postgres) GetLoginFailures(ctx context.Context, key string) (models.LoginFailures, error) {
+	var failures models.LoginFailures
+
+	err := scanLoginFailures(pg.conn().QueryRowContext(ctx,
+		`SELECT key, count, last_failure FROM login_failures WHERE key = $1`, key,
+	), &failures)
+	if err == sql.ErrNoRows {
+		return models.LoginFailures{}, store.ErrNotExists
+	} else if err != nil {
+		return models.LoginFailures{}, errors.Wrap(err, "query")
+	}
+
+	return failures, nil
+}
+
+func (pg *Postgres) ListLoginFailures(ctx context.Context, since time.Time) ([]models.LoginFailures, error) {
+	rows, err := pg.conn().QueryContext(ctx,
+		`SELECT key, count, last_failure FROM login_failures WHERE last_failure >= $1 ORDER BY key`, since,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+	defer rows.Close()
+
+	list := []models.LoginFailures{}
+	for rows.Next() {
+		var failures models.LoginFailures
+		if err := scanLoginFailures(rows, &failures); err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+
+		list = append(list, failures)
+	}
+
+	return list, errors.Wrap(rows.Err(), "rows")
+}
+
+func (pg *Postgres) RemoveLoginFailures(ctx context.Context, key string) error {
+	res, err := pg.conn().ExecContext(ctx, `DELETE FROM login_failures WHERE key = $1`, key)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) RemoveExpiredLoginFailures(ctx context.Context, before time.Time) (int, error) {
+	res, err := pg.conn().ExecContext(ctx, `DELETE FROM login_failures WHERE last_failure < $1`, before)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), errors.Wrap(err, "rows affected")
+}
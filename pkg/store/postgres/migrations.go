@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// migrations contains every version of the database schema. Entries may never be
+// changed or removed once released, changes must be made by appending a new migration.
+var migrations = []string{
+	// 1: users, groups and memberships
+	`
+	CREATE TABLE users (
+		id       BIGSERIAL PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE,
+		password TEXT NOT NULL DEFAULT '',
+		email    TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE groups (
+		id                 BIGSERIAL PRIMARY KEY,
+		name               TEXT NOT NULL UNIQUE,
+		allow_registration BOOLEAN NOT NULL DEFAULT FALSE
+	);
+
+	CREATE TABLE memberships (
+		user_id  BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		group_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+		role     INTEGER NOT NULL,
+		PRIMARY KEY (user_id, group_id)
+	);
+
+	CREATE INDEX memberships_group_id_idx ON memberships (group_id);
+	`,
+	// 2: group settings
+	`
+	ALTER TABLE groups
+		ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+		ADD COLUMN description  TEXT NOT NULL DEFAULT '',
+		ADD COLUMN metadata     JSONB NOT NULL DEFAULT '{}';
+	`,
+	// 3: revoked tokens
+	`
+	CREATE TABLE revoked_tokens (
+		id         TEXT PRIMARY KEY,
+		expires_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+	`,
+	// 4: sessions
+	`
+	CREATE TABLE sessions (
+		username     TEXT NOT NULL,
+		id           TEXT NOT NULL,
+		created_at   TIMESTAMPTZ NOT NULL,
+		last_refresh TIMESTAMPTZ NOT NULL,
+		expires_at   TIMESTAMPTZ NOT NULL,
+		ip           TEXT NOT NULL DEFAULT '',
+		user_agent   TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (username, id)
+	);
+
+	CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
+	`,
+	// 5: two-factor authentication
+	`
+	CREATE TABLE second_factors (
+		username       TEXT PRIMARY KEY,
+		secret         TEXT NOT NULL,
+		confirmed      BOOLEAN NOT NULL DEFAULT FALSE,
+		last_step      BIGINT NOT NULL DEFAULT 0,
+		recovery_codes TEXT[] NOT NULL DEFAULT '{}'
+	);
+
+	ALTER TABLE groups
+		ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+	`,
+	// 6: email verification
+	`
+	ALTER TABLE users
+		ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+		ADD COLUMN pending_email  TEXT NOT NULL DEFAULT '';
+
+	ALTER TABLE groups
+		ADD COLUMN require_verified_email BOOLEAN NOT NULL DEFAULT FALSE;
+	`,
+	// 7: failed logins
+	`
+	CREATE TABLE login_failures (
+		key          TEXT PRIMARY KEY,
+		count        INTEGER NOT NULL,
+		last_failure TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX login_failures_last_failure_idx ON login_failures (last_failure);
+	`,
+	// 8: rate limits
+	`
+	CREATE TABLE rate_limits (
+		key TEXT PRIMARY KEY,
+		tat TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX rate_limits_tat_idx ON rate_limits (tat);
+	`,
+	// 9: password policies
+	`
+	ALTER TABLE groups
+		ADD COLUMN password_policy JSONB;
+	`,
+	// 10: password history and expiry
+	`
+	ALTER TABLE users
+		ADD COLUMN password_changed_at TIMESTAMPTZ;
+
+	CREATE TABLE password_history (
+		username TEXT PRIMARY KEY,
+		hashes   TEXT[] NOT NULL
+	);
+	`,
+}
+
+// migrationLock is the key of the advisory lock taken while migrating, so multiple
+// replicas starting at the same time don't migrate concurrently.
+const migrationLock = 0x61757275 // "aur"
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return errors.Wrap(err, "acquiring migration lock")
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+		return errors.Wrap(err, "creating migrations table")
+	}
+
+	var version int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return errors.Wrap(err, "querying schema version")
+	}
+
+	if version > len(migrations) {
+		return errors.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			return errors.Wrapf(err, "applying migration %d", i+1)
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			return errors.Wrapf(err, "recording migration %d", i+1)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func (pg *Postgres) GetPasswordHistory(ctx context.Context, username string) ([]string, error) {
+	var hashes []string
+
+	err := pg.conn().QueryRowContext(ctx,
+		`SELECT hashes FROM password_history WHERE username = $1`, username,
+	).Scan(pq.Array(&hashes))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+
+	if len(hashes) == 0 {
+		hashes = nil
+	}
+
+	return hashes, nil
+}
+
+func (pg *Postgres) SetPasswordHistory(ctx context.Context, username string, hashes []string) error {
+	if len(hashes) == 0 {
+		_, err := pg.conn().ExecContext(ctx, `DELETE FROM password_history WHERE username = $1`, username)
+		return errors.Wrap(err, "delete")
+	}
+
+	_, err := pg.conn().ExecContext(ctx, `
+		INSERT INTO password_history (username, hashes) VALUES ($1, $2)
+		ON CONFLICT (username) DO UPDATE SET hashes = EXCLUDED.hashes`,
+		username, pq.Array(hashes),
+	)
+
+	return errors.Wrap(err, "upsert")
+}
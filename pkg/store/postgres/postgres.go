@@ -0,0 +1,94 @@
+// Package postgres implements an AurumStore backed by a PostgreSQL database.
+// The schema is created and upgraded automatically using the migrations embedded
+// in this package.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// uniqueViolation is the postgres error code for a violated unique constraint
+const uniqueViolation = "23505"
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type Postgres struct {
+	db *sql.DB
+
+	// tx is the running transaction when this store was handed out by WithTx
+	tx *sql.Tx
+}
+
+// New connects to the database at url and migrates it to the latest schema.
+func New(ctx context.Context, url string) (*Postgres, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database")
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "connecting to database")
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "migrating database")
+	}
+
+	return &Postgres{db: db}, nil
+}
+
+// Close closes the connection pool to the database.
+func (pg *Postgres) Close() error {
+	return pg.db.Close()
+}
+
+// ClearAllImSure removes all users and groups from the database.
+func (pg *Postgres) ClearAllImSure(ctx context.Context) error {
+	_, err := pg.db.ExecContext(ctx, `TRUNCATE memberships, users, groups, password_history RESTART IDENTITY`)
+	return err
+}
+
+// WithTx runs fn inside a single database transaction.
+func (pg *Postgres) WithTx(ctx context.Context, fn func(tx store.AurumStore) error) error {
+	if pg.tx != nil {
+		return fn(pg)
+	}
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	if err := fn(&Postgres{db: pg.db, tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "commit transaction")
+}
+
+// conn returns the running transaction if there is one, and the connection pool otherwise.
+func (pg *Postgres) conn() querier {
+	if pg.tx != nil {
+		return pg.tx
+	}
+
+	return pg.db
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
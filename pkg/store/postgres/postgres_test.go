@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDB connects to the database in POSTGRES_URL, skipping the test when it isn't set.
+// All data in this database is removed!
+func testDB(t *testing.T) *Postgres {
+	url := os.Getenv("POSTGRES_URL")
+	if testing.Short() || url == "" {
+		t.Skip("Skipping postgres integration test, set POSTGRES_URL to run it")
+	}
+
+	ctx := context.Background()
+	pg, err := New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = pg.Close() })
+
+	if err := pg.ClearAllImSure(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return pg
+}
+
+func TestPostgres(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.AurumStore {
+		return testDB(t)
+	})
+}
+
+func TestPostgres_Migrate(t *testing.T) {
+	pg := testDB(t)
+
+	// Migrating an up to date database is a no-op
+	assert.NoError(t, migrate(context.Background(), pg.db))
+}
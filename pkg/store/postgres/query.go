@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+)
+
+// queryBuilder collects the conditions and arguments of a listing query
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// where adds a condition, in which %s is replaced by the placeholder for arg
+func (q *queryBuilder) where(condition string, arg interface{}) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, fmt.Sprintf("$%d", len(q.args))))
+}
+
+// page completes stmt with the conditions, and sorts it on the sort column and then the unique
+// name column. Continuing after the cursor relies on the sort order, so it is always bytewise
+// to agree with the order of store.Cursor. One more row than limit is selected, which tells
+// whether there is a next page.
+func (q *queryBuilder) page(stmt, sortColumn, nameColumn, cursor string, descending bool, limit int) (string, error) {
+	op, order := ">", "ASC"
+	if descending {
+		op, order = "<", "DESC"
+	}
+
+	if cursor != "" {
+		c, err := store.DecodeCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+
+		q.args = append(q.args, c.Key, c.Name)
+		q.conditions = append(q.conditions, fmt.Sprintf(`(%s COLLATE "C", %s COLLATE "C") %s ($%d, $%d)`,
+			sortColumn, nameColumn, op, len(q.args)-1, len(q.args)))
+	}
+
+	if len(q.conditions) > 0 {
+		stmt += " WHERE " + strings.Join(q.conditions, " AND ")
+	}
+
+	stmt += fmt.Sprintf(` ORDER BY %s COLLATE "C" %s, %s COLLATE "C" %s`, sortColumn, order, nameColumn, order)
+
+	if limit > 0 {
+		q.args = append(q.args, limit+1)
+		stmt += fmt.Sprintf(" LIMIT $%d", len(q.args))
+	}
+
+	return stmt, nil
+}
+
+// likePattern turns a search into a pattern for LIKE, escaping the wildcards in it
+func likePattern(search string, match models.Match) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(search))
+
+	if match == models.MatchPrefix {
+		return escaped + "%"
+	}
+
+	return "%" + escaped + "%"
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+func (pg *Postgres) TakeRateLimitToken(ctx context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	var retry time.Duration
+
+	err := pg.WithTx(ctx, func(tx store.AurumStore) error {
+		conn := tx.(*Postgres).conn()
+
+		// A new bucket is full, the row is locked so concurrent requests take their tokens one by one
+		if _, err := conn.ExecContext(ctx,
+			`INSERT INTO rate_limits (key, tat) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`, key, now,
+		); err != nil {
+			return errors.Wrap(err, "insert")
+		}
+
+		var tat time.Time
+		if err := conn.QueryRowContext(ctx,
+			`SELECT tat FROM rate_limits WHERE key = $1 FOR UPDATE`, key,
+		).Scan(&tat); err != nil {
+			return errors.Wrap(err, "query")
+		}
+
+		tat, retry = store.TakeToken(tat, now, burst, interval)
+		if retry > 0 {
+			return nil
+		}
+
+		_, err := conn.ExecContext(ctx, `UPDATE rate_limits SET tat = $2 WHERE key = $1`, key, tat)
+		return errors.Wrap(err, "update")
+	})
+
+	return retry, err
+}
+
+func (pg *Postgres) RemoveExpiredRateLimits(ctx context.Context, now time.Time) (int, error) {
+	res, err := pg.conn().ExecContext(ctx, `DELETE FROM rate_limits WHERE tat <= $1`, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), errors.Wrap(err, "rows affected")
+}
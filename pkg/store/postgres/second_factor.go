@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func (pg *Postgres) GetSecondFactor(ctx context.Context, username string) (models.SecondFactor, error) {
+	var factor models.SecondFactor
+
+	err := pg.conn().QueryRowContext(ctx, `
+		SELECT username, secret, confirmed, last_step, recovery_codes
+		FROM second_factors WHERE username = $1`, username,
+	).Scan(&factor.Username, &factor.Secret, &factor.Confirmed, &factor.LastStep, pq.Array(&factor.RecoveryCodes))
+	if err == sql.ErrNoRows {
+		return models.SecondFactor{}, store.ErrNotExists
+	} else if err != nil {
+		return models.SecondFactor{}, errors.Wrap(err, "query")
+	}
+
+	if len(factor.RecoveryCodes) == 0 {
+		factor.RecoveryCodes = nil
+	}
+
+	return factor, nil
+}
+
+func (pg *Postgres) SetSecondFactor(ctx context.Context, factor models.SecondFactor) error {
+	codes := factor.RecoveryCodes
+	if codes == nil {
+		codes = []string{}
+	}
+
+	_, err := pg.conn().ExecContext(ctx, `
+		INSERT INTO second_factors (username, secret, confirmed, last_step, recovery_codes)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (username) DO UPDATE SET
+			secret = EXCLUDED.secret, confirmed = EXCLUDED.confirmed,
+			last_step = EXCLUDED.last_step, recovery_codes = EXCLUDED.recovery_codes`,
+		factor.Username, factor.Secret, factor.Confirmed, factor.LastStep, pq.Array(codes),
+	)
+
+	return errors.Wrap(err, "upsert")
+}
+
+func (pg *Postgres) RemoveSecondFactor(ctx context.Context, username string) error {
+	res, err := pg.conn().ExecContext(ctx, `DELETE FROM second_factors WHERE username = $1`, username)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+
+	return expectRows(res)
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// sessionColumns are the columns of a session read by scanSession, in order
+const sessionColumns = `username, id, created_at, last_refresh, expires_at, ip, user_agent`
+
+// scanSession scans the sessionColumns into session
+func scanSession(s scanner, session *models.Session) error {
+	if err := s.Scan(
+		&session.Username, &session.ID,
+		&session.CreatedAt, &session.LastRefresh, &session.ExpiresAt,
+		&session.IP, &session.UserAgent,
+	); err != nil {
+		return err
+	}
+
+	session.CreatedAt = session.CreatedAt.UTC()
+	session.LastRefresh = session.LastRefresh.UTC()
+	session.ExpiresAt = session.ExpiresAt.UTC()
+
+	return nil
+}
+
+func (pg *Postgres) CreateSession(ctx context.Context, session models.Session) error {
+	_, err := pg.conn().ExecContext(ctx,
+		`INSERT INTO sessions (`+sessionColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		session.Username, session.ID,
+		session.CreatedAt, session.LastRefresh, session.ExpiresAt,
+		session.IP, session.UserAgent,
+	)
+	if isUniqueViolation(err) {
+		return store.ErrExists
+	}
+
+	return errors.Wrap(err, "insert")
+}
+
+func (pg *Postgres) GetSession(ctx context.Context, username, id string) (models.Session, error) {
+	var session models.Session
+
+	err := scanSession(pg.conn().QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE username = $1 AND id = $2`, username, id,
+	), &session)
+	if err == sql.ErrNoRows {
+		return models.Session{}, store.ErrNotExists
+	} else if err != nil {
+		return models.Session{}, errors.Wrap(err, "query")
+	}
+
+	return session, nil
+}
+
+func (pg *Postgres) GetSessions(ctx context.Context, username string) ([]models.Session, error) {
+	rows, err := pg.conn().QueryContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE username = $1 ORDER BY created_at, id`, username,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query")
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := scanSession(rows, &session); err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, errors.Wrap(rows.Err(), "rows")
+}
+
+func (pg *Postgres) SetSession(ctx context.Context, session models.Session) error {
+	res, err := pg.conn().ExecContext(ctx, `
+		UPDATE sessions
+		SET created_at = $3, last_refresh = $4, expires_at = $5, ip = $6, user_agent = $7
+		WHERE username = $1 AND id = $2`,
+		session.Username, session.ID,
+		session.CreatedAt, session.LastRefresh, session.ExpiresAt,
+		session.IP, session.UserAgent,
+	)
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) RemoveSession(ctx context.Context, username, id string) error {
+	res, err := pg.conn().ExecContext(ctx,
+		`DELETE FROM sessions WHERE username = $1 AND id = $2`, username, id,
+	)
+	if err != nil {
+		return errors.Wrap(err, "delete")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) RemoveExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	res, err := pg.conn().ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), errors.Wrap(err, "rows affected")
+}
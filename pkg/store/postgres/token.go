@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (pg *Postgres) RevokeToken(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	res, err := pg.conn().ExecContext(ctx, `
+		INSERT INTO revoked_tokens (id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING`,
+		id, expiresAt,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "insert")
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, errors.Wrap(err, "rows affected")
+}
+
+func (pg *Postgres) IsTokenRevoked(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+
+	err := pg.conn().QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE id = $1)`, id,
+	).Scan(&revoked)
+
+	return revoked, errors.Wrap(err, "query")
+}
+
+func (pg *Postgres) RemoveExpiredRevocations(ctx context.Context, now time.Time) (int, error) {
+	res, err := pg.conn().ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete")
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), errors.Wrap(err, "rows affected")
+}
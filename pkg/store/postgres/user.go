@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// userColumns are the columns scanned by scanUser, in order
+const userColumns = `username, password, email, email_verified, pending_email, password_changed_at`
+
+// scanUser scans the userColumns of a row into a user
+func scanUser(s scanner, u *models.User) error {
+	var changed sql.NullTime
+	if err := s.Scan(&u.Username, &u.Password, &u.Email, &u.EmailVerified, &u.PendingEmail, &changed); err != nil {
+		return err
+	}
+
+	if changed.Valid {
+		u.PasswordChangedAt = changed.Time.UTC()
+	}
+
+	return nil
+}
+
+// nullTime stores the zero time as null
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (pg *Postgres) CreateUser(ctx context.Context, user models.User) error {
+	_, err := pg.conn().ExecContext(ctx, `
+		INSERT INTO users (username, password, email, email_verified, pending_email, password_changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		user.Username, user.Password, user.Email, user.EmailVerified, user.PendingEmail, nullTime(user.PasswordChangedAt),
+	)
+	if isUniqueViolation(err) {
+		return store.ErrExists
+	}
+
+	return errors.Wrap(err, "insert")
+}
+
+func (pg *Postgres) RemoveUser(ctx context.Context, user string) (models.RemovalSummary, error) {
+	var memberships []models.Membership
+
+	err := pg.WithTx(ctx, func(tx store.AurumStore) error {
+		conn := tx.(*Postgres).conn()
+
+		// Memberships are removed by the cascading foreign key, but the select
+		// still sees them as all parts of the statement share a single snapshot.
+		rows, err := conn.QueryContext(ctx, `
+			WITH removed AS (DELETE FROM users WHERE username = $1 RETURNING id)
+			SELECT g.name, m.role
+			FROM removed r
+			LEFT JOIN memberships m ON m.user_id = r.id
+			LEFT JOIN groups g ON g.id = m.group_id
+			ORDER BY g.name`,
+			user,
+		)
+		if err != nil {
+			return errors.Wrap(err, "delete")
+		}
+
+		memberships, err = scanRemovedMemberships(rows, func(name string, role models.Role) models.Membership {
+			return models.Membership{Username: user, GroupName: name, Role: role}
+		})
+		if err != nil {
+			return err
+		}
+
+		// The other tables refer to users by name, so a new user with the same name mustn't find these
+		for _, table := range []string{"sessions", "second_factors", "password_history"} {
+			if _, err := conn.ExecContext(ctx, `DELETE FROM `+table+` WHERE username = $1`, user); err != nil {
+				return errors.Wrapf(err, "delete %s", table)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.RemovalSummary{}, err
+	}
+
+	return models.RemovalSummary{Users: []string{user}, Memberships: memberships}, nil
+}
+
+func (pg *Postgres) GetUser(ctx context.Context, user string) (models.User, error) {
+	var u models.User
+
+	err := scanUser(pg.conn().QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE username = $1`, user,
+	), &u)
+	if err == sql.ErrNoRows {
+		return models.User{}, store.ErrNotExists
+	} else if err != nil {
+		return models.User{}, errors.Wrap(err, "query")
+	}
+
+	return u, nil
+}
+
+func (pg *Postgres) GetUsers(ctx context.Context, query models.UserQuery) (models.UserPage, error) {
+	if err := store.ValidateUserQuery(query); err != nil {
+		return models.UserPage{}, err
+	}
+
+	sortColumn := "u.username"
+	if query.Sort == models.SortEmail {
+		sortColumn = "u.email"
+	}
+
+	var q queryBuilder
+	stmt := `SELECT u.username, u.email, u.email_verified, u.pending_email, u.password_changed_at FROM users u`
+
+	if query.Group != "" {
+		stmt += ` JOIN memberships m ON m.user_id = u.id JOIN groups g ON g.id = m.group_id`
+		q.where("g.name = %s", query.Group)
+
+		if query.Role != 0 {
+			q.where("m.role = %s", query.Role)
+		}
+	}
+
+	if query.Search != "" {
+		pattern := likePattern(query.Search, query.Match)
+		q.where("(lower(u.username) LIKE %s OR lower(u.email) LIKE %[1]s)", pattern)
+	}
+
+	stmt, err := q.page(stmt, sortColumn, "u.username", query.Cursor, query.Descending, query.Limit)
+	if err != nil {
+		return models.UserPage{}, err
+	}
+
+	rows, err := pg.conn().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return models.UserPage{}, errors.Wrap(err, "query")
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		var changed sql.NullTime
+		if err := rows.Scan(&u.Username, &u.Email, &u.EmailVerified, &u.PendingEmail, &changed); err != nil {
+			return models.UserPage{}, errors.Wrap(err, "scan")
+		}
+
+		if changed.Valid {
+			u.PasswordChangedAt = changed.Time.UTC()
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.UserPage{}, errors.Wrap(err, "rows")
+	}
+
+	page := models.UserPage{Users: users}
+	if query.Limit > 0 && len(users) > query.Limit {
+		page.Users = users[:query.Limit]
+
+		last := page.Users[query.Limit-1]
+		page.NextCursor = store.Cursor{Key: store.UserSortKey(last, query.Sort), Name: last.Username}.Encode()
+	}
+
+	return page, nil
+}
+
+func (pg *Postgres) SetUser(ctx context.Context, user models.User) (models.User, error) {
+	var u models.User
+
+	// Empty fields are left unchanged
+	err := scanUser(pg.conn().QueryRowContext(ctx, `
+		UPDATE users
+		SET password            = COALESCE(NULLIF($2, ''), password),
+		    email               = COALESCE(NULLIF($3, ''), email),
+		    password_changed_at = COALESCE($4, password_changed_at)
+		WHERE username = $1
+		RETURNING `+userColumns,
+		user.Username, user.Password, user.Email, nullTime(user.PasswordChangedAt),
+	), &u)
+	if err == sql.ErrNoRows {
+		return models.User{}, store.ErrNotExists
+	} else if err != nil {
+		return models.User{}, errors.Wrap(err, "update")
+	}
+
+	return u, nil
+}
+
+func (pg *Postgres) SetUserEmail(ctx context.Context, username, email, pending string, verified bool) error {
+	res, err := pg.conn().ExecContext(ctx, `
+		UPDATE users
+		SET email = $2, pending_email = $3, email_verified = $4
+		WHERE username = $1`,
+		username, email, pending, verified,
+	)
+	if err != nil {
+		return errors.Wrap(err, "update")
+	}
+
+	return expectRows(res)
+}
+
+func (pg *Postgres) CountUsers(ctx context.Context) (int, error) {
+	var n int
+	if err := pg.conn().QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&n); err != nil {
+		return -1, errors.Wrap(err, "query")
+	}
+
+	return n, nil
+}
+
+func (pg *Postgres) CountPasswordsWithPrefix(ctx context.Context, prefix string) (int, error) {
+	var n int
+	if err := pg.conn().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users WHERE left(password, char_length($1)) = $1`, prefix,
+	).Scan(&n); err != nil {
+		return -1, errors.Wrap(err, "query")
+	}
+
+	return n, nil
+}
+
+// scanRemovedMemberships reads the (name, role) rows returned when removing a user or group.
+// There is a single row with nulls when nothing but the user or group itself was removed,
+// and no rows at all when it didn't exist.
+func scanRemovedMemberships(rows *sql.Rows, membership func(name string, role models.Role) models.Membership) ([]models.Membership, error) {
+	defer rows.Close()
+
+	var found bool
+	var memberships []models.Membership
+
+	for rows.Next() {
+		found = true
+
+		var name sql.NullString
+		var role sql.NullInt64
+		if err := rows.Scan(&name, &role); err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+
+		if name.Valid {
+			memberships = append(memberships, membership(name.String, models.Role(role.Int64)))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows")
+	}
+
+	if !found {
+		return nil, store.ErrNotExists
+	}
+
+	return memberships, nil
+}
+
+// expectRows returns store.ErrNotExists if a statement didn't affect any rows
+func expectRows(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return store.ErrNotExists
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/finitum/aurum/pkg/models"
+)
+
+// ErrInvalidQuery is returned when listing users or groups with a malformed query or cursor
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Cursor is a position in a sorted listing. It holds the sort key and the (unique) name
+// of the last item of a page, the next page starts with the first item sorted after it.
+type Cursor struct {
+	Key  string `json:"k"`
+	Name string `json:"n"`
+}
+
+// Encode turns the cursor into an opaque string to hand out to clients
+func (c Cursor) Encode() string {
+	js, _ := json.Marshal(&c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// DecodeCursor is the inverse of Cursor.Encode
+func DecodeCursor(s string) (Cursor, error) {
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidQuery
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(js, &c); err != nil {
+		return Cursor{}, ErrInvalidQuery
+	}
+
+	return c, nil
+}
+
+// After reports whether the item with the given sort key and name comes after the cursor
+func (c Cursor) After(key, name string, descending bool) bool {
+	if key == c.Key {
+		return (name > c.Name) != descending && name != c.Name
+	}
+
+	return (key > c.Key) != descending
+}
+
+// ValidateUserQuery checks whether all fields of the query have a known value
+func ValidateUserQuery(q models.UserQuery) error {
+	if err := validateSearch(q.Match, q.Limit); err != nil {
+		return err
+	}
+
+	switch q.Sort {
+	case "", models.SortUsername, models.SortEmail:
+	default:
+		return ErrInvalidQuery
+	}
+
+	switch q.Role {
+	case 0:
+	case models.RoleUser, models.RoleAdmin:
+		if q.Group == "" {
+			return ErrInvalidQuery
+		}
+	default:
+		return ErrInvalidQuery
+	}
+
+	return nil
+}
+
+// ValidateGroupQuery checks whether all fields of the query have a known value
+func ValidateGroupQuery(q models.GroupQuery) error {
+	return validateSearch(q.Match, q.Limit)
+}
+
+func validateSearch(match models.Match, limit int) error {
+	switch match {
+	case "", models.MatchContains, models.MatchPrefix:
+	default:
+		return ErrInvalidQuery
+	}
+
+	if limit < 0 {
+		return ErrInvalidQuery
+	}
+
+	return nil
+}
+
+// Matches reports whether value matches search, ignoring case
+func Matches(value, search string, match models.Match) bool {
+	value = strings.ToLower(value)
+	search = strings.ToLower(search)
+
+	if match == models.MatchPrefix {
+		return strings.HasPrefix(value, search)
+	}
+
+	return strings.Contains(value, search)
+}
+
+// UserSortKey is the value a user is sorted on, besides its username
+func UserSortKey(user models.User, sort models.UserSort) string {
+	if sort == models.SortEmail {
+		return user.Email
+	}
+
+	return user.Username
+}
+
+// PageUsers selects a page of users according to query, for stores which can't do so themselves.
+// The role function returns the role a user has in query.Group. Passwords are never included.
+func PageUsers(users []models.User, query models.UserQuery, role func(user string) (models.Role, bool)) (models.UserPage, error) {
+	if err := ValidateUserQuery(query); err != nil {
+		return models.UserPage{}, err
+	}
+
+	var cursor *Cursor
+	if query.Cursor != "" {
+		c, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return models.UserPage{}, err
+		}
+		cursor = &c
+	}
+
+	selected := make([]models.User, 0, len(users))
+	for _, u := range users {
+		if query.Search != "" && !Matches(u.Username, query.Search, query.Match) && !Matches(u.Email, query.Search, query.Match) {
+			continue
+		}
+
+		if query.Group != "" {
+			r, ok := role(u.Username)
+			if !ok || (query.Role != 0 && r != query.Role) {
+				continue
+			}
+		}
+
+		if cursor != nil && !cursor.After(UserSortKey(u, query.Sort), u.Username, query.Descending) {
+			continue
+		}
+
+		u.Password = ""
+		selected = append(selected, u)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		c := Cursor{Key: UserSortKey(selected[i], query.Sort), Name: selected[i].Username}
+		return c.After(UserSortKey(selected[j], query.Sort), selected[j].Username, query.Descending)
+	})
+
+	page := models.UserPage{Users: selected}
+	if query.Limit > 0 && len(selected) > query.Limit {
+		page.Users = selected[:query.Limit]
+
+		last := page.Users[query.Limit-1]
+		page.NextCursor = Cursor{Key: UserSortKey(last, query.Sort), Name: last.Username}.Encode()
+	}
+
+	return page, nil
+}
+
+// PageGroups selects a page of groups according to query, for stores which can't do so themselves.
+func PageGroups(groups []models.Group, query models.GroupQuery) (models.GroupPage, error) {
+	if err := ValidateGroupQuery(query); err != nil {
+		return models.GroupPage{}, err
+	}
+
+	var cursor *Cursor
+	if query.Cursor != "" {
+		c, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return models.GroupPage{}, err
+		}
+		cursor = &c
+	}
+
+	selected := make([]models.Group, 0, len(groups))
+	for _, g := range groups {
+		if query.Search != "" && !Matches(g.Name, query.Search, query.Match) {
+			continue
+		}
+
+		if query.AllowRegistration && !g.AllowRegistration {
+			continue
+		}
+
+		if cursor != nil && !cursor.After(g.Name, g.Name, query.Descending) {
+			continue
+		}
+
+		selected = append(selected, g)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return (selected[i].Name < selected[j].Name) != query.Descending
+	})
+
+	page := models.GroupPage{Groups: selected}
+	if query.Limit > 0 && len(selected) > query.Limit {
+		page.Groups = selected[:query.Limit]
+
+		last := page.Groups[query.Limit-1]
+		page.NextCursor = Cursor{Key: last.Name, Name: last.Name}.Encode()
+	}
+
+	return page, nil
+}
+
+// PageMembers selects a page of the members of group according to query, for stores which can't do so
+// themselves. Users which aren't a member of group are left out. The role function returns the role
+// a user has in group. Passwords are never included.
+func PageMembers(users []models.User, group string, query models.MemberQuery, role func(user string) (models.Role, bool)) (models.MemberPage, error) {
+	page, err := PageUsers(users, query.UserQuery(group), role)
+	if err != nil {
+		return models.MemberPage{}, err
+	}
+
+	members := make([]models.UserWithRole, 0, len(page.Users))
+	for _, u := range page.Users {
+		r, _ := role(u.Username)
+		members = append(members, models.UserWithRole{User: u, Role: r})
+	}
+
+	return models.MemberPage{Members: members, NextCursor: page.NextCursor}, nil
+}
@@ -0,0 +1,24 @@
+package store
+
+import "time"
+
+// TakeToken takes a token from a token bucket, which holds up to burst tokens and gains one every interval.
+// Rather than by its number of tokens, the bucket is described by tat: the time at which it's full again.
+// A bucket which was never used, or has been full for a while, has a tat at or before now.
+//
+// It returns the new tat and zero when a token was taken. Otherwise it returns tat unchanged,
+// and how long it takes until a token can be taken.
+func TakeToken(tat, now time.Time, burst int, interval time.Duration) (time.Time, time.Duration) {
+	if tat.Before(now) {
+		tat = now
+	}
+
+	next := tat.Add(interval)
+
+	// Taking the token may not push the time the bucket is full again beyond burst intervals from now
+	if allowAt := next.Add(-time.Duration(burst) * interval); now.Before(allowAt) {
+		return tat, allowAt.Sub(now)
+	}
+
+	return next, 0
+}
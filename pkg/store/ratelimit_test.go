@@ -0,0 +1,39 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeToken(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	var tat time.Time
+
+	// A new bucket is full, so burst tokens can be taken at once
+	for i := 0; i < 3; i++ {
+		var retry time.Duration
+		tat, retry = TakeToken(tat, now, 3, time.Second)
+		assert.Zero(t, retry)
+	}
+	assert.Equal(t, now.Add(3*time.Second), tat)
+
+	// After that the bucket is empty until it gained a token
+	next, retry := TakeToken(tat, now, 3, time.Second)
+	assert.Equal(t, tat, next)
+	assert.Equal(t, time.Second, retry)
+
+	next, retry = TakeToken(tat, now.Add(400*time.Millisecond), 3, time.Second)
+	assert.Equal(t, tat, next)
+	assert.Equal(t, 600*time.Millisecond, retry)
+
+	tat, retry = TakeToken(tat, now.Add(time.Second), 3, time.Second)
+	assert.Zero(t, retry)
+	assert.Equal(t, now.Add(4*time.Second), tat)
+
+	// Once full again, it doesn't keep gaining tokens
+	tat, retry = TakeToken(tat, now.Add(time.Hour), 3, time.Second)
+	assert.Zero(t, retry)
+	assert.Equal(t, now.Add(time.Hour+time.Second), tat)
+}
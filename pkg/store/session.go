@@ -0,0 +1,19 @@
+package store
+
+import (
+	"sort"
+
+	"github.com/finitum/aurum/pkg/models"
+)
+
+// SortSessions sorts sessions in the order GetSessions returns them: oldest first, and by id
+// when they were created at the same time.
+func SortSessions(sessions []models.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+		}
+
+		return sessions[i].ID < sessions[j].ID
+	})
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/finitum/aurum/pkg/models"
 )
@@ -20,14 +21,25 @@ type AurumStore interface {
 	CreateGroup(ctx context.Context, group models.Group) error
 
 	// RemoveGroup removes an group from the database based
-	// on it's name.
-	RemoveGroup(ctx context.Context, group string) error
+	// on it's name, together with the memberships of all its users.
+	// The summary lists the removed group and memberships sorted by username.
+	RemoveGroup(ctx context.Context, group string) (models.RemovalSummary, error)
 
 	// GetGroup retrieves an group based on it name.
 	GetGroup(ctx context.Context, group string) (*models.Group, error)
 
-	// GetGroups lists all groups.
-	GetGroups(ctx context.Context) ([]models.Group, error)
+	// SetGroup replaces the settings of an existing group with those of group,
+	// based on its name.
+	SetGroup(ctx context.Context, group models.Group) error
+
+	// GetGroups lists a page of the groups selected by query.
+	// It returns ErrInvalidQuery when the query or its cursor are malformed.
+	GetGroups(ctx context.Context, query models.GroupQuery) (models.GroupPage, error)
+
+	// GetGroupMembers lists a page of the members of a group together with their role
+	// in it, without their passwords. It returns ErrNotExists when the group doesn't exist
+	// and ErrInvalidQuery when the query or its cursor are malformed.
+	GetGroupMembers(ctx context.Context, group string, query models.MemberQuery) (models.MemberPage, error)
 
 	// GetGroupsForUser lists all groups a user has a specified role in.
 	GetGroupsForUser(ctx context.Context, group string) ([]models.GroupWithRole, error)
@@ -36,20 +48,28 @@ type AurumStore interface {
 	// User names and ids must be unique
 	CreateUser(ctx context.Context, user models.User) error
 
-	// RemoveUser removes a user from the database
-	RemoveUser(ctx context.Context, user string) error
+	// RemoveUser removes a user from the database, together with its memberships
+	// of all groups, its sessions, second factor and password history. The summary
+	// lists the removed user and memberships sorted by group name.
+	RemoveUser(ctx context.Context, user string) (models.RemovalSummary, error)
 
 	// GetUser retrieves a user from the database based on it's
 	// user id.
 	GetUser(ctx context.Context, user string) (models.User, error)
 
-	// GetUsers lists all users.
-	GetUsers(ctx context.Context) ([]models.User, error)
+	// GetUsers lists a page of the users selected by query, without their passwords.
+	// It returns ErrInvalidQuery when the query or its cursor are malformed.
+	GetUsers(ctx context.Context, query models.UserQuery) (models.UserPage, error)
 
-	// SetUser updates a users info in the database.
+	// SetUser updates a users info in the database, leaving empty fields unchanged.
 	// User names and ids must be the same
 	SetUser(ctx context.Context, user models.User) (models.User, error)
 
+	// SetUserEmail sets the email address of a user, whether it has been verified, and the
+	// address waiting to be verified. Unlike SetUser it stores empty values as well.
+	// If the user doesn't exist ErrNotExists is returned.
+	SetUserEmail(ctx context.Context, username, email, pending string, verified bool) error
+
 	// AddUserToGroup links a user to an group with a given role.
 	// This role is the role the user has within this group.
 	AddGroupToUser(ctx context.Context, user string, group string, role models.Role) error
@@ -65,4 +85,91 @@ type AurumStore interface {
 
 	// CountUsers counts the number of users currently in the database
 	CountUsers(ctx context.Context) (int, error)
+
+	// CountPasswordsWithPrefix counts the users of which the password hash starts with prefix.
+	CountPasswordsWithPrefix(ctx context.Context, prefix string) (int, error)
+
+	// RevokeToken revokes the token with the given id (its jti claim) until it expires
+	// at expiresAt. It returns false when the token had already been revoked, which
+	// is not an error.
+	RevokeToken(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+
+	// IsTokenRevoked reports whether the token with the given id has been revoked.
+	IsTokenRevoked(ctx context.Context, id string) (bool, error)
+
+	// RemoveExpiredRevocations forgets the revocations of tokens which expired before now,
+	// as those are rejected anyway. It returns the number of revocations removed.
+	RemoveExpiredRevocations(ctx context.Context, now time.Time) (int, error)
+
+	// CreateSession stores a new session. Sessions are identified by their username and id
+	// together. If the session already exists ErrExists is returned.
+	CreateSession(ctx context.Context, session models.Session) error
+
+	// GetSession gets a session of a user. If it doesn't exist ErrNotExists is returned.
+	GetSession(ctx context.Context, username, id string) (models.Session, error)
+
+	// GetSessions gets all sessions of a user, oldest first.
+	GetSessions(ctx context.Context, username string) ([]models.Session, error)
+
+	// SetSession updates an existing session. If it doesn't exist ErrNotExists is returned.
+	SetSession(ctx context.Context, session models.Session) error
+
+	// RemoveSession removes a session of a user. If it doesn't exist ErrNotExists is returned.
+	RemoveSession(ctx context.Context, username, id string) error
+
+	// RemoveExpiredSessions removes the sessions which expired before now.
+	// It returns the number of sessions removed.
+	RemoveExpiredSessions(ctx context.Context, now time.Time) (int, error)
+
+	// GetSecondFactor gets the second factor of a user. If the user has none ErrNotExists is returned.
+	GetSecondFactor(ctx context.Context, username string) (models.SecondFactor, error)
+
+	// SetSecondFactor stores the second factor of a user, replacing the existing one if any.
+	SetSecondFactor(ctx context.Context, factor models.SecondFactor) error
+
+	// RemoveSecondFactor removes the second factor of a user. If the user has none ErrNotExists is returned.
+	RemoveSecondFactor(ctx context.Context, username string) error
+
+	// GetPasswordHistory gets the hashes of the previous passwords of a user, most recent first.
+	// A user without history has an empty history, which isn't an error.
+	GetPasswordHistory(ctx context.Context, username string) ([]string, error)
+
+	// SetPasswordHistory replaces the hashes of the previous passwords of a user, most recent first.
+	// Setting an empty history removes it.
+	SetPasswordHistory(ctx context.Context, username string, hashes []string) error
+
+	// AddLoginFailure counts a failed login at time at for key, which identifies an account or a
+	// source address, and returns the updated failures. When the last failure was before since,
+	// the earlier failures are forgotten and counting starts over. Concurrent failures are all counted.
+	AddLoginFailure(ctx context.Context, key string, at, since time.Time) (models.LoginFailures, error)
+
+	// GetLoginFailures gets the failed logins for key. If there are none ErrNotExists is returned.
+	GetLoginFailures(ctx context.Context, key string) (models.LoginFailures, error)
+
+	// ListLoginFailures lists the failed logins for every key of which the last failure
+	// was at or after since, ordered by key.
+	ListLoginFailures(ctx context.Context, since time.Time) ([]models.LoginFailures, error)
+
+	// RemoveLoginFailures forgets the failed logins for key. If there are none ErrNotExists is returned.
+	RemoveLoginFailures(ctx context.Context, key string) error
+
+	// RemoveExpiredLoginFailures forgets the failed logins for keys of which the last failure
+	// was before before. It returns the number of keys removed.
+	RemoveExpiredLoginFailures(ctx context.Context, before time.Time) (int, error)
+
+	// TakeRateLimitToken takes a token from the token bucket of key, which holds up to burst tokens
+	// and gains one every interval, see TakeToken. It returns zero when a token was taken, or otherwise
+	// how long it takes until one can be taken. Concurrent calls never take the same token.
+	TakeRateLimitToken(ctx context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error)
+
+	// RemoveExpiredRateLimits forgets the token buckets which are full at now, as those are the
+	// same as new ones. It returns the number of buckets removed.
+	RemoveExpiredRateLimits(ctx context.Context, now time.Time) (int, error)
+
+	// WithTx runs fn within a single transaction. Every change made through tx is
+	// committed when fn returns nil, and none of them are when it returns an error.
+	// The error returned by fn is passed through as is. Calling WithTx on tx joins
+	// the already running transaction. Stores may run fn again in a new transaction
+	// when it conflicted with another one, so it mustn't change state outside of tx.
+	WithTx(ctx context.Context, fn func(tx AurumStore) error) error
 }
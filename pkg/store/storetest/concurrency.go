@@ -0,0 +1,81 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrency is the number of simultaneous operations in the concurrency tests
+const concurrency = 20
+
+// race runs f concurrently and asserts exactly one call succeeded while
+// all others failed with store.ErrExists.
+func race(t *testing.T, f func(i int) error) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency)
+
+	start := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs <- f(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	var succeeded int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.Equal(t, store.ErrExists, err)
+		}
+	}
+
+	assert.Equal(t, 1, succeeded, "expected exactly one create to succeed")
+}
+
+func testConcurrentCreateUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	race(t, func(i int) error {
+		return s.CreateUser(ctx, models.User{
+			Username: bob.Username,
+			Email:    fmt.Sprintf("bob%d@example.com", i),
+		})
+	})
+
+	n, err := s.CountUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+}
+
+func testConcurrentCreateGroup(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	race(t, func(i int) error {
+		return s.CreateGroup(ctx, models.Group{
+			Name:              groupA.Name,
+			AllowRegistration: i%2 == 0,
+		})
+	})
+
+	page, err := s.GetGroups(ctx, models.GroupQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Groups, 1)
+}
@@ -0,0 +1,117 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateGroup(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.CreateGroup(ctx, groupA))
+	assert.Equal(t, store.ErrExists, s.CreateGroup(ctx, groupA))
+
+	// Group names must be unique, the other fields don't matter
+	assert.Equal(t, store.ErrExists, s.CreateGroup(ctx, models.Group{Name: groupA.Name}))
+
+	assert.NoError(t, s.CreateGroup(ctx, groupB))
+}
+
+func testGetGroup(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, nil, []models.Group{groupA, groupB})
+
+	g, err := s.GetGroup(ctx, groupA.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, &groupA, g)
+
+	g, err = s.GetGroup(ctx, groupB.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, &groupB, g)
+
+	_, err = s.GetGroup(ctx, "group-c")
+	assert.Equal(t, store.ErrNotExists, err)
+}
+
+func testGetGroups(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	page, err := s.GetGroups(ctx, models.GroupQuery{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Groups)
+	assert.Empty(t, page.NextCursor)
+
+	seed(t, s, nil, []models.Group{groupA, groupB})
+
+	page, err = s.GetGroups(ctx, models.GroupQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Group{groupA, groupB}, page.Groups)
+	assert.Empty(t, page.NextCursor)
+}
+
+func testSetGroup(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob}, []models.Group{groupA, groupB})
+	require.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleAdmin))
+
+	// Every setting can be reset to its zero value
+	cleared := models.Group{Name: groupA.Name}
+	assert.NoError(t, s.SetGroup(ctx, cleared))
+
+	g, err := s.GetGroup(ctx, groupA.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, &cleared, g)
+
+	updated := models.Group{
+		Name:                 groupA.Name,
+		AllowRegistration:    true,
+		DisplayName:          "Renamed",
+		Description:          "Changed \"description\"\nover multiple lines",
+		Metadata:             map[string]string{"homepage": "https://new.example.com"},
+		RequireTwoFactor:     true,
+		RequireVerifiedEmail: true,
+		PasswordPolicy:       &models.PasswordPolicy{MinScore: 4, HistoryDepth: 3, MaxAgeDays: 90},
+	}
+	assert.NoError(t, s.SetGroup(ctx, updated))
+
+	g, err = s.GetGroup(ctx, groupA.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, &updated, g)
+
+	groups, err := s.GetGroupsForUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GroupWithRole{{Group: updated, Role: models.RoleAdmin}}, groups)
+
+	// Other groups are untouched
+	g, err = s.GetGroup(ctx, groupB.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, &groupB, g)
+
+	assert.Equal(t, store.ErrNotExists, s.SetGroup(ctx, models.Group{Name: "group-c"}))
+}
+
+func testRemoveGroup(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, nil, []models.Group{groupA, groupB})
+
+	summary, err := s.RemoveGroup(ctx, groupA.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RemovalSummary{Groups: []string{groupA.Name}}, summary)
+
+	_, err = s.RemoveGroup(ctx, groupA.Name)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetGroup(ctx, groupA.Name)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetGroup(ctx, groupB.Name)
+	assert.NoError(t, err)
+
+	// The name is free to use again
+	assert.NoError(t, s.CreateGroup(ctx, groupA))
+}
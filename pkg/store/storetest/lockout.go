@@ -0,0 +1,138 @@
+package storetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAddLoginFailure(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	since := now.Add(-time.Hour)
+
+	_, err := s.GetLoginFailures(ctx, "user:bob")
+	assert.Equal(t, store.ErrNotExists, err)
+
+	failures, err := s.AddLoginFailure(ctx, "user:bob", now.Add(-time.Minute), since)
+	require.NoError(t, err)
+	assert.Equal(t, models.LoginFailures{Key: "user:bob", Count: 1, LastFailure: now.Add(-time.Minute)}, failures)
+
+	failures, err = s.AddLoginFailure(ctx, "user:bob", now, since)
+	require.NoError(t, err)
+	assert.Equal(t, models.LoginFailures{Key: "user:bob", Count: 2, LastFailure: now}, failures)
+
+	got, err := s.GetLoginFailures(ctx, "user:bob")
+	assert.NoError(t, err)
+	assert.Equal(t, failures, got)
+
+	// Failures are counted per key
+	failures, err = s.AddLoginFailure(ctx, "ip:192.0.2.1", now, since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures.Count)
+
+	// Counting starts over when the last failure was before since
+	later := now.Add(2 * time.Hour)
+	failures, err = s.AddLoginFailure(ctx, "user:bob", later, later.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, models.LoginFailures{Key: "user:bob", Count: 1, LastFailure: later}, failures)
+}
+
+func testListLoginFailures(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	old, err := s.AddLoginFailure(ctx, "user:alice", now.Add(-2*time.Hour), now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	bob, err := s.AddLoginFailure(ctx, "user:bob", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+	ip, err := s.AddLoginFailure(ctx, "ip:192.0.2.1", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	list, err := s.ListLoginFailures(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LoginFailures{ip, bob}, list)
+
+	list, err = s.ListLoginFailures(ctx, now.Add(-3*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LoginFailures{ip, old, bob}, list)
+
+	list, err = s.ListLoginFailures(ctx, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func testRemoveLoginFailures(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	_, err := s.AddLoginFailure(ctx, "user:bob", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = s.AddLoginFailure(ctx, "user:alice", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	assert.NoError(t, s.RemoveLoginFailures(ctx, "user:bob"))
+	assert.Equal(t, store.ErrNotExists, s.RemoveLoginFailures(ctx, "user:bob"))
+
+	_, err = s.GetLoginFailures(ctx, "user:bob")
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetLoginFailures(ctx, "user:alice")
+	assert.NoError(t, err)
+
+	// Counting starts over
+	failures, err := s.AddLoginFailure(ctx, "user:bob", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures.Count)
+}
+
+func testRemoveExpiredLoginFailures(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	_, err := s.AddLoginFailure(ctx, "user:alice", now.Add(-2*time.Hour), now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	valid, err := s.AddLoginFailure(ctx, "user:bob", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	removed, err := s.RemoveExpiredLoginFailures(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	list, err := s.ListLoginFailures(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LoginFailures{valid}, list)
+
+	removed, err = s.RemoveExpiredLoginFailures(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func testConcurrentAddLoginFailure(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := s.AddLoginFailure(ctx, "user:bob", now, now.Add(-time.Hour))
+			assert.NoError(t, err)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	failures, err := s.GetLoginFailures(ctx, "user:bob")
+	assert.NoError(t, err)
+	assert.Equal(t, concurrency, failures.Count, "expected every failure to be counted")
+}
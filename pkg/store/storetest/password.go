@@ -0,0 +1,45 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSetPasswordHistory(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	hashes, err := s.GetPasswordHistory(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	require.NoError(t, s.SetPasswordHistory(ctx, bob.Username, []string{"hash-c", "hash-b", "hash-a"}))
+
+	hashes, err = s.GetPasswordHistory(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hash-c", "hash-b", "hash-a"}, hashes)
+
+	// Setting it again replaces it, keeping the order
+	require.NoError(t, s.SetPasswordHistory(ctx, bob.Username, []string{"hash-d", "hash-c"}))
+
+	hashes, err = s.GetPasswordHistory(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hash-d", "hash-c"}, hashes)
+
+	// Other users are untouched
+	hashes, err = s.GetPasswordHistory(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	// An empty history removes it
+	require.NoError(t, s.SetPasswordHistory(ctx, bob.Username, nil))
+
+	hashes, err = s.GetPasswordHistory(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	assert.NoError(t, s.SetPasswordHistory(ctx, alice.Username, nil))
+}
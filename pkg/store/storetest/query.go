@@ -0,0 +1,235 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	carol = models.User{Username: "carol", Email: "carol@bob.dev"}
+	dave  = models.User{Username: "dave", Email: "aaa@example.com"}
+	// eve shares an email address with bob, so sorting on email needs the username to break the tie
+	eve = models.User{Username: "eve", Email: bob.Email}
+
+	everyone = []models.User{alice, bob, carol, dave, eve}
+)
+
+// usernames lists the names of users, in order
+func usernames(users []models.User) []string {
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	return names
+}
+
+func assertUsers(t *testing.T, s store.AurumStore, query models.UserQuery, expected ...string) {
+	t.Helper()
+
+	page, err := s.GetUsers(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, expected, usernames(page.Users))
+}
+
+func testSearchUsers(t *testing.T, s store.AurumStore) {
+	seed(t, s, everyone, nil)
+
+	// Both username and email are searched, ignoring case
+	assertUsers(t, s, models.UserQuery{Search: "BOB"}, "bob", "carol", "eve")
+	assertUsers(t, s, models.UserQuery{Search: "example.com", Match: models.MatchContains}, "alice", "bob", "dave", "eve")
+
+	assertUsers(t, s, models.UserQuery{Search: "bob", Match: models.MatchPrefix}, "bob", "eve")
+	assertUsers(t, s, models.UserQuery{Search: "a", Match: models.MatchPrefix}, "alice", "dave")
+
+	// Wildcards are matched literally
+	assertUsers(t, s, models.UserQuery{Search: "%"})
+	assertUsers(t, s, models.UserQuery{Search: "_", Match: models.MatchPrefix})
+}
+
+func testFilterUsersByGroup(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, everyone, []models.Group{groupA, groupB})
+
+	require.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleAdmin))
+	require.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleUser))
+	require.NoError(t, s.AddGroupToUser(ctx, carol.Username, groupA.Name, models.RoleUser))
+	require.NoError(t, s.AddGroupToUser(ctx, carol.Username, groupB.Name, models.RoleAdmin))
+
+	assertUsers(t, s, models.UserQuery{Group: groupA.Name}, "alice", "bob", "carol")
+	assertUsers(t, s, models.UserQuery{Group: groupA.Name, Role: models.RoleUser}, "alice", "carol")
+	assertUsers(t, s, models.UserQuery{Group: groupA.Name, Role: models.RoleAdmin}, "bob")
+	assertUsers(t, s, models.UserQuery{Group: groupB.Name, Role: models.RoleUser})
+	assertUsers(t, s, models.UserQuery{Group: groupA.Name, Search: "bob"}, "bob", "carol")
+	assertUsers(t, s, models.UserQuery{Group: "group-c"})
+}
+
+func testSortUsers(t *testing.T, s store.AurumStore) {
+	seed(t, s, everyone, nil)
+
+	assertUsers(t, s, models.UserQuery{Sort: models.SortUsername}, "alice", "bob", "carol", "dave", "eve")
+	assertUsers(t, s, models.UserQuery{Descending: true}, "eve", "dave", "carol", "bob", "alice")
+
+	assertUsers(t, s, models.UserQuery{Sort: models.SortEmail}, "dave", "alice", "bob", "eve", "carol")
+	assertUsers(t, s, models.UserQuery{Sort: models.SortEmail, Descending: true}, "carol", "eve", "bob", "alice", "dave")
+}
+
+func testPaginateUsers(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, everyone, nil)
+
+	for _, query := range []models.UserQuery{
+		{},
+		{Descending: true},
+		{Sort: models.SortEmail},
+		{Sort: models.SortEmail, Descending: true},
+	} {
+		all, err := s.GetUsers(ctx, query)
+		require.NoError(t, err)
+
+		var paged []models.User
+		var pages int
+
+		query.Limit = 2
+		for {
+			page, err := s.GetUsers(ctx, query)
+			require.NoError(t, err)
+			require.True(t, len(page.Users) <= query.Limit)
+
+			paged = append(paged, page.Users...)
+			pages++
+
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		assert.Equal(t, usernames(all.Users), usernames(paged), "query %+v", query)
+		assert.Equal(t, 3, pages)
+	}
+
+	// The last page doesn't have a cursor, even when it's full
+	page, err := s.GetUsers(ctx, models.UserQuery{Limit: len(everyone)})
+	assert.NoError(t, err)
+	assert.Len(t, page.Users, len(everyone))
+	assert.Empty(t, page.NextCursor)
+}
+
+func testInvalidUserQuery(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, everyone, []models.Group{groupA})
+
+	for _, query := range []models.UserQuery{
+		{Cursor: "not a cursor"},
+		{Sort: "password"},
+		{Match: "regex"},
+		{Role: models.RoleAdmin},
+		{Group: groupA.Name, Role: 42},
+		{Limit: -1},
+	} {
+		_, err := s.GetUsers(ctx, query)
+		assert.Equal(t, store.ErrInvalidQuery, err, "query %+v", query)
+	}
+}
+
+func testSearchGroups(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	groupC := models.Group{Name: "c-group"}
+	seed(t, s, nil, []models.Group{groupA, groupB, groupC})
+
+	page, err := s.GetGroups(ctx, models.GroupQuery{Search: "GROUP-"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Group{groupA, groupB}, page.Groups)
+
+	page, err = s.GetGroups(ctx, models.GroupQuery{Search: "c", Match: models.MatchPrefix})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Group{groupC}, page.Groups)
+
+	page, err = s.GetGroups(ctx, models.GroupQuery{Descending: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Group{groupB, groupA, groupC}, page.Groups)
+
+	page, err = s.GetGroups(ctx, models.GroupQuery{AllowRegistration: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Group{groupA}, page.Groups)
+
+	_, err = s.GetGroups(ctx, models.GroupQuery{Match: "regex"})
+	assert.Equal(t, store.ErrInvalidQuery, err)
+}
+
+func testPaginateGroups(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	groupC := models.Group{Name: "group-c"}
+	seed(t, s, nil, []models.Group{groupA, groupB, groupC})
+
+	page, err := s.GetGroups(ctx, models.GroupQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Group{groupA, groupB}, page.Groups)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = s.GetGroups(ctx, models.GroupQuery{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Group{groupC}, page.Groups)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = s.GetGroups(ctx, models.GroupQuery{Cursor: "not a cursor"})
+	assert.Equal(t, store.ErrInvalidQuery, err)
+}
+
+// memberNames lists the names and roles of members, in order
+func memberNames(members []models.UserWithRole) []string {
+	var names []string
+	for _, m := range members {
+		names = append(names, fmt.Sprintf("%s:%d", m.Username, m.Role))
+	}
+	return names
+}
+
+func assertMembers(t *testing.T, s store.AurumStore, group string, query models.MemberQuery, expected ...string) {
+	t.Helper()
+
+	page, err := s.GetGroupMembers(context.Background(), group, query)
+	require.NoError(t, err)
+	assert.Equal(t, expected, memberNames(page.Members))
+}
+
+func testGetGroupMembers(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, everyone, []models.Group{groupA, groupB})
+
+	require.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleAdmin))
+	require.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleUser))
+	require.NoError(t, s.AddGroupToUser(ctx, carol.Username, groupA.Name, models.RoleUser))
+	require.NoError(t, s.AddGroupToUser(ctx, carol.Username, groupB.Name, models.RoleAdmin))
+
+	page, err := s.GetGroupMembers(ctx, groupB.Name, models.MemberQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.UserWithRole{{
+		User: models.User{Username: carol.Username, Email: carol.Email},
+		Role: models.RoleAdmin,
+	}}, page.Members)
+
+	assertMembers(t, s, groupA.Name, models.MemberQuery{}, "alice:1", "bob:2", "carol:1")
+	assertMembers(t, s, groupA.Name, models.MemberQuery{Descending: true}, "carol:1", "bob:2", "alice:1")
+	assertMembers(t, s, groupA.Name, models.MemberQuery{Role: models.RoleUser}, "alice:1", "carol:1")
+	assertMembers(t, s, groupA.Name, models.MemberQuery{Search: "bob"}, "bob:2", "carol:1")
+
+	page, err = s.GetGroupMembers(ctx, groupA.Name, models.MemberQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:1", "bob:2"}, memberNames(page.Members))
+	require.NotEmpty(t, page.NextCursor)
+
+	assertMembers(t, s, groupA.Name, models.MemberQuery{Limit: 2, Cursor: page.NextCursor}, "carol:1")
+
+	_, err = s.GetGroupMembers(ctx, "group-c", models.MemberQuery{})
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetGroupMembers(ctx, groupA.Name, models.MemberQuery{Role: 42})
+	assert.Equal(t, store.ErrInvalidQuery, err)
+}
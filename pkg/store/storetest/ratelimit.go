@@ -0,0 +1,97 @@
+package storetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTakeRateLimitToken(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	for i := 0; i < 3; i++ {
+		retry, err := s.TakeRateLimitToken(ctx, "login:192.0.2.1", 3, time.Second, now)
+		assert.NoError(t, err)
+		assert.Zero(t, retry)
+	}
+
+	retry, err := s.TakeRateLimitToken(ctx, "login:192.0.2.1", 3, time.Second, now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, retry)
+
+	// Buckets are kept per key
+	retry, err = s.TakeRateLimitToken(ctx, "login:192.0.2.2", 3, time.Second, now)
+	assert.NoError(t, err)
+	assert.Zero(t, retry)
+
+	// The bucket gains a token every interval
+	retry, err = s.TakeRateLimitToken(ctx, "login:192.0.2.1", 3, time.Second, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Zero(t, retry)
+
+	retry, err = s.TakeRateLimitToken(ctx, "login:192.0.2.1", 3, time.Second, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, retry)
+}
+
+func testRemoveExpiredRateLimits(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	_, err := s.TakeRateLimitToken(ctx, "full", 1, time.Second, now.Add(-time.Minute))
+	assert.NoError(t, err)
+	_, err = s.TakeRateLimitToken(ctx, "empty", 1, time.Minute, now)
+	assert.NoError(t, err)
+
+	removed, err := s.RemoveExpiredRateLimits(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	// The bucket which wasn't full is kept
+	retry, err := s.TakeRateLimitToken(ctx, "empty", 1, time.Minute, now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, retry)
+
+	removed, err = s.RemoveExpiredRateLimits(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func testConcurrentTakeRateLimitToken(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+
+	const burst = 5
+
+	var wg sync.WaitGroup
+	taken := make(chan bool, concurrency)
+
+	start := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			retry, err := s.TakeRateLimitToken(ctx, "signup:192.0.2.1", burst, time.Hour, now)
+			assert.NoError(t, err)
+			taken <- err == nil && retry == 0
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(taken)
+
+	var n int
+	for ok := range taken {
+		if ok {
+			n++
+		}
+	}
+
+	assert.Equal(t, burst, n, "expected exactly burst tokens to be taken")
+}
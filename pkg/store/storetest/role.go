@@ -0,0 +1,186 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAddGroupToUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, []models.Group{groupA, groupB})
+
+	assert.Equal(t, store.ErrNotExists, s.AddGroupToUser(ctx, "carol", groupA.Name, models.RoleUser))
+	assert.Equal(t, store.ErrNotExists, s.AddGroupToUser(ctx, bob.Username, "group-c", models.RoleUser))
+	assertNoRole(t, s, groupA.Name, bob.Username)
+
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleAdmin))
+
+	role, err := s.GetGroupRole(ctx, groupA.Name, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, role)
+
+	role, err = s.GetGroupRole(ctx, groupA.Name, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+
+	assertNoRole(t, s, groupB.Name, bob.Username)
+	assertNoRole(t, s, "group-c", bob.Username)
+	assertNoRole(t, s, groupA.Name, "carol")
+}
+
+func testSetGroupRole(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, []models.Group{groupA, groupB})
+
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleUser))
+
+	assert.NoError(t, s.SetGroupRole(ctx, groupA.Name, bob.Username, models.RoleAdmin))
+
+	role, err := s.GetGroupRole(ctx, groupA.Name, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+
+	// Changing a role doesn't create a second membership
+	groups, err := s.GetGroupsForUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GroupWithRole{{Group: groupA, Role: models.RoleAdmin}}, groups)
+
+	// Other members are untouched
+	role, err = s.GetGroupRole(ctx, groupA.Name, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, role)
+
+	assert.NoError(t, s.SetGroupRole(ctx, groupA.Name, bob.Username, models.RoleUser))
+
+	role, err = s.GetGroupRole(ctx, groupA.Name, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, role)
+
+	assert.Equal(t, store.ErrNotExists, s.SetGroupRole(ctx, "group-c", bob.Username, models.RoleUser))
+	assert.Equal(t, store.ErrNotExists, s.SetGroupRole(ctx, groupA.Name, "carol", models.RoleUser))
+}
+
+func testRemoveGroupFromUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, []models.Group{groupA, groupB})
+
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupB.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleUser))
+
+	assert.NoError(t, s.RemoveGroupFromUser(ctx, groupA.Name, bob.Username))
+	assert.Equal(t, store.ErrNotExists, s.RemoveGroupFromUser(ctx, groupA.Name, bob.Username))
+	assert.Equal(t, store.ErrNotExists, s.RemoveGroupFromUser(ctx, "group-c", bob.Username))
+	assert.Equal(t, store.ErrNotExists, s.RemoveGroupFromUser(ctx, groupA.Name, "carol"))
+
+	assertNoRole(t, s, groupA.Name, bob.Username)
+
+	// Other memberships are untouched
+	_, err := s.GetGroupRole(ctx, groupB.Name, bob.Username)
+	assert.NoError(t, err)
+	_, err = s.GetGroupRole(ctx, groupA.Name, alice.Username)
+	assert.NoError(t, err)
+}
+
+func testGetGroupsForUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, []models.Group{groupA, groupB})
+
+	_, err := s.GetGroupsForUser(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetGroupsForUser(ctx, "carol")
+	assert.Equal(t, store.ErrNotExists, err)
+
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupB.Name, models.RoleAdmin))
+	assert.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupB.Name, models.RoleUser))
+
+	groups, err := s.GetGroupsForUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []models.GroupWithRole{
+		{Group: groupA, Role: models.RoleUser},
+		{Group: groupB, Role: models.RoleAdmin},
+	}, groups)
+
+	groups, err = s.GetGroupsForUser(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GroupWithRole{{Group: groupB, Role: models.RoleUser}}, groups)
+}
+
+func testRemoveGroupCascade(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, []models.Group{groupA, groupB})
+
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleAdmin))
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupB.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleUser))
+
+	summary, err := s.RemoveGroup(ctx, groupA.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RemovalSummary{
+		Groups: []string{groupA.Name},
+		Memberships: []models.Membership{
+			{Username: alice.Username, GroupName: groupA.Name, Role: models.RoleUser},
+			{Username: bob.Username, GroupName: groupA.Name, Role: models.RoleAdmin},
+		},
+	}, summary)
+
+	assertNoRole(t, s, groupA.Name, bob.Username)
+	assertNoRole(t, s, groupA.Name, alice.Username)
+
+	groups, err := s.GetGroupsForUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GroupWithRole{{Group: groupB, Role: models.RoleUser}}, groups)
+
+	_, err = s.GetGroupsForUser(ctx, alice.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	// A new group with the same name doesn't inherit the old members
+	assert.NoError(t, s.CreateGroup(ctx, groupA))
+	assertNoRole(t, s, groupA.Name, bob.Username)
+	assertNoRole(t, s, groupA.Name, alice.Username)
+}
+
+func testRemoveUserCascade(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, []models.Group{groupA, groupB})
+
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleAdmin))
+	assert.NoError(t, s.AddGroupToUser(ctx, bob.Username, groupB.Name, models.RoleUser))
+	assert.NoError(t, s.AddGroupToUser(ctx, alice.Username, groupA.Name, models.RoleUser))
+
+	summary, err := s.RemoveUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RemovalSummary{
+		Users: []string{bob.Username},
+		Memberships: []models.Membership{
+			{Username: bob.Username, GroupName: groupA.Name, Role: models.RoleAdmin},
+			{Username: bob.Username, GroupName: groupB.Name, Role: models.RoleUser},
+		},
+	}, summary)
+
+	assertNoRole(t, s, groupA.Name, bob.Username)
+	assertNoRole(t, s, groupB.Name, bob.Username)
+
+	// The group and its other members are untouched
+	_, err = s.GetGroup(ctx, groupA.Name)
+	assert.NoError(t, err)
+
+	role, err := s.GetGroupRole(ctx, groupA.Name, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, role)
+
+	// A new user with the same name doesn't inherit the old memberships
+	assert.NoError(t, s.CreateUser(ctx, bob))
+	assertNoRole(t, s, groupA.Name, bob.Username)
+
+	_, err = s.GetGroupsForUser(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+}
@@ -0,0 +1,63 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSetSecondFactor(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	_, err := s.GetSecondFactor(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	factor := models.SecondFactor{
+		Username: bob.Username,
+		Secret:   "JBSWY3DPEHPK3PXP",
+	}
+	require.NoError(t, s.SetSecondFactor(ctx, factor))
+
+	got, err := s.GetSecondFactor(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, factor, got)
+
+	// Setting it again replaces it, recovery codes aren't added to the existing ones
+	factor.Confirmed = true
+	factor.LastStep = 53690000
+	factor.RecoveryCodes = []string{"code-a", "code-b"}
+	require.NoError(t, s.SetSecondFactor(ctx, factor))
+
+	factor.RecoveryCodes = []string{"code-b"}
+	require.NoError(t, s.SetSecondFactor(ctx, factor))
+
+	got, err = s.GetSecondFactor(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, factor, got)
+
+	// Other users are untouched
+	_, err = s.GetSecondFactor(ctx, alice.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+}
+
+func testRemoveSecondFactor(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	assert.Equal(t, store.ErrNotExists, s.RemoveSecondFactor(ctx, bob.Username))
+
+	require.NoError(t, s.SetSecondFactor(ctx, models.SecondFactor{Username: bob.Username, Secret: "JBSWY3DPEHPK3PXP"}))
+	require.NoError(t, s.SetSecondFactor(ctx, models.SecondFactor{Username: alice.Username, Secret: "KRSXG5CTMVRXEZLU"}))
+
+	assert.NoError(t, s.RemoveSecondFactor(ctx, bob.Username))
+	assert.Equal(t, store.ErrNotExists, s.RemoveSecondFactor(ctx, bob.Username))
+
+	_, err := s.GetSecondFactor(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetSecondFactor(ctx, alice.Username)
+	assert.NoError(t, err)
+}
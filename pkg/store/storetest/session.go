@@ -0,0 +1,133 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSession creates a session of user, created at the given time. Times are truncated
+// to whole seconds in UTC, so they survive a round trip through every backend.
+func newSession(user, id string, createdAt time.Time) models.Session {
+	createdAt = createdAt.Truncate(time.Second).UTC()
+
+	return models.Session{
+		ID:          id,
+		Username:    user,
+		CreatedAt:   createdAt,
+		LastRefresh: createdAt,
+		ExpiresAt:   createdAt.Add(time.Hour),
+		IP:          "192.0.2.1",
+		UserAgent:   "storetest",
+	}
+}
+
+func testCreateSession(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	session := newSession(bob.Username, "session-a", time.Now())
+
+	_, err := s.GetSession(ctx, bob.Username, session.ID)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	require.NoError(t, s.CreateSession(ctx, session))
+	assert.Equal(t, store.ErrExists, s.CreateSession(ctx, session))
+
+	got, err := s.GetSession(ctx, bob.Username, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, session, got)
+
+	// Session ids are only unique per user
+	other := newSession(alice.Username, session.ID, time.Now())
+	assert.NoError(t, s.CreateSession(ctx, other))
+
+	got, err = s.GetSession(ctx, alice.Username, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, other, got)
+}
+
+func testGetSessions(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	sessions, err := s.GetSessions(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	newer := newSession(bob.Username, "session-a", now)
+	older := newSession(bob.Username, "session-b", now.Add(-time.Minute))
+
+	require.NoError(t, s.CreateSession(ctx, newer))
+	require.NoError(t, s.CreateSession(ctx, older))
+	require.NoError(t, s.CreateSession(ctx, newSession(alice.Username, "session-c", now)))
+
+	sessions, err = s.GetSessions(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Session{older, newer}, sessions)
+}
+
+func testSetSession(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	session := newSession(bob.Username, "session-a", time.Now())
+
+	assert.Equal(t, store.ErrNotExists, s.SetSession(ctx, session))
+
+	require.NoError(t, s.CreateSession(ctx, session))
+
+	session.LastRefresh = session.LastRefresh.Add(time.Minute)
+	session.ExpiresAt = session.ExpiresAt.Add(time.Minute)
+	session.IP = "192.0.2.2"
+	session.UserAgent = ""
+	assert.NoError(t, s.SetSession(ctx, session))
+
+	got, err := s.GetSession(ctx, bob.Username, session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, session, got)
+}
+
+func testRemoveSession(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	session := newSession(bob.Username, "session-a", time.Now())
+
+	assert.Equal(t, store.ErrNotExists, s.RemoveSession(ctx, bob.Username, session.ID))
+
+	require.NoError(t, s.CreateSession(ctx, session))
+	require.NoError(t, s.CreateSession(ctx, newSession(alice.Username, session.ID, time.Now())))
+
+	assert.NoError(t, s.RemoveSession(ctx, bob.Username, session.ID))
+	assert.Equal(t, store.ErrNotExists, s.RemoveSession(ctx, bob.Username, session.ID))
+
+	_, err := s.GetSession(ctx, bob.Username, session.ID)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	// The session of the other user with the same id is left alone
+	_, err = s.GetSession(ctx, alice.Username, session.ID)
+	assert.NoError(t, err)
+}
+
+func testRemoveExpiredSessions(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	expired := newSession(bob.Username, "expired", now.Add(-2*time.Hour))
+	valid := newSession(bob.Username, "valid", now)
+
+	require.NoError(t, s.CreateSession(ctx, expired))
+	require.NoError(t, s.CreateSession(ctx, valid))
+
+	removed, err := s.RemoveExpiredSessions(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	sessions, err := s.GetSessions(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Session{valid}, sessions)
+
+	removed, err = s.RemoveExpiredSessions(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
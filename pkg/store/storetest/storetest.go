@@ -0,0 +1,156 @@
+// Package storetest contains a behavioural test suite for store.AurumStore implementations.
+// Every backend should run it from its own tests, so they are guaranteed to agree on semantics:
+//
+//	func TestMemory(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) store.AurumStore {
+//			return memory.New()
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory creates a new and empty store. It is called once for every test in the suite.
+type Factory func(t *testing.T) store.AurumStore
+
+// Run runs the full suite against stores created by newStore.
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s store.AurumStore)
+	}{
+		{"CreateUser", testCreateUser},
+		{"GetUser", testGetUser},
+		{"GetUsers", testGetUsers},
+		{"SetUser", testSetUser},
+		{"SetUserEmail", testSetUserEmail},
+		{"PasswordChangedAt", testPasswordChangedAt},
+		{"RemoveUser", testRemoveUser},
+		{"RemoveUserState", testRemoveUserState},
+		{"CountUsers", testCountUsers},
+		{"CountPasswordsWithPrefix", testCountPasswordsWithPrefix},
+
+		{"CreateGroup", testCreateGroup},
+		{"GetGroup", testGetGroup},
+		{"GetGroups", testGetGroups},
+		{"SetGroup", testSetGroup},
+		{"RemoveGroup", testRemoveGroup},
+
+		{"AddGroupToUser", testAddGroupToUser},
+		{"SetGroupRole", testSetGroupRole},
+		{"RemoveGroupFromUser", testRemoveGroupFromUser},
+		{"GetGroupsForUser", testGetGroupsForUser},
+		{"RemoveGroupCascade", testRemoveGroupCascade},
+		{"RemoveUserCascade", testRemoveUserCascade},
+
+		{"SearchUsers", testSearchUsers},
+		{"FilterUsersByGroup", testFilterUsersByGroup},
+		{"SortUsers", testSortUsers},
+		{"PaginateUsers", testPaginateUsers},
+		{"InvalidUserQuery", testInvalidUserQuery},
+		{"SearchGroups", testSearchGroups},
+		{"PaginateGroups", testPaginateGroups},
+		{"GetGroupMembers", testGetGroupMembers},
+
+		{"RevokeToken", testRevokeToken},
+		{"RemoveExpiredRevocations", testRemoveExpiredRevocations},
+
+		{"CreateSession", testCreateSession},
+		{"GetSessions", testGetSessions},
+		{"SetSession", testSetSession},
+		{"RemoveSession", testRemoveSession},
+		{"RemoveExpiredSessions", testRemoveExpiredSessions},
+
+		{"SetSecondFactor", testSetSecondFactor},
+		{"RemoveSecondFactor", testRemoveSecondFactor},
+
+		{"SetPasswordHistory", testSetPasswordHistory},
+
+		{"AddLoginFailure", testAddLoginFailure},
+		{"ListLoginFailures", testListLoginFailures},
+		{"RemoveLoginFailures", testRemoveLoginFailures},
+		{"RemoveExpiredLoginFailures", testRemoveExpiredLoginFailures},
+
+		{"TakeRateLimitToken", testTakeRateLimitToken},
+		{"RemoveExpiredRateLimits", testRemoveExpiredRateLimits},
+
+		{"WithTxCommit", testWithTxCommit},
+		{"WithTxRollback", testWithTxRollback},
+		{"WithTxNested", testWithTxNested},
+
+		{"ConcurrentCreateUser", testConcurrentCreateUser},
+		{"ConcurrentCreateGroup", testConcurrentCreateGroup},
+		{"ConcurrentAddLoginFailure", testConcurrentAddLoginFailure},
+		{"ConcurrentTakeRateLimitToken", testConcurrentTakeRateLimitToken},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+var (
+	bob = models.User{
+		Username: "bob",
+		Password: "hashed password",
+		Email:    "bob@example.com",
+	}
+	alice = models.User{
+		Username: "alice",
+		Password: "another hashed password",
+		Email:    "alice@example.com",
+	}
+
+	groupA = models.Group{
+		Name:              "group-a",
+		AllowRegistration: true,
+		DisplayName:       "Group A",
+		Description:       "The first group",
+		Metadata: map[string]string{
+			"homepage": "https://a.example.com",
+			"team":     "alpha",
+		},
+	}
+	groupB = models.Group{
+		Name:              "group-b",
+		AllowRegistration: false,
+		PasswordPolicy: &models.PasswordPolicy{
+			MinLength:    12,
+			RequireDigit: true,
+			BannedWords:  []string{"group-b"},
+		},
+	}
+)
+
+// seed creates the given users and groups, failing the test if that isn't possible
+func seed(t *testing.T, s store.AurumStore, users []models.User, groups []models.Group) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, u := range users {
+		require.NoError(t, s.CreateUser(ctx, u))
+	}
+
+	for _, g := range groups {
+		require.NoError(t, s.CreateGroup(ctx, g))
+	}
+}
+
+// assertNoRole asserts that user is not a member of group
+func assertNoRole(t *testing.T, s store.AurumStore, group, user string) {
+	t.Helper()
+
+	_, err := s.GetGroupRole(context.Background(), group, user)
+	assert.Equal(t, store.ErrNotExists, err)
+}
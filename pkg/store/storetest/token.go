@@ -0,0 +1,63 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRevokeToken(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	revoked, err := s.IsTokenRevoked(ctx, "token-a")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = s.RevokeToken(ctx, "token-a", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Revoking twice is fine, but tells the token was revoked already
+	revoked, err = s.RevokeToken(ctx, "token-a", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = s.IsTokenRevoked(ctx, "token-a")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = s.IsTokenRevoked(ctx, "token-b")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func testRemoveExpiredRevocations(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := s.RevokeToken(ctx, "expired", now.Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = s.RevokeToken(ctx, "valid", now.Add(time.Hour))
+	require.NoError(t, err)
+
+	removed, err := s.RemoveExpiredRevocations(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	revoked, err := s.IsTokenRevoked(ctx, "expired")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = s.IsTokenRevoked(ctx, "valid")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	removed, err = s.RemoveExpiredRevocations(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
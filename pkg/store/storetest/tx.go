@@ -0,0 +1,100 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWithTxCommit(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	err := s.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateUser(ctx, bob); err != nil {
+			return err
+		}
+
+		if err := tx.CreateGroup(ctx, groupA); err != nil {
+			return err
+		}
+
+		// Changes made earlier in the transaction must be visible to later steps
+		return tx.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleAdmin)
+	})
+	require.NoError(t, err)
+
+	_, err = s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+
+	role, err := s.GetGroupRole(ctx, groupA.Name, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+}
+
+func testWithTxRollback(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{alice}, nil)
+
+	errFailed := errors.New("second step failed")
+
+	err := s.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateUser(ctx, bob); err != nil {
+			return err
+		}
+
+		if _, err := tx.SetUser(ctx, models.User{Username: alice.Username, Email: "new@example.com"}); err != nil {
+			return err
+		}
+
+		return errFailed
+	})
+	assert.Equal(t, errFailed, err)
+
+	_, err = s.GetUser(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	u, err := s.GetUser(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, alice.Email, u.Email)
+
+	n, err := s.CountUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// A failed store operation should abort the transaction in the same way
+	err = s.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateGroup(ctx, groupA); err != nil {
+			return err
+		}
+
+		return tx.AddGroupToUser(ctx, bob.Username, groupA.Name, models.RoleUser)
+	})
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetGroup(ctx, groupA.Name)
+	assert.Equal(t, store.ErrNotExists, err)
+}
+
+func testWithTxNested(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	err := s.WithTx(ctx, func(tx store.AurumStore) error {
+		if err := tx.CreateUser(ctx, bob); err != nil {
+			return err
+		}
+
+		return tx.WithTx(ctx, func(tx store.AurumStore) error {
+			return tx.CreateUser(ctx, alice)
+		})
+	})
+	require.NoError(t, err)
+
+	page, err := s.GetUsers(ctx, models.UserQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Users, 2)
+}
@@ -0,0 +1,261 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/models"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	assert.NoError(t, s.CreateUser(ctx, bob))
+	assert.Equal(t, store.ErrExists, s.CreateUser(ctx, bob))
+
+	// Usernames must be unique, the other fields don't matter
+	assert.Equal(t, store.ErrExists, s.CreateUser(ctx, models.User{Username: bob.Username}))
+
+	assert.NoError(t, s.CreateUser(ctx, alice))
+}
+
+func testGetUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	u, err := s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, bob, u)
+
+	u, err = s.GetUser(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, alice, u)
+
+	_, err = s.GetUser(ctx, "carol")
+	assert.Equal(t, store.ErrNotExists, err)
+}
+
+func testGetUsers(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	page, err := s.GetUsers(ctx, models.UserQuery{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Users)
+	assert.Empty(t, page.NextCursor)
+
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	// Passwords are never listed
+	page, err = s.GetUsers(ctx, models.UserQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.User{
+		{Username: alice.Username, Email: alice.Email},
+		{Username: bob.Username, Email: bob.Email},
+	}, page.Users)
+	assert.Empty(t, page.NextCursor)
+}
+
+func testSetUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	// Empty fields are left unchanged
+	u, err := s.SetUser(ctx, models.User{Username: bob.Username, Email: "new@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{Username: bob.Username, Password: bob.Password, Email: "new@example.com"}, u)
+
+	u, err = s.SetUser(ctx, models.User{Username: bob.Username, Password: "new password"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{Username: bob.Username, Password: "new password", Email: "new@example.com"}, u)
+
+	u, err = s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{Username: bob.Username, Password: "new password", Email: "new@example.com"}, u)
+
+	// Other users are untouched
+	u, err = s.GetUser(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, alice, u)
+
+	_, err = s.SetUser(ctx, models.User{Username: "carol", Email: "carol@example.com"})
+	assert.Equal(t, store.ErrNotExists, err)
+}
+
+func testSetUserEmail(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	assert.NoError(t, s.SetUserEmail(ctx, bob.Username, bob.Email, "new@example.com", true))
+
+	u, err := s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{
+		Username:      bob.Username,
+		Password:      bob.Password,
+		Email:         bob.Email,
+		EmailVerified: true,
+		PendingEmail:  "new@example.com",
+	}, u)
+
+	// Empty values are stored too
+	assert.NoError(t, s.SetUserEmail(ctx, bob.Username, "new@example.com", "", false))
+
+	u, err = s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.User{Username: bob.Username, Password: bob.Password, Email: "new@example.com"}, u)
+
+	// SetUser leaves the verification state alone
+	assert.NoError(t, s.SetUserEmail(ctx, bob.Username, "new@example.com", "", true))
+	u, err = s.SetUser(ctx, models.User{Username: bob.Username, Password: "new password"})
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+
+	// Other users are untouched
+	u, err = s.GetUser(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, alice, u)
+
+	assert.Equal(t, store.ErrNotExists, s.SetUserEmail(ctx, "carol", "carol@example.com", "", false))
+}
+
+func testPasswordChangedAt(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	changed := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	carol := models.User{Username: "carol", Password: "hashed password", PasswordChangedAt: changed}
+	require.NoError(t, s.CreateUser(ctx, carol))
+	seed(t, s, []models.User{bob}, nil)
+
+	u, err := s.GetUser(ctx, carol.Username)
+	assert.NoError(t, err)
+	assert.True(t, changed.Equal(u.PasswordChangedAt), "%v != %v", changed, u.PasswordChangedAt)
+
+	// It's the zero time when not known
+	u, err = s.GetUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.True(t, u.PasswordChangedAt.IsZero())
+
+	// Like the other fields it's left unchanged when empty
+	u, err = s.SetUser(ctx, models.User{Username: carol.Username, Email: "carol@example.com"})
+	assert.NoError(t, err)
+	assert.True(t, changed.Equal(u.PasswordChangedAt), "%v != %v", changed, u.PasswordChangedAt)
+
+	changed = changed.AddDate(0, 1, 0)
+	_, err = s.SetUser(ctx, models.User{Username: carol.Username, Password: "new password", PasswordChangedAt: changed})
+	assert.NoError(t, err)
+
+	u, err = s.GetUser(ctx, carol.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, "new password", u.Password)
+	assert.True(t, changed.Equal(u.PasswordChangedAt), "%v != %v", changed, u.PasswordChangedAt)
+}
+
+func testRemoveUser(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	summary, err := s.RemoveUser(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RemovalSummary{Users: []string{bob.Username}}, summary)
+
+	_, err = s.RemoveUser(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetUser(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	_, err = s.GetUser(ctx, alice.Username)
+	assert.NoError(t, err)
+
+	// The username is free to use again
+	assert.NoError(t, s.CreateUser(ctx, bob))
+}
+
+func testRemoveUserState(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	for _, u := range []models.User{bob, alice} {
+		require.NoError(t, s.CreateSession(ctx, newSession(u.Username, "session", time.Now())))
+		require.NoError(t, s.SetSecondFactor(ctx, models.SecondFactor{Username: u.Username, Secret: "JBSWY3DPEHPK3PXP", Confirmed: true}))
+		require.NoError(t, s.SetPasswordHistory(ctx, u.Username, []string{"hash"}))
+	}
+
+	_, err := s.RemoveUser(ctx, bob.Username)
+	require.NoError(t, err)
+
+	// A new user with the same name starts without any of the state of the old one
+	require.NoError(t, s.CreateUser(ctx, bob))
+
+	sessions, err := s.GetSessions(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, err = s.GetSecondFactor(ctx, bob.Username)
+	assert.Equal(t, store.ErrNotExists, err)
+
+	hashes, err := s.GetPasswordHistory(ctx, bob.Username)
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	// That of other users is untouched
+	sessions, err = s.GetSessions(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	_, err = s.GetSecondFactor(ctx, alice.Username)
+	assert.NoError(t, err)
+
+	hashes, err = s.GetPasswordHistory(ctx, alice.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hash"}, hashes)
+}
+
+func testCountUsers(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	n, err := s.CountUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	seed(t, s, []models.User{bob, alice}, nil)
+
+	n, err = s.CountUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = s.RemoveUser(ctx, bob.Username)
+	assert.NoError(t, err)
+
+	n, err = s.CountUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func testCountPasswordsWithPrefix(t *testing.T, s store.AurumStore) {
+	ctx := context.Background()
+
+	seed(t, s, []models.User{
+		{Username: "bob", Password: "$pepper=1$hash"},
+		{Username: "alice", Password: "$pepper=12$hash"},
+		{Username: "carol", Password: "hash"},
+	}, nil)
+
+	for prefix, expected := range map[string]int{
+		"":            3,
+		"$pepper=":    2,
+		"$pepper=1$":  1,
+		"$pepper=2$":  0,
+		"hash":        1,
+		"$pepper=12$": 1,
+	} {
+		n, err := s.CountPasswordsWithPrefix(ctx, prefix)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, n, prefix)
+	}
+}
@@ -3,15 +3,23 @@ package main
 import (
 	"context"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/finitum/aurum/internal/aurum"
 	"github.com/finitum/aurum/internal/cors"
+	"github.com/finitum/aurum/internal/mail"
+	"github.com/finitum/aurum/internal/passwords"
 	"github.com/finitum/aurum/pkg/config"
+	"github.com/finitum/aurum/pkg/store"
+	"github.com/finitum/aurum/pkg/store/bolt"
 	"github.com/finitum/aurum/pkg/store/dgraph"
+	"github.com/finitum/aurum/pkg/store/memory"
+	"github.com/finitum/aurum/pkg/store/postgres"
 	"github.com/finitum/aurum/services/aurum/routes"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -23,58 +31,115 @@ func main() {
 	ctx := context.Background()
 	cfg := config.GetConfig()
 
-	log.Infof("Starting Aurum")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(ctx, cfg)
+		return
+	}
 
-	var dg *dgraph.DGraph
-	var err error
-	for i := 0; i < 10; i++ {
-		log.Infof("Connecting to DGraph")
-		dg, err = dgraph.New(ctx, cfg.DgraphUrl)
-		if err != nil {
-			log.Errorf("Couldn't create Dgraph client, retrying in 3 seconds: %v", err)
-			time.Sleep(3 * time.Second)
-		} else {
-			log.Infof("Connection with DGraph established")
-			break
+	if len(os.Args) > 1 && os.Args[1] == "convert-breached" {
+		if len(os.Args) != 4 {
+			log.Fatalf("Usage: %s convert-breached <pwned-passwords.txt> <breached list>", os.Args[0])
 		}
+		convertBreached(os.Args[2], os.Args[3])
+		return
 	}
+
+	log.Infof("Starting Aurum")
+
+	db, err := connectStore(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Couldn't create Dgraph client: %v", err)
+		log.Fatalf("Couldn't create store: %v", err)
 	}
 
+	mailer, err := connectMailer(cfg)
+	if err != nil {
+		log.Fatalf("Couldn't create mailer: %v", err)
+	}
 
-	au, err := aurum.New(ctx, dg, cfg)
+	au, err := aurum.New(ctx, db, mailer, cfg)
 	if err != nil {
 		log.Fatalf("Couldn't create Aurum client: %v", err)
 	}
 
+	// Unless shared, the rate limits are kept by this replica alone
+	var limitStore store.AurumStore = memory.New()
+	if cfg.RateLimitShared {
+		limitStore = db
+	}
+	limits := routes.NewRateLimiter(limitStore, cfg.PublicKey)
+
+	go collectExpired(ctx, au, limitStore, cfg.RevocationGCInterval)
+
 	r := chi.NewRouter()
 	r.Use(middleware.StripSlashes)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(cors.AllowAll)
+	r.Use(limits.ByIP("ip", routes.PerMinute(cfg.RateLimitIP)))
 
 	rs := routes.NewRoutes(au, cfg)
 
 	r.Get("/pk", rs.PublicKey)
 
-	r.Post("/signup", rs.SignUp)
-	r.Post("/login", rs.Login)
-	r.Post("/refresh", rs.Refresh)
+	r.With(limits.ByIP("signup", routes.PerMinute(cfg.RateLimitSignUp))).Post("/signup", rs.SignUp)
+	r.With(limits.ByIP("login", routes.PerMinute(cfg.RateLimitLogin))).Post("/login", rs.Login)
+	r.Post("/login/challenge", rs.CompleteChallenge)
+	r.Post("/login/password", rs.ChangeExpiredPassword)
+	r.With(limits.ByIP("refresh", routes.PerMinute(cfg.RateLimitRefresh))).Post("/refresh", rs.Refresh)
+	r.Post("/logout", rs.Logout)
+	r.Get("/revoked/{jti}", rs.GetRevocation)
+
+	r.With(limits.ByIP("forgot", routes.PerMinute(cfg.RateLimitForgot))).Post("/password/forgot", rs.ForgotPassword)
+	r.Post("/password/reset", rs.ResetPassword)
+	r.Get("/password/policy", rs.GetPasswordPolicy)
 
-	r.Get("/group/{group}/{user}", rs.GetAccess)
+	r.Post("/email/verify", rs.VerifyEmail)
+
+	r.With(limits.ByIP("access", routes.PerMinute(cfg.RateLimitAccess))).Get("/group/{group}/{user}", rs.GetAccess)
 
 	r.Group(func(r chi.Router) {
 		r.Use(rs.TokenExtractionMiddleware)
+		r.Use(limits.ByUser("user", routes.PerMinute(cfg.RateLimitUser)))
 
 		r.Get("/user", rs.GetMe)
 		r.Post("/user", rs.SetUser)
+		r.Post("/email/verify/resend", rs.ResendVerification)
 		r.Get("/user/{user}/groups", rs.GetGroupsForUser)
+		r.Get("/password/policy/user", rs.GetUserPasswordPolicy)
+
+		// Sessions
+		r.Get("/sessions", rs.GetSessions)
+		r.Delete("/sessions", rs.RevokeOtherSessions)
+		r.Delete("/sessions/{session}", rs.RevokeSession)
+
+		// Two-factor authentication
+		r.Post("/totp", rs.EnrollTOTP)
+		r.Post("/totp/confirm", rs.ConfirmTOTP)
+		r.Post("/totp/disable", rs.DisableTOTP)
+
+		// User management (Aurum admins only)
+		r.Get("/users", rs.GetUsers)
+		r.Get("/user/{user}", rs.LookupUser)
+		r.Post("/user/{user}", rs.AdminSetUser)
+		r.Delete("/user/{user}", rs.RemoveUser)
+		r.Get("/user/{user}/sessions", rs.GetUserSessions)
+		r.Delete("/user/{user}/sessions", rs.RevokeUserSessions)
+		r.Delete("/user/{user}/sessions/{session}", rs.RevokeUserSession)
+		r.Get("/user/{user}/lockout", rs.GetUserLockout)
+		r.Delete("/user/{user}/lockout", rs.UnlockUser)
+		r.Get("/lockouts", rs.GetLockouts)
+		r.Delete("/lockouts/{address}", rs.UnlockAddress)
 
 		// Group
+		r.Get("/groups", rs.GetGroups)
 		r.Post("/group", rs.AddGroup)
+		r.Get("/group/{group}", rs.GetGroup)
+		r.Post("/group/{group}", rs.UpdateGroup)
 		r.Delete("/group/{group}", rs.RemoveGroup)
 
+		// Takes precedence over GET /group/{group}/{user}, as chi prefers static segments
+		r.Get("/group/{group}/members", rs.GetGroupMembers)
+
 		r.Put("/group/{group}/{user}", rs.SetAccess)
 		r.Post("/group/{group}/{user}", rs.AddUserToGroup)
 		r.Delete("/group/{group}/{user}", rs.RemoveUserFromGroup)
@@ -90,3 +155,152 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// collectExpired periodically removes the token revocations, sessions, login failures and
+// rate limits which have expired
+func collectExpired(ctx context.Context, au aurum.Aurum, limits store.AurumStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := au.RemoveExpiredRevocations(ctx)
+			if err != nil {
+				log.Errorf("Couldn't remove expired token revocations: %v", err)
+			} else if n > 0 {
+				log.Debugf("Removed %d expired token revocations", n)
+			}
+
+			n, err = au.RemoveExpiredSessions(ctx)
+			if err != nil {
+				log.Errorf("Couldn't remove expired sessions: %v", err)
+			} else if n > 0 {
+				log.Debugf("Removed %d expired sessions", n)
+			}
+
+			n, err = au.RemoveExpiredLoginFailures(ctx)
+			if err != nil {
+				log.Errorf("Couldn't remove expired login failures: %v", err)
+			} else if n > 0 {
+				log.Debugf("Removed %d expired login failures", n)
+			}
+
+			n, err = au.RemoveExpiredCooldowns(ctx)
+			if err != nil {
+				log.Errorf("Couldn't remove expired password reset cooldowns: %v", err)
+			} else if n > 0 {
+				log.Debugf("Removed %d expired password reset cooldowns", n)
+			}
+
+			n, err = limits.RemoveExpiredRateLimits(ctx, time.Now())
+			if err != nil {
+				log.Errorf("Couldn't remove expired rate limits: %v", err)
+			} else if n > 0 {
+				log.Debugf("Removed %d expired rate limits", n)
+			}
+		}
+	}
+}
+
+// connectStore creates the database backend selected in the config
+func connectStore(ctx context.Context, cfg *config.Config) (store.AurumStore, error) {
+	switch cfg.Store {
+	case "postgres":
+		log.Infof("Connecting to Postgres")
+		pg, err := postgres.New(ctx, cfg.PostgresUrl)
+		if err != nil {
+			return nil, err
+		}
+		return pg, nil
+	case "bolt":
+		log.Infof("Opening database %s", cfg.BoltPath)
+		b, err := bolt.New(cfg.BoltPath)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "memory":
+		log.Warnf("Using in-memory store, nothing will be persisted")
+		return memory.New(), nil
+	case "dgraph", "":
+		open := dgraph.New
+		if cfg.NoMigrate {
+			open = dgraph.Connect
+		}
+
+		var dg *dgraph.DGraph
+		var err error
+		for i := 0; i < 10; i++ {
+			log.Infof("Connecting to DGraph")
+			dg, err = open(ctx, cfg.DgraphUrl)
+			if err != nil {
+				log.Errorf("Couldn't create Dgraph client, retrying in 3 seconds: %v", err)
+				time.Sleep(3 * time.Second)
+			} else {
+				log.Infof("Connection with DGraph established")
+				break
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't create Dgraph client")
+		}
+		return dg, nil
+	default:
+		return nil, errors.Errorf("unknown store %q", cfg.Store)
+	}
+}
+
+// connectMailer creates the mailer selected in the config
+func connectMailer(cfg *config.Config) (mail.Mailer, error) {
+	switch cfg.Mailer {
+	case "smtp":
+		log.Infof("Sending mail through %s", cfg.SMTPAddr)
+		return mail.NewSMTPMailer(cfg.SMTPAddr, cfg.MailFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	case "log", "":
+		log.Warnf("Writing mail to %s instead of sending it", cfg.MailLogPath)
+		return mail.NewFileMailer(cfg.MailLogPath, cfg.MailFrom), nil
+	default:
+		return nil, errors.Errorf("unknown mailer %q", cfg.Mailer)
+	}
+}
+
+// migrate migrates the database to the latest schema version, without starting Aurum
+func migrate(ctx context.Context, cfg *config.Config) {
+	// Every store migrates when connecting unless told otherwise
+	cfg.NoMigrate = false
+
+	if _, err := connectStore(ctx, cfg); err != nil {
+		log.Fatalf("Couldn't migrate store: %v", err)
+	}
+
+	log.Infof("Database migrated")
+}
+
+// convertBreached converts the Pwned Passwords list "ordered by hash" to a list for BREACHED_PASSWORDS_PATH
+func convertBreached(in, out string) {
+	r, err := os.Open(in)
+	if err != nil {
+		log.Fatalf("Couldn't open %s: %v", in, err)
+	}
+	defer r.Close()
+
+	w, err := os.Create(out)
+	if err != nil {
+		log.Fatalf("Couldn't create %s: %v", out, err)
+	}
+
+	n, err := passwords.ConvertBreachedList(w, r)
+	if err != nil {
+		_ = w.Close()
+		log.Fatalf("Couldn't convert breached passwords: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		log.Fatalf("Couldn't write %s: %v", out, err)
+	}
+
+	log.Infof("Converted %d breached passwords", n)
+}
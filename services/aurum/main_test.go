@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/finitum/aurum/clients/go"
 	internal "github.com/finitum/aurum/internal/aurum"
+	"github.com/finitum/aurum/internal/totp"
+	"github.com/finitum/aurum/pkg/api"
 	"github.com/finitum/aurum/pkg/store/dgraph"
 	"github.com/stretchr/testify/assert"
 
@@ -44,7 +47,7 @@ func VerifyGetUser(assert *assert.Assertions, client aurum.Client, tp jwt.TokenP
 	assert.Equal(expected.Email, user.Email)
 }
 
-func VerifyRefresh(assert *assert.Assertions, client aurum.Client, tp jwt.TokenPair, u models.User, pk ecc.PublicKey) {
+func VerifyRefresh(assert *assert.Assertions, client aurum.Client, tp *jwt.TokenPair, u models.User, pk ecc.PublicKey) {
 	oldClaims, err := jwt.VerifyJWT(tp.LoginToken, pk)
 	assert.NoError(err)
 
@@ -61,15 +64,89 @@ func VerifyRefresh(assert *assert.Assertions, client aurum.Client, tp jwt.TokenP
 
 	var rtp jwt.TokenPair
 	err = json.NewDecoder(resp.Body).Decode(&rtp)
-	assert.Empty(rtp.RefreshToken)
+	assert.NoError(err)
+
+	// The refresh token is rotated
+	assert.NotEmpty(rtp.RefreshToken)
+	assert.NotEqual(tp.RefreshToken, rtp.RefreshToken)
 
 	newClaims, err := jwt.VerifyJWT(rtp.LoginToken, pk)
 	assert.NoError(err)
 
 	assert.True(oldClaims.IssuedAt < newClaims.IssuedAt)
 
-	tp.LoginToken = rtp.LoginToken
-	VerifyGetUser(assert, client, tp, u)
+	*tp = rtp
+	VerifyGetUser(assert, client, *tp, u)
+}
+
+func VerifyRefreshReuse(assert *assert.Assertions, client aurum.Client, u models.User) {
+	tp := VerifyLogin(assert, client, u)
+	old := tp
+
+	err := client.Refresh(&tp)
+	assert.NoError(err)
+
+	// Presenting the rotated refresh token again revokes the whole family
+	err = client.Refresh(&old)
+	assert.Error(err)
+
+	err = client.Refresh(&tp)
+	assert.Error(err)
+}
+
+func VerifySessions(assert *assert.Assertions, client aurum.Client, tp, admin jwt.TokenPair, u models.User) {
+	other := VerifyLogin(assert, client, u)
+
+	sessions, err := client.GetSessions(&tp)
+	assert.NoError(err)
+	assert.Len(sessions, 2)
+
+	var revoke string
+	for _, s := range sessions {
+		if !s.Current {
+			revoke = s.ID
+		}
+	}
+	assert.NotEmpty(revoke)
+
+	err = client.RevokeSession(&tp, revoke)
+	assert.NoError(err)
+
+	// The tokens of the revoked session can't be used anymore
+	_, err = client.GetUserInfo(&other)
+	assert.Error(err)
+
+	sessions, err = client.GetUserSessions(&admin, u.Username)
+	assert.NoError(err)
+	assert.Len(sessions, 1)
+}
+
+func VerifyTwoFactor(assert *assert.Assertions, client aurum.Client, tp jwt.TokenPair, u models.User) {
+	enrollment, err := client.EnrollTOTP(&tp)
+	assert.NoError(err)
+
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	assert.NoError(err)
+
+	codes, err := client.ConfirmTOTP(&tp, code)
+	assert.NoError(err)
+	assert.NotEmpty(codes.Codes)
+
+	// Logging in now requires a second factor
+	_, err = client.Login(u.Username, u.Password)
+	var challenge *api.ChallengeError
+	if !assert.True(errors.As(err, &challenge)) {
+		return
+	}
+
+	other, err := client.LoginWithCode(challenge.Challenge, codes.Codes[0])
+	assert.NoError(err)
+	VerifyGetUser(assert, client, *other, u)
+
+	err = client.DisableTOTP(&tp, codes.Codes[1])
+	assert.NoError(err)
+
+	VerifyLogin(assert, client, u)
 }
 
 func VerifyUpdateUserPasswordEmail(assert *assert.Assertions, client aurum.Client, tp jwt.TokenPair, u models.User) {
@@ -83,11 +160,13 @@ func VerifyUpdateUserPasswordEmail(assert *assert.Assertions, client aurum.Clien
 	assert.NoError(err)
 
 	assert.Equal(u.Username, resp.Username)
-	assert.Equal(newuser.Email, resp.Email)
 	assert.Empty(resp.Password)
 
+	// The old address stays in effect until the new one is verified
+	assert.Equal(u.Email, resp.Email)
+	assert.Equal(newuser.Email, resp.PendingEmail)
+
 	u.Password = newuser.Password
-	u.Email = newuser.Email
 
 	time.Sleep(2 * time.Second)
 
@@ -187,8 +266,9 @@ func TestSystemIntegration(t *testing.T) {
 	VerifyGetUser(assert, client, tpUserOne, userOne)
 	VerifyGetUser(assert, client, tpUserTwo, userTwo)
 
-	VerifyRefresh(assert, client, tpUserOne, userOne, pub)
-	VerifyRefresh(assert, client, tpUserTwo, userTwo, pub)
+	VerifyRefresh(assert, client, &tpUserOne, userOne, pub)
+	VerifyRefresh(assert, client, &tpUserTwo, userTwo, pub)
+	VerifyRefreshReuse(assert, client, userOne)
 
 	VerifyUpdateUserPasswordEmail(assert, client, tpUserOne, userOne)
 	VerifyUpdateUserPasswordEmail(assert, client, tpUserTwo, userTwo)
@@ -228,6 +308,16 @@ func TestSystemIntegration(t *testing.T) {
 
 	VerifyAccess(assert, client, group, userTwo, models.RoleUser)
 
+	members, err := client.GetGroupMembers(&tpUserOne, group.Name, models.MemberQuery{})
+	assert.NoError(err)
+	assert.Len(members.Members, 2)
+
+	// Regular members only see the admins of a group
+	members, err = client.GetGroupMembers(&tpUserTwo, group.Name, models.MemberQuery{})
+	assert.NoError(err)
+	assert.Len(members.Members, 1)
+	assert.Equal(models.RoleAdmin, members.Members[0].Role)
+
 	err = client.SetAccess(&tpUserOne, models.AccessStatus{
 		GroupName:     group.Name,
 		Username:      userTwo.Username,
@@ -249,4 +339,13 @@ func TestSystemIntegration(t *testing.T) {
 	assert.NoError(err)
 	time.Sleep(time.Second)
 	VerifyNoAccess(assert, client, group, userOne)
+
+	VerifySessions(assert, client, tpUserTwo, tpUserOne, userTwo)
+	VerifyTwoFactor(assert, client, tpUserTwo, userTwo)
+
+	// After logging out neither token can be used anymore
+	err = client.Logout(&tpUserTwo)
+	assert.NoError(err)
+	_, err = client.GetUserInfo(&tpUserTwo)
+	assert.Error(err)
 }
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/finitum/aurum/pkg/models"
+)
+
+// POST /email/verify
+// Confirms an email address with the token from a verification mail.
+func (rs Routes) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var ev models.EmailVerification
+
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	if err := rs.au.VerifyEmail(r.Context(), ev.Token); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /email/verify/resend (Authenticated)
+// Mails a new verification link for the pending or unverified address of the user.
+func (rs Routes) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.ResendVerification(r.Context(), token); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -34,6 +34,82 @@ func (rs Routes) AddGroup(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(&group)
 }
 
+// GET /groups (Authenticated)
+func (rs Routes) GetGroups(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseGroupQuery(r.URL.Query())
+	if err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	token := TokenFromContext(r.Context())
+
+	page, err := rs.au.GetGroups(r.Context(), token, query)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&page)
+}
+
+// GET /group/{group} (Authenticated)
+func (rs Routes) GetGroup(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "group")
+
+	token := TokenFromContext(r.Context())
+
+	group, err := rs.au.GetGroup(r.Context(), token, name)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&group)
+}
+
+// POST /group/{group} (Authenticated)
+func (rs Routes) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "group")
+
+	var update models.GroupUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	token := TokenFromContext(r.Context())
+
+	group, err := rs.au.UpdateGroup(r.Context(), token, name, update)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&group)
+}
+
+// GET /group/{group}/members (Authenticated)
+func (rs Routes) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
+	group := chi.URLParam(r, "group")
+
+	query, err := models.ParseMemberQuery(r.URL.Query())
+	if err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	token := TokenFromContext(r.Context())
+
+	page, err := rs.au.GetGroupMembers(r.Context(), token, group, query)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&page)
+}
+
 // DELETE /group/{group} (Authenticated)
 func (rs Routes) RemoveGroup(w http.ResponseWriter, r *http.Request) {
 	group := chi.URLParam(r, "group")
@@ -44,7 +120,8 @@ func (rs Routes) RemoveGroup(w http.ResponseWriter, r *http.Request) {
 
 	token := TokenFromContext(r.Context())
 
-	if err := rs.au.RemoveGroup(r.Context(), token, group); err != nil {
+	summary, err := rs.au.RemoveGroup(r.Context(), token, group)
+	if err != nil {
 		if err == store.ErrExists {
 			_ = RenderError(w, err, Duplicate)
 			return
@@ -56,6 +133,8 @@ func (rs Routes) RemoveGroup(w http.ResponseWriter, r *http.Request) {
 		_ = RenderError(w, err, ServerError)
 		return
 	}
+
+	_ = json.NewEncoder(w).Encode(&summary)
 }
 
 // GET /group/{group}/{user}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// GET /lockouts (Authenticated)
+// Lists the accounts and source addresses which are locked because of failed logins.
+func (rs Routes) GetLockouts(w http.ResponseWriter, r *http.Request) {
+	token := TokenFromContext(r.Context())
+
+	lockouts, err := rs.au.GetLockouts(r.Context(), token)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&lockouts)
+}
+
+// DELETE /lockouts/{address} (Authenticated)
+func (rs Routes) UnlockAddress(w http.ResponseWriter, r *http.Request) {
+	address := chi.URLParam(r, "address")
+
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.UnlockAddress(r.Context(), token, address); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /user/{user}/lockout (Authenticated)
+func (rs Routes) GetUserLockout(w http.ResponseWriter, r *http.Request) {
+	user := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	lockout, err := rs.au.GetUserLockout(r.Context(), token, user)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&lockout)
+}
+
+// DELETE /user/{user}/lockout (Authenticated)
+func (rs Routes) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	user := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.UnlockUser(r.Context(), token, user); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
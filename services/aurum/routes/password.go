@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/finitum/aurum/pkg/models"
+)
+
+// POST /password/forgot
+// Mails a password reset token to the user. Always responds with 202 Accepted,
+// so it can't be used to find out which users exist.
+func (rs Routes) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var pf models.PasswordForgot
+
+	if err := json.NewDecoder(r.Body).Decode(&pf); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	if err := rs.au.ForgotPassword(r.Context(), pf.Username); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// POST /password/reset
+func (rs Routes) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var pr models.PasswordReset
+
+	if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	if err := rs.au.ResetPassword(r.Context(), pr); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /login/password
+// Replaces an expired password with the token from the login, and responds like the login would have
+func (rs Routes) ChangeExpiredPassword(w http.ResponseWriter, r *http.Request) {
+	var pc models.PasswordChange
+
+	if err := json.NewDecoder(r.Body).Decode(&pc); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	resp, err := rs.au.ChangeExpiredPassword(r.Context(), pc, clientInfo(r))
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&resp)
+}
+
+// GET /password/policy
+// The rules the passwords of new users have to follow
+func (rs Routes) GetPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := rs.au.GetPasswordPolicy(r.Context())
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&policy)
+}
+
+// GET /password/policy/user (Authenticated)
+// The rules a new password of the user has to follow, including those of their groups
+func (rs Routes) GetUserPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	token := TokenFromContext(r.Context())
+
+	policy, err := rs.au.GetUserPasswordPolicy(r.Context(), token)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&policy)
+}
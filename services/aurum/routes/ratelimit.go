@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrRateLimited = errors.New("too many requests, try again later")
+
+// RateLimit describes a token bucket: it holds up to Burst tokens and gains one every Interval.
+// Every request takes a token, and requests are refused while the bucket is empty.
+// The zero RateLimit doesn't limit anything.
+type RateLimit struct {
+	Burst    int
+	Interval time.Duration
+}
+
+// PerMinute is a rate limit of n requests per minute, which can all be made at once.
+// When n isn't positive nothing is limited.
+func PerMinute(n int) RateLimit {
+	if n <= 0 {
+		return RateLimit{}
+	}
+
+	return RateLimit{Burst: n, Interval: time.Minute / time.Duration(n)}
+}
+
+// RateLimitStore keeps the token buckets. Every store.AurumStore is one, so the buckets can be
+// shared by all replicas using the same database, or kept in a memory.Memory of just this one.
+type RateLimitStore interface {
+	TakeRateLimitToken(ctx context.Context, key string, burst int, interval time.Duration, now time.Time) (time.Duration, error)
+}
+
+// RateLimiter provides middleware which limits the rate of requests
+type RateLimiter struct {
+	store RateLimitStore
+	pk    ecc.PublicKey
+}
+
+func NewRateLimiter(store RateLimitStore, pk ecc.PublicKey) RateLimiter {
+	return RateLimiter{store, pk}
+}
+
+// limit limits the requests for which key returns the same value, using a bucket per name
+// and key. Requests for which key returns the empty string aren't limited.
+func (rl RateLimiter) limit(name string, limit RateLimit, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Burst <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			retry, err := rl.store.TakeRateLimitToken(r.Context(), name+":"+k, limit.Burst, limit.Interval, time.Now())
+			if err != nil {
+				// Rather than refusing every request when the store fails, nothing is limited
+				log.Errorf("Couldn't take rate limit token: %v", err)
+			} else if retry > 0 {
+				seconds := int((retry + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				_ = RenderError(w, ErrRateLimited, RateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ByIP limits requests per client address
+func (rl RateLimiter) ByIP(name string, limit RateLimit) func(http.Handler) http.Handler {
+	return rl.limit(name, limit, func(r *http.Request) string {
+		return clientInfo(r).IP
+	})
+}
+
+// ByUser limits requests per user, it must be used after the TokenExtractionMiddleware.
+// Requests without a valid token aren't limited, as they will be refused anyway.
+func (rl RateLimiter) ByUser(name string, limit RateLimit) func(http.Handler) http.Handler {
+	return rl.limit(name, limit, func(r *http.Request) string {
+		claims, err := jwt.VerifyJWT(TokenFromContext(r.Context()), rl.pk)
+		if err != nil {
+			return ""
+		}
+
+		return claims.Username
+	})
+}
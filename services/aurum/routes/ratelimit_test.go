@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/finitum/aurum/pkg/jwt"
+	"github.com/finitum/aurum/pkg/jwt/ecc"
+	"github.com/finitum/aurum/pkg/store/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func request(h http.Handler, addr, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = addr
+	if token != "" {
+		r = r.WithContext(context.WithValue(r.Context(), contextKeyToken, token))
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestPerMinute(t *testing.T) {
+	assert.Equal(t, RateLimit{Burst: 10, Interval: 6 * time.Second}, PerMinute(10))
+	assert.Equal(t, RateLimit{}, PerMinute(0))
+	assert.Equal(t, RateLimit{}, PerMinute(-1))
+}
+
+func TestRateLimiterByIP(t *testing.T) {
+	pk, _, err := ecc.GenerateKey()
+	require.NoError(t, err)
+
+	h := NewRateLimiter(memory.New(), pk).ByIP("test", RateLimit{Burst: 2, Interval: time.Minute})(ok)
+
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1234", "").Code)
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1235", "").Code)
+
+	w := request(h, "10.0.0.1:1236", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Other addresses have their own bucket
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.2:1234", "").Code)
+}
+
+func TestRateLimiterByUser(t *testing.T) {
+	pk, sk, err := ecc.GenerateKey()
+	require.NoError(t, err)
+
+	token, err := jwt.GenerateJWT("jan", false, sk)
+	require.NoError(t, err)
+	other, err := jwt.GenerateJWT("piet", false, sk)
+	require.NoError(t, err)
+
+	h := NewRateLimiter(memory.New(), pk).ByUser("test", RateLimit{Burst: 1, Interval: time.Minute})(ok)
+
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1234", token).Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(h, "10.0.0.2:1234", token).Code)
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1234", other).Code)
+
+	// Requests without a valid token are left to the handler
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1234", "invalid").Code)
+	assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1234", "invalid").Code)
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	pk, _, err := ecc.GenerateKey()
+	require.NoError(t, err)
+
+	h := NewRateLimiter(memory.New(), pk).ByIP("test", PerMinute(0))(ok)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusNoContent, request(h, "10.0.0.1:1234", "").Code)
+	}
+}
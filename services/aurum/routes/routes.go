@@ -31,6 +31,11 @@ const (
 	WeakPassword
 	Unauthorized
 	NotFound
+	EmailNotVerified
+	LockedOut
+	RateLimited
+	BreachedPassword
+	PasswordReused
 )
 
 type ErrorResponse struct {
@@ -45,12 +50,20 @@ func AutomaticRenderError(w http.ResponseWriter, err error) error {
 		code = Duplicate
 	case store.ErrNotExists:
 		code = NotFound
-	case aurum.ErrInvalidInput:
+	case aurum.ErrInvalidInput, store.ErrInvalidQuery:
 		code = InvalidRequest
 	case aurum.ErrWeakPassword:
 		code = WeakPassword
+	case aurum.ErrBreachedPassword:
+		code = BreachedPassword
+	case aurum.ErrPasswordReused:
+		code = PasswordReused
 	case aurum.ErrUnauthorized:
 		code = Unauthorized
+	case aurum.ErrEmailNotVerified:
+		code = EmailNotVerified
+	case aurum.ErrLockedOut:
+		code = LockedOut
 	}
 
 	return RenderError(w, err, code)
@@ -64,8 +77,12 @@ func RenderError(w http.ResponseWriter, err error, code ErrorCode) error {
 		w.WriteHeader(http.StatusConflict)
 	case Unauthorized:
 		w.WriteHeader(http.StatusUnauthorized)
-	case InvalidRequest, WeakPassword:
+	case InvalidRequest, WeakPassword, BreachedPassword, PasswordReused:
 		w.WriteHeader(http.StatusBadRequest)
+	case EmailNotVerified:
+		w.WriteHeader(http.StatusForbidden)
+	case LockedOut, RateLimited:
+		w.WriteHeader(http.StatusTooManyRequests)
 	case ServerError:
 		fallthrough
 	default:
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/finitum/aurum/internal/aurum"
+	"github.com/go-chi/chi"
+)
+
+// clientInfo describes the client which made the request
+func clientInfo(r *http.Request) aurum.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return aurum.ClientInfo{IP: ip, UserAgent: r.UserAgent()}
+}
+
+// GET /sessions (Authenticated)
+func (rs Routes) GetSessions(w http.ResponseWriter, r *http.Request) {
+	token := TokenFromContext(r.Context())
+
+	sessions, err := rs.au.GetSessions(r.Context(), token)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&sessions)
+}
+
+// DELETE /sessions (Authenticated)
+// Revokes all sessions except the one the request is made from.
+func (rs Routes) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.RevokeOtherSessions(r.Context(), token); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /sessions/{session} (Authenticated)
+func (rs Routes) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "session")
+
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.RevokeSession(r.Context(), token, id); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /user/{user}/sessions (Authenticated)
+func (rs Routes) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	user := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	sessions, err := rs.au.GetUserSessions(r.Context(), token, user)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&sessions)
+}
+
+// DELETE /user/{user}/sessions (Authenticated)
+func (rs Routes) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	user := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.RevokeUserSessions(r.Context(), token, user); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /user/{user}/sessions/{session} (Authenticated)
+func (rs Routes) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	user := chi.URLParam(r, "user")
+	id := chi.URLParam(r, "session")
+
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.RevokeUserSession(r.Context(), token, user, id); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/finitum/aurum/pkg/models"
+)
+
+// POST /login/challenge
+// Completes a login which requires a second factor, responding like the login would have without one.
+func (rs Routes) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
+	var cr models.ChallengeResponse
+
+	if err := json.NewDecoder(r.Body).Decode(&cr); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	resp, err := rs.au.CompleteChallenge(r.Context(), cr, clientInfo(r))
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&resp)
+}
+
+// POST /totp (Authenticated)
+// Starts setting up TOTP, responding with the secret to add to an authenticator.
+func (rs Routes) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	token := TokenFromContext(r.Context())
+
+	enrollment, err := rs.au.EnrollTOTP(r.Context(), token)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(&enrollment)
+}
+
+// POST /totp/confirm (Authenticated)
+// Enables TOTP with a first code, responding with the recovery codes.
+func (rs Routes) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var code models.TOTPCode
+
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	token := TokenFromContext(r.Context())
+
+	codes, err := rs.au.ConfirmTOTP(r.Context(), token, code.Code, clientInfo(r))
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&codes)
+}
+
+// POST /totp/disable (Authenticated)
+func (rs Routes) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var code models.TOTPCode
+
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	token := TokenFromContext(r.Context())
+
+	if err := rs.au.DisableTOTP(r.Context(), token, code.Code, clientInfo(r)); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
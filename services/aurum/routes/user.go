@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/finitum/aurum/internal/aurum"
 	"github.com/finitum/aurum/pkg/jwt"
 	"github.com/finitum/aurum/pkg/models"
 	"github.com/go-chi/chi"
@@ -26,6 +27,8 @@ func (rs Routes) SignUp(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// POST /login
+// Responds with a token pair, or with a challenge when the user has to supply a second factor.
 func (rs Routes) Login(w http.ResponseWriter, r *http.Request) {
 	var u models.User
 
@@ -34,15 +37,20 @@ func (rs Routes) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tp, err := rs.au.Login(r.Context(), u)
-	if err != nil {
+	resp, err := rs.au.Login(r.Context(), u, clientInfo(r))
+	if err == aurum.ErrLockedOut {
+		_ = RenderError(w, err, LockedOut)
+		return
+	} else if err != nil {
 		_ = RenderError(w, err, Unauthorized)
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(&tp)
+	_ = json.NewEncoder(w).Encode(&resp)
 }
 
+// POST /refresh
+// Responds with a new token pair, the refresh token in the request can't be used again.
 func (rs Routes) Refresh(w http.ResponseWriter, r *http.Request) {
 	var tp jwt.TokenPair
 
@@ -51,17 +59,46 @@ func (rs Routes) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := rs.au.RefreshToken(&tp)
+	err := rs.au.RefreshToken(r.Context(), &tp, clientInfo(r))
 	if err != nil {
 		_ = AutomaticRenderError(w, err)
 		return
 	}
 
-	tp.RefreshToken = ""
-
 	_ = json.NewEncoder(w).Encode(&tp)
 }
 
+// POST /logout
+func (rs Routes) Logout(w http.ResponseWriter, r *http.Request) {
+	var tp jwt.TokenPair
+
+	if err := json.NewDecoder(r.Body).Decode(&tp); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	if err := rs.au.Logout(r.Context(), tp); err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /revoked/{jti}?family={family}
+func (rs Routes) GetRevocation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "jti")
+	family := r.URL.Query().Get("family")
+
+	revoked, err := rs.au.IsTokenRevoked(r.Context(), id, family)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&models.RevocationStatus{ID: id, Revoked: revoked})
+}
+
 func (rs Routes) GetMe(w http.ResponseWriter, r *http.Request) {
 	token := TokenFromContext(r.Context())
 
@@ -106,3 +143,73 @@ func (rs Routes) GetGroupsForUser(w http.ResponseWriter, r *http.Request) {
 
 	_ = json.NewEncoder(w).Encode(&grouproles)
 }
+
+// DELETE /user/{user} (Authenticated)
+func (rs Routes) RemoveUser(w http.ResponseWriter, r *http.Request) {
+	user := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	summary, err := rs.au.RemoveUser(r.Context(), token, user)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&summary)
+}
+
+// GET /users (Authenticated)
+func (rs Routes) GetUsers(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseUserQuery(r.URL.Query())
+	if err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	token := TokenFromContext(r.Context())
+
+	page, err := rs.au.GetUsers(r.Context(), token, query)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&page)
+}
+
+// GET /user/{user} (Authenticated)
+func (rs Routes) LookupUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	user, err := rs.au.LookupUser(r.Context(), token, username)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&user)
+}
+
+// POST /user/{user} (Authenticated)
+func (rs Routes) AdminSetUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "user")
+
+	token := TokenFromContext(r.Context())
+
+	var u models.User
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		_ = RenderError(w, err, InvalidRequest)
+		return
+	}
+
+	user, err := rs.au.AdminUpdateUser(r.Context(), token, username, u)
+	if err != nil {
+		_ = AutomaticRenderError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(&user)
+}